@@ -5,7 +5,6 @@ package kunstruct
 
 import (
 	"encoding/json"
-	"fmt"
 
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -21,7 +20,8 @@ func NewKustHash() *kustHash {
 	return &kustHash{}
 }
 
-// Hash returns a hash of either a ConfigMap or a Secret
+// Hash returns a hash of a ConfigMap, a Secret, or
+// the content of any other kind of object.
 func (h *kustHash) Hash(m ifc.Kunstructured) (string, error) {
 	u := unstructured.Unstructured{
 		Object: m.Map(),
@@ -42,12 +42,31 @@ func (h *kustHash) Hash(m ifc.Kunstructured) (string, error) {
 		}
 		return secretHash(sec)
 	default:
-		return "", fmt.Errorf(
-			"type %s is not supported for hashing in %v",
-			kind, m.Map())
+		return objectHash(u)
 	}
 }
 
+// objectHash returns a hash of an object of any kind.
+// The Kind, Name and all but metadata and status are
+// taken into account.
+func objectHash(u unstructured.Unstructured) (string, error) {
+	content := make(map[string]interface{})
+	for k, v := range u.Object {
+		switch k {
+		case "apiVersion", "kind", "metadata", "status":
+		default:
+			content[k] = v
+		}
+	}
+	// json.Marshal sorts the keys in a stable order in the encoding
+	data, err := json.Marshal(map[string]interface{}{
+		"kind": u.GetKind(), "name": u.GetName(), "content": content})
+	if err != nil {
+		return "", err
+	}
+	return hasher.Encode(hasher.Hash(string(data)))
+}
+
 // configMapHash returns a hash of the ConfigMap.
 // The Data, Kind, and Name are taken into account.
 func configMapHash(cm *v1.ConfigMap) (string, error) {
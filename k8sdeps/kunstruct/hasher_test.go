@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestConfigMapHash(t *testing.T) {
@@ -71,6 +72,46 @@ func TestSecretHash(t *testing.T) {
 	}
 }
 
+func TestObjectHash(t *testing.T) {
+	job := func(name, image string, labels map[string]interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": labels,
+			},
+			"spec": map[string]interface{}{
+				"image": image,
+			},
+			"status": map[string]interface{}{
+				"active": int64(1),
+			},
+		}}
+	}
+	h, err := objectHash(job("migrate", "db:1", nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(h) != 10 {
+		t.Fatalf("unexpected hash %q", h)
+	}
+	same, _ := objectHash(job("migrate", "db:1",
+		map[string]interface{}{"app": "db"}))
+	if same != h {
+		t.Errorf("metadata other than the name changed the hash")
+	}
+	for _, other := range []unstructured.Unstructured{
+		job("migrate", "db:2", nil),
+		job("migrate2", "db:1", nil),
+	} {
+		o, _ := objectHash(other)
+		if o == h {
+			t.Errorf("expected a different hash for %v", other.Object)
+		}
+	}
+}
+
 func TestEncodeConfigMap(t *testing.T) {
 	cases := []struct {
 		desc   string
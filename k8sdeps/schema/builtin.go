@@ -0,0 +1,180 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package schema provides OpenAPI schemas for the built-in
+// Kubernetes kinds, derived from the Go types registered
+// in the client-go scheme.
+package schema
+
+//go:generate go run ./gen
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/spec"
+	"github.com/irairdon/kustomize/v3/pkg/gvk"
+	"github.com/irairdon/kustomize/v3/pkg/openapi"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+var (
+	builtinOnce    sync.Once
+	builtinSchemas *openapi.Schemas
+)
+
+// NewBuiltinSchemas returns schemas for all the kinds known
+// to the client-go scheme.  Definitions are named after their
+// Go types, e.g. "k8s.io/api/apps/v1.Deployment", so that
+// CRD definitions can refer to them with $ref.
+func NewBuiltinSchemas() *openapi.Schemas {
+	builtinOnce.Do(func() {
+		b := builder{schemas: openapi.NewSchemas(), seen: map[string]bool{}}
+		for k, t := range scheme.Scheme.AllKnownTypes() {
+			if k.Version == runtime.APIVersionInternal {
+				continue
+			}
+			b.addKind(gvk.Gvk{Group: k.Group, Version: k.Version, Kind: k.Kind}, t)
+		}
+		builtinSchemas = b.schemas
+	})
+	return builtinSchemas.Copy()
+}
+
+// Types whose JSON form doesn't follow from their
+// Go structure, keyed by definition name.
+var specialTypes = map[string]spec.Schema{
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Time": stringSchema("date-time"),
+	"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime": stringSchema(
+		"date-time"),
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Duration": stringSchema(""),
+	"k8s.io/apimachinery/pkg/util/intstr.IntOrString": stringSchema(
+		openapi.FormatIntOrString),
+	"k8s.io/apimachinery/pkg/api/resource.Quantity": stringSchema(
+		openapi.FormatQuantity),
+	// Holds arbitrary objects.
+	"k8s.io/apimachinery/pkg/runtime.RawExtension": {},
+}
+
+func stringSchema(format string) spec.Schema {
+	s := *spec.StringProperty()
+	s.Format = format
+	return s
+}
+
+type builder struct {
+	schemas *openapi.Schemas
+	seen    map[string]bool
+}
+
+func definitionName(t reflect.Type) string {
+	return t.PkgPath() + "." + t.Name()
+}
+
+func (b *builder) addKind(k gvk.Gvk, t reflect.Type) {
+	name := definitionName(t)
+	b.seen[name] = true
+	b.schemas.AddDefinition(name, b.structSchema(t), k)
+}
+
+// schemaOf returns the schema for a value of the given type.
+// Named struct types are added as definitions and referred to.
+func (b *builder) schemaOf(t reflect.Type) spec.Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, ok := specialTypes[definitionName(t)]; ok {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		name := definitionName(t)
+		if !b.seen[name] {
+			b.seen[name] = true
+			b.schemas.AddDefinition(name, b.structSchema(t))
+		}
+		return spec.Schema{SchemaProps: spec.SchemaProps{
+			Ref: spec.MustCreateRef(name)}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return stringSchema("byte")
+		}
+		return *spec.ArrayProperty(schemaRef(b.schemaOf(t.Elem())))
+	case reflect.Map:
+		return *spec.MapProperty(schemaRef(b.schemaOf(t.Elem())))
+	case reflect.String:
+		return *spec.StringProperty()
+	case reflect.Bool:
+		return *spec.BoolProperty()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64:
+		return spec.Schema{SchemaProps: spec.SchemaProps{
+			Type: []string{"integer"}}}
+	case reflect.Float32, reflect.Float64:
+		return *spec.Float64Property()
+	}
+	// Interfaces and the like may hold anything.
+	return spec.Schema{}
+}
+
+func schemaRef(s spec.Schema) *spec.Schema {
+	return &s
+}
+
+// structSchema returns a closed object schema with a property
+// per JSON field.  As in the Kubernetes OpenAPI, fields are
+// required unless marked omitempty or +optional.
+func (b *builder) structSchema(t reflect.Type) spec.Schema {
+	s := spec.Schema{SchemaProps: spec.SchemaProps{
+		Type:       []string{"object"},
+		Properties: map[string]spec.Schema{},
+	}}
+	b.addFields(&s, t)
+	return s
+}
+
+func (b *builder) addFields(s *spec.Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(s, ft)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = b.schemaOf(f.Type)
+		if !hasOption(opts[1:], "omitempty") &&
+			!hasOption(optionalFields[definitionName(t)], name) {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func hasOption(opts []string, o string) bool {
+	for _, x := range opts {
+		if x == o {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Writes optional_generated.go, listing the fields of the
+// types in the client-go scheme that are marked +optional
+// in their source but not omitempty in their json tags.
+// Run via go generate in k8sdeps/schema.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func main() {
+	pkgs := map[string]bool{}
+	seen := map[reflect.Type]bool{}
+	for k, t := range scheme.Scheme.AllKnownTypes() {
+		if k.Version == runtime.APIVersionInternal {
+			continue
+		}
+		collectPackages(t, pkgs, seen)
+	}
+	optional := map[string][]string{}
+	for _, pkg := range sortedKeys(pkgs) {
+		addOptionalFields(pkg, optional)
+	}
+	var b bytes.Buffer
+	b.WriteString("// Code generated by k8sdeps/schema/gen; DO NOT EDIT.\n\n")
+	b.WriteString("package schema\n\n")
+	b.WriteString("// optionalFields lists, by definition name, the fields\n")
+	b.WriteString("// marked +optional that aren't omitempty.\n")
+	b.WriteString("var optionalFields = map[string][]string{\n")
+	names := make([]string, 0, len(optional))
+	for n := range optional {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(&b, "%q: {", n)
+		for i, f := range optional[n] {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%q", f)
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("}\n")
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("optional_generated.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// collectPackages adds the packages of the named struct
+// types reachable from t to pkgs.
+func collectPackages(t reflect.Type, pkgs map[string]bool, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		collectPackages(t.Elem(), pkgs, seen)
+	case reflect.Struct:
+		if t.PkgPath() != "" {
+			pkgs[t.PkgPath()] = true
+		}
+		for i := 0; i < t.NumField(); i++ {
+			collectPackages(t.Field(i).Type, pkgs, seen)
+		}
+	}
+}
+
+// addOptionalFields parses the source of pkg, adding the
+// json names of its +optional fields that lack omitempty,
+// keyed by definition name.
+func addOptionalFields(pkg string, optional map[string][]string) {
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", pkg).Output()
+	if err != nil {
+		log.Fatalf("go list %s: %v", pkg, err)
+	}
+	dir := strings.TrimSpace(string(out))
+	fset := token.NewFileSet()
+	parsed, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range parsed {
+		if strings.HasSuffix(p.Name, "_test") {
+			continue
+		}
+		for _, f := range p.Files {
+			for _, d := range f.Decls {
+				g, ok := d.(*ast.GenDecl)
+				if !ok {
+					continue
+				}
+				for _, spec := range g.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					name := pkg + "." + ts.Name.Name
+					for _, field := range st.Fields.List {
+						if n := optionalName(field); n != "" {
+							optional[name] = append(optional[name], n)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// optionalName returns the json name of a field marked
+// +optional without omitempty, or "".
+func optionalName(field *ast.Field) string {
+	if field.Tag == nil || field.Doc == nil {
+		return ""
+	}
+	marked := false
+	for _, c := range field.Doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == "+optional" {
+			marked = true
+		}
+	}
+	if !marked {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	opts := strings.Split(reflect.StructTag(tag).Get("json"), ",")
+	name := opts[0]
+	if name == "" || name == "-" {
+		return ""
+	}
+	for _, o := range opts[1:] {
+		if o == "omitempty" {
+			return ""
+		}
+	}
+	return name
+}
+
+func sortedKeys(m map[string]bool) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
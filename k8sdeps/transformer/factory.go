@@ -23,3 +23,9 @@ func (p *FactoryImpl) MergePatches(patches []*resource.Resource,
 	resmap.ResMap, error) {
 	return patch.MergePatches(patches, rf)
 }
+
+func (p *FactoryImpl) DerivePatch(
+	original, modified *resource.Resource) (
+	map[string]interface{}, bool, error) {
+	return patch.DerivePatch(original, modified)
+}
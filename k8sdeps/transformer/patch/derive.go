@@ -0,0 +1,37 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package patch
+
+import (
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// DerivePatch returns the strategic merge patch that turns
+// original into modified, using the patch strategy of the
+// registered type of their kind.  It returns false if the
+// kind isn't registered, e.g. a custom resource.
+func DerivePatch(
+	original, modified *resource.Resource) (
+	map[string]interface{}, bool, error) {
+	versionedObj, err := scheme.Scheme.New(toSchemaGvk(original.GetGvk()))
+	if runtime.IsNotRegisteredError(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	lookupPatchMeta, err := strategicpatch.NewPatchMetaFromStruct(versionedObj)
+	if err != nil {
+		return nil, false, err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergeMapPatchUsingLookupPatchMeta(
+		original.Map(), modified.Map(), lookupPatchMeta)
+	if err != nil {
+		return nil, false, err
+	}
+	return patch, true, nil
+}
@@ -6,6 +6,7 @@ package accumulator
 import (
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	"github.com/irairdon/kustomize/v3/pkg/resid"
@@ -22,6 +23,8 @@ type ResAccumulator struct {
 	resMap  resmap.ResMap
 	tConfig *config.TransformerConfig
 	varSet  types.VarSet
+	// Set by ResolveVars.
+	unusedVars []string
 }
 
 func MakeEmptyAccumulator() *ResAccumulator {
@@ -144,18 +147,32 @@ func (ra *ResAccumulator) ResolveVars() error {
 	if err != nil {
 		return err
 	}
-	if len(replacementMap) == 0 {
-		return nil
-	}
 	t := transformers.NewRefVarTransformer(
 		replacementMap, ra.tConfig.VarReference)
 	err = ra.Transform(t)
+	if err != nil {
+		return err
+	}
+	ra.unusedVars = t.UnusedVars()
+	sort.Strings(ra.unusedVars)
 	if len(t.UnusedVars()) > 0 {
 		log.Printf(
 			"well-defined vars that were never replaced: %s\n",
 			strings.Join(t.UnusedVars(), ","))
 	}
-	return err
+	if len(t.UnresolvedVars()) > 0 {
+		return fmt.Errorf(
+			"unresolved vars: %s; declare them in vars, give them "+
+				"a default as in $(NAME:-default), or escape them as $$(NAME)",
+			strings.Join(t.UnresolvedVars(), ", "))
+	}
+	return nil
+}
+
+// UnusedVars returns the sorted names of the vars that
+// the last ResolveVars found no reference to.
+func (ra *ResAccumulator) UnusedVars() []string {
+	return ra.unusedVars
 }
 
 func (ra *ResAccumulator) FixBackReferences() (err error) {
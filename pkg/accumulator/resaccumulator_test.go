@@ -123,8 +123,8 @@ func TestResolveVarsOneUnused(t *testing.T) {
 		log.SetOutput(os.Stderr)
 	}()
 	err = ra.ResolveVars()
-	if err != nil {
-		t.Fatalf("unexpected err: %v", err)
+	if err == nil || !strings.Contains(err.Error(), "unresolved vars: SERVICE_TWO;") {
+		t.Fatalf("expected unresolved var error, got %v", err)
 	}
 	expectLog(t, buf, "well-defined vars that were never replaced: SERVICE_UNUSED")
 	c := getCommand(find("deploy1", ra.ResMap()))
@@ -228,6 +228,51 @@ func TestResolveVarsUnmappableVar(t *testing.T) {
 	}
 }
 
+func TestResolveVarsDefaultsAndFunctions(t *testing.T) {
+	ra, _ := makeResAccumulator(t)
+	container := getContainer(find("deploy1", ra.ResMap()))
+	container["command"] = []interface{}{
+		"myserver",
+		"--somebackendService $(SERVICE_ONE:-backendOne|upper)",
+		"--yetAnother $(SERVICE_TWO:-backendTwo)",
+	}
+	err := ra.ResolveVars()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	c := getCommand(find("deploy1", ra.ResMap()))
+	if c != "myserver --somebackendService BACKENDONE --yetAnother backendTwo" {
+		t.Fatalf("unexpected command: %s", c)
+	}
+}
+
+func TestResolveVarsLeavesContainerEnvToKubernetes(t *testing.T) {
+	ra, _ := makeResAccumulator(t)
+	container := getContainer(find("deploy1", ra.ResMap()))
+	container["env"] = []interface{}{
+		map[string]interface{}{"name": "SERVICE_TWO", "value": "backendTwo"},
+	}
+	err := ra.MergeVars([]types.Var{
+		{
+			Name: "SERVICE_ONE",
+			ObjRef: types.Target{
+				Gvk:  gvk.Gvk{Version: "v1", Kind: "Service"},
+				Name: "backendOne"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	err = ra.ResolveVars()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	c := getCommand(find("deploy1", ra.ResMap()))
+	if c != "myserver --somebackendService backendOne --yetAnother $(SERVICE_TWO)" {
+		t.Fatalf("unexpected command: %s", c)
+	}
+}
+
 func TestResolveVarsWithNoambiguation(t *testing.T) {
 	ra1, rf := makeResAccumulator(t)
 	err := ra1.MergeVars([]types.Var{
@@ -238,6 +283,13 @@ func TestResolveVarsWithNoambiguation(t *testing.T) {
 				Name: "backendOne",
 			},
 		},
+		{
+			Name: "SERVICE_TWO",
+			ObjRef: types.Target{
+				Gvk:  gvk.Gvk{Version: "v1", Kind: "Service"},
+				Name: "backendTwo",
+			},
+		},
 	})
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
@@ -317,8 +369,8 @@ func find(name string, resMap resmap.ResMap) *resource.Resource {
 	return nil
 }
 
-// Assumes arg is a deployment, returns the command of first container.
-func getCommand(r *resource.Resource) string {
+// Assumes arg is a deployment, returns its first container.
+func getContainer(r *resource.Resource) map[string]interface{} {
 	var m map[string]interface{}
 	var c []interface{}
 	m, _ = r.Map()["spec"].(map[string]interface{})
@@ -326,8 +378,12 @@ func getCommand(r *resource.Resource) string {
 	m, _ = m["spec"].(map[string]interface{})
 	c, _ = m["containers"].([]interface{})
 	m, _ = c[0].(map[string]interface{})
+	return m
+}
 
-	cmd, _ := m["command"].([]interface{})
+// Assumes arg is a deployment, returns the command of first container.
+func getCommand(r *resource.Resource) string {
+	cmd, _ := getContainer(r)["command"].([]interface{})
 	n := make([]string, len(cmd))
 	for i, v := range cmd {
 		n[i] = v.(string)
@@ -10,7 +10,9 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/k8sdeps/schema"
 	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/git"
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
 	"github.com/irairdon/kustomize/v3/pkg/loader"
 	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
@@ -28,6 +30,13 @@ type Options struct {
 	outputPath        string
 	loadRestrictor    loader.LoadRestrictorFunc
 	outOrder          reorderOutput
+	outFormat         outputFormat
+	provenance        bool
+	validateSchema    bool
+	gitCache          bool
+	offline           bool
+	updateLock        bool
+	strict            bool
 }
 
 // NewOptions creates a Options object
@@ -88,6 +97,40 @@ func NewCmdBuild(
 	plugins.AddFlagEnablePlugins(
 		cmd.Flags(), &pluginConfig.Enabled)
 	addFlagReorderOutput(cmd.Flags())
+	addFlagOutputFormat(cmd.Flags())
+	cmd.Flags().BoolVar(
+		&o.provenance,
+		"provenance", false,
+		"If true, annotate each resource with the file it was read from, "+
+			"the kustomizations it passed through, and the generators, "+
+			"transformers and patches that made or changed it.")
+	cmd.Flags().BoolVar(
+		&o.validateSchema,
+		"validate-schema", false,
+		"If true, validate the output against the OpenAPI schemas of "+
+			"the built-in kinds and of the 'crds' in the kustomizations, "+
+			"failing on unknown fields, wrong types and missing required fields.")
+	cmd.Flags().BoolVar(
+		&o.gitCache,
+		"git-cache", false,
+		"If true, keep clones of remote bases in "+git.DefaultCacheDir()+
+			" for use by later builds. See 'kustomize cache'.")
+	cmd.Flags().BoolVar(
+		&o.offline,
+		"offline", false,
+		"If true, take remote bases only from the git cache, "+
+			"failing if they aren't there. Implies --git-cache.")
+	cmd.Flags().BoolVar(
+		&o.updateLock,
+		"update-lock", false,
+		"If true, rather than failing when a remote base has drifted "+
+			"from the commit pinned in "+git.LockFileName+", update the lock.")
+	cmd.Flags().BoolVar(
+		&o.strict,
+		"strict", false,
+		"If true, fail on configuration that has no effect: vars never "+
+			"referenced, patches and images that match nothing, and "+
+			"transformers that change nothing.")
 	cmd.AddCommand(NewCmdBuildPrune(out, v, fSys, rf, ptf, pl))
 	return cmd
 }
@@ -108,6 +151,10 @@ func (o *Options) Validate(args []string) (err error) {
 		return err
 	}
 	o.outOrder, err = validateFlagReorderOutput()
+	if err != nil {
+		return err
+	}
+	o.outFormat, err = validateFlagOutputFormat()
 	return
 }
 
@@ -116,8 +163,9 @@ func (o *Options) RunBuild(
 	out io.Writer, v ifc.Validator, fSys fs.FileSystem,
 	rf *resmap.Factory, ptf resmap.PatchFactory,
 	pl *plugins.Loader) error {
-	ldr, err := loader.NewLoader(
-		o.loadRestrictor, v, o.kustomizationPath, fSys)
+	ldr, err := loader.NewLoaderWithLock(
+		o.loadRestrictor, v, o.kustomizationPath, fSys,
+		o.cloner(), o.lockPolicy())
 	if err != nil {
 		return err
 	}
@@ -126,10 +174,22 @@ func (o *Options) RunBuild(
 	if err != nil {
 		return err
 	}
+	if o.provenance {
+		kt.EnableProvenance()
+	}
+	if o.validateSchema {
+		kt.EnableSchemaValidation(schema.NewBuiltinSchemas())
+	}
+	if o.strict {
+		kt.EnableStrict()
+	}
 	m, err := kt.MakeCustomizedResMap()
 	if err != nil {
 		return err
 	}
+	if err = loader.SaveLock(ldr); err != nil {
+		return err
+	}
 	return o.emitResources(out, fSys, m)
 }
 
@@ -137,8 +197,9 @@ func (o *Options) RunBuildPrune(
 	out io.Writer, v ifc.Validator, fSys fs.FileSystem,
 	rf *resmap.Factory, ptf resmap.PatchFactory,
 	pl *plugins.Loader) error {
-	ldr, err := loader.NewLoader(
-		o.loadRestrictor, v, o.kustomizationPath, fSys)
+	ldr, err := loader.NewLoaderWithLock(
+		o.loadRestrictor, v, o.kustomizationPath, fSys,
+		o.cloner(), o.lockPolicy())
 	if err != nil {
 		return err
 	}
@@ -151,11 +212,44 @@ func (o *Options) RunBuildPrune(
 	if err != nil {
 		return err
 	}
+	if err = loader.SaveLock(ldr); err != nil {
+		return err
+	}
 	return o.emitResources(out, fSys, m)
 }
 
+// cloner returns the cloner used to obtain remote bases.
+func (o *Options) cloner() git.Cloner {
+	if !o.gitCache && !o.offline {
+		return git.DefaultCloner
+	}
+	return git.NewCache(git.DefaultCacheDir(), o.offline).Cloner(
+		git.DefaultCloner, git.DefaultRefResolver)
+}
+
+// lockPolicy says how to treat a kustomization.lock.
+// Offline, drift can't be checked, so the lock is
+// taken at its word.
+func (o *Options) lockPolicy() loader.LockPolicy {
+	p := loader.LockPolicy{
+		Resolve: git.DefaultRefResolver,
+		Update:  o.updateLock,
+	}
+	if o.offline {
+		p.Resolve = nil
+	}
+	return p
+}
+
 func (o *Options) emitResources(
 	out io.Writer, fSys fs.FileSystem, m resmap.ResMap) error {
+	err := validateOutputPath(o.outFormat, fSys, o.outputPath)
+	if err != nil {
+		return err
+	}
+	if o.outFormat == treeFormat {
+		return writeTree(fSys, o.outputPath, m)
+	}
 	if o.outputPath != "" && fSys.IsDir(o.outputPath) {
 		return writeIndividualFiles(fSys, o.outputPath, m)
 	}
@@ -166,7 +260,7 @@ func (o *Options) emitResources(
 		// it and call transform.
 		builtin.NewLegacyOrderTransformerPlugin().Transform(m)
 	}
-	res, err := m.AsYaml()
+	res, err := marshalAs(o.outFormat, m)
 	if err != nil {
 		return err
 	}
@@ -17,9 +17,15 @@ limitations under the License.
 package build
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/irairdon/kustomize/v3/k8sdeps/kunstruct"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
 	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
 )
 
 func TestNewOptionsToSilenceCodeInspectionError(t *testing.T) {
@@ -62,3 +68,169 @@ func TestBuildValidate(t *testing.T) {
 		}
 	}
 }
+
+const emitInput = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: ns1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: ns1
+`
+
+func makeEmitResMap(t *testing.T) resmap.ResMap {
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), nil)
+	m, err := rf.NewResMapFromBytes([]byte(emitInput))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestEmitResourcesJson(t *testing.T) {
+	var cases = []struct {
+		format   outputFormat
+		expected string
+	}{
+		{jsonFormat, `{
+  "apiVersion": "v1",
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "Namespace",
+      "metadata": {
+        "name": "ns1"
+      }
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Service",
+      "metadata": {
+        "name": "svc",
+        "namespace": "ns1"
+      }
+    }
+  ],
+  "kind": "List"
+}
+`},
+		{jsonLineFormat, `{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"ns1"}}
+{"apiVersion":"v1","kind":"Service","metadata":{"name":"svc","namespace":"ns1"}}
+`},
+	}
+	for _, c := range cases {
+		o := Options{outFormat: c.format}
+		var out bytes.Buffer
+		err := o.emitResources(&out, fs.MakeFakeFS(), makeEmitResMap(t))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.format, err)
+		}
+		if out.String() != c.expected {
+			t.Errorf("%s: expected\n%s\nbut got\n%s",
+				c.format, c.expected, out.String())
+		}
+	}
+}
+
+func TestEmitResourcesTree(t *testing.T) {
+	fSys := fs.MakeFakeFS()
+	fSys.Mkdir("/out")
+	o := Options{outFormat: treeFormat, outputPath: "/out"}
+	err := o.emitResources(nil, fSys, makeEmitResMap(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, f := range []string{
+		"/out/_cluster/namespace/ns1.yaml",
+		"/out/ns1/service/svc.yaml",
+	} {
+		if !fSys.Exists(f) {
+			t.Errorf("expected file %s", f)
+		}
+	}
+	content, _ := fSys.ReadFile("/out/ns1/service/svc.yaml")
+	expected := `apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: ns1
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, string(content))
+	}
+}
+
+func TestEmitResourcesTreeNeedsDirectory(t *testing.T) {
+	o := Options{outFormat: treeFormat}
+	err := o.emitResources(nil, fs.MakeFakeFS(), makeEmitResMap(t))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+}
+
+func TestEmitResourcesTreeGroups(t *testing.T) {
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), nil)
+	m, err := rf.NewResMapFromBytes([]byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: ns1
+---
+apiVersion: example.com/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: ns1
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fSys := fs.MakeFakeFS()
+	fSys.Mkdir("/out")
+	o := Options{outFormat: treeFormat, outputPath: "/out"}
+	err = o.emitResources(nil, fSys, m)
+	if err == nil || !strings.Contains(err.Error(), "would both be written to") {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+	if fSys.Exists("/out/ns1/deployment/web.yaml") {
+		t.Fatalf("expected nothing written")
+	}
+}
+
+func TestEmitResourcesTreeCollision(t *testing.T) {
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), nil)
+	m, err := rf.NewResMapFromBytes([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+  namespace: ns1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: Web
+  namespace: ns1
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fSys := fs.MakeFakeFS()
+	fSys.Mkdir("/out")
+	o := Options{outFormat: treeFormat, outputPath: "/out"}
+	err = o.emitResources(nil, fSys, m)
+	if err == nil || !strings.Contains(err.Error(), "would both be written to") {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+	if fSys.Exists("/out/ns1/configmap/web.yaml") {
+		t.Fatalf("expected nothing written")
+	}
+}
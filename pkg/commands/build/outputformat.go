@@ -0,0 +1,127 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/spf13/pflag"
+)
+
+//go:generate stringer -type=outputFormat -linecomment
+type outputFormat int
+
+const (
+	unknownFormat  outputFormat = iota // unknown
+	yamlFormat                         // yaml
+	jsonFormat                         // json
+	jsonLineFormat                     // ndjson
+	treeFormat                         // tree
+)
+
+const (
+	flagOutputFormatName = "output-format"
+)
+
+var (
+	flagOutputFormatValue = yamlFormat.String()
+	flagOutputFormatHelp  = "Format of the build output. " +
+		"Use '" + yamlFormat.String() + "' for a YAML stream, " +
+		"'" + jsonFormat.String() + "' for a single v1.List JSON document, " +
+		"'" + jsonLineFormat.String() + "' for one JSON document per line, or " +
+		"'" + treeFormat.String() + "' to write each resource to " +
+		"<namespace>/<kind>/<name>.yaml, in lower case, below the directory " +
+		"given by --output. Cluster scoped resources go under " + clusterScopedDir +
+		"/<kind>/<name>.yaml, and resources that would share a file, " +
+		"e.g. kinds of one name in two API groups, are an error."
+)
+
+func addFlagOutputFormat(set *pflag.FlagSet) {
+	set.StringVar(
+		&flagOutputFormatValue, flagOutputFormatName,
+		yamlFormat.String(), flagOutputFormatHelp)
+}
+
+func validateFlagOutputFormat() (outputFormat, error) {
+	for _, f := range []outputFormat{
+		yamlFormat, jsonFormat, jsonLineFormat, treeFormat} {
+		if flagOutputFormatValue == f.String() {
+			return f, nil
+		}
+	}
+	return unknownFormat, fmt.Errorf(
+		"illegal flag value --%s %s; legal values: %v",
+		flagOutputFormatName, flagOutputFormatValue,
+		[]string{
+			yamlFormat.String(), jsonFormat.String(),
+			jsonLineFormat.String(), treeFormat.String()})
+}
+
+// validateOutputPath checks that the --output path
+// suits the format: a tree needs an existing directory,
+// and JSON can't be split into files in one.
+func validateOutputPath(
+	f outputFormat, fSys fs.FileSystem, path string) error {
+	isDir := path != "" && fSys.IsDir(path)
+	switch {
+	case f == treeFormat && !isDir:
+		return fmt.Errorf(
+			"--%s %s requires --output to name an existing directory",
+			flagOutputFormatName, f)
+	case (f == jsonFormat || f == jsonLineFormat) && isDir:
+		return fmt.Errorf(
+			"--%s %s cannot write to directory '%s'",
+			flagOutputFormatName, f, path)
+	}
+	return nil
+}
+
+// marshalAs returns the resources as a stream in
+// the given format.
+func marshalAs(f outputFormat, m resmap.ResMap) ([]byte, error) {
+	switch f {
+	case jsonFormat:
+		return asJsonList(m)
+	case jsonLineFormat:
+		return asJsonLines(m)
+	default:
+		return m.AsYaml()
+	}
+}
+
+// asJsonList wraps the resources in a v1.List, the
+// form kubectl emits for 'get -o json' on many objects.
+func asJsonList(m resmap.ResMap) ([]byte, error) {
+	items := make([]interface{}, m.Size())
+	for i, res := range m.Resources() {
+		items[i] = res.Map()
+	}
+	out, err := json.MarshalIndent(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// asJsonLines emits one compact JSON document per
+// line (aka newline-delimited JSON).
+func asJsonLines(m resmap.ResMap) ([]byte, error) {
+	var b []byte
+	for _, res := range m.Resources() {
+		out, err := json.Marshal(res.Map())
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, out...)
+		b = append(b, '\n')
+	}
+	return b, nil
+}
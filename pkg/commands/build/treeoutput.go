@@ -0,0 +1,58 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/resid"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+)
+
+// clusterScopedDir holds resources that cannot be
+// placed in a namespace.  The leading underscore can't
+// appear in a namespace name, so it cannot collide.
+const clusterScopedDir = "_cluster"
+
+// writeTree writes each resource to a file named
+// {folderPath}/{namespace}/{kind}/{name}.yaml, with
+// cluster scoped resources under clusterScopedDir.  Two
+// resources that would land in the same file, e.g. kinds
+// of one name in two groups, are an error, found before
+// anything is written.
+func writeTree(
+	fSys fs.FileSystem, folderPath string, m resmap.ResMap) error {
+	paths := make([]string, len(m.Resources()))
+	written := make(map[string]resid.ResId)
+	for i, res := range m.Resources() {
+		id := res.CurId()
+		ns := clusterScopedDir
+		if id.IsNamespaceableKind() {
+			ns = id.EffectiveNamespace()
+		}
+		paths[i] = filepath.Join(
+			folderPath, strings.ToLower(ns), strings.ToLower(id.Kind),
+			strings.ToLower(id.Name)+".yaml")
+		if other, ok := written[paths[i]]; ok {
+			return fmt.Errorf(
+				"%s and %s would both be written to %s", other, id, paths[i])
+		}
+		written[paths[i]] = id
+	}
+	for i, res := range m.Resources() {
+		dir, name := filepath.Split(paths[i])
+		err := fSys.MkdirAll(dir)
+		if err != nil {
+			return err
+		}
+		err = writeFile(fSys, dir, name, res)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
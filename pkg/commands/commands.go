@@ -14,6 +14,7 @@ import (
 	"github.com/irairdon/kustomize/v3/k8sdeps/validator"
 	"github.com/irairdon/kustomize/v3/pkg/commands/build"
 	"github.com/irairdon/kustomize/v3/pkg/commands/create"
+	"github.com/irairdon/kustomize/v3/pkg/commands/diff"
 	"github.com/irairdon/kustomize/v3/pkg/commands/edit"
 	"github.com/irairdon/kustomize/v3/pkg/commands/misc"
 	"github.com/irairdon/kustomize/v3/pkg/fs"
@@ -44,9 +45,13 @@ See https://github.com/irairdon/kustomize
 		build.NewCmdBuild(
 			stdOut, fSys, v,
 			rf, pf),
-		edit.NewCmdEdit(fSys, v, uf),
-		create.NewCmdCreate(fSys, uf),
+		diff.NewCmdDiff(
+			stdOut, fSys, v,
+			rf, pf),
+		edit.NewCmdEdit(fSys, v, uf, rf, pf),
+		create.NewCmdCreate(os.Stdin, fSys, uf),
 		misc.NewCmdConfig(fSys),
+		misc.NewCmdCache(stdOut),
 		misc.NewCmdVersion(stdOut),
 	)
 	c.PersistentFlags().AddGoFlagSet(flag.CommandLine)
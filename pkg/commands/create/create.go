@@ -5,6 +5,8 @@ package create
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,6 +18,7 @@ import (
 	"github.com/irairdon/kustomize/v3/pkg/fs"
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
 	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+	"github.com/irairdon/kustomize/v3/pkg/types"
 )
 
 type createFlags struct {
@@ -27,12 +30,19 @@ type createFlags struct {
 	suffix          string
 	detectResources bool
 	detectRecursive bool
+	fromStream      string
+	factorLabels    bool
+	factorNamespace bool
 	path            string
+	stdin           io.Reader
 }
 
 // NewCmdCreate returns an instance of 'create' subcommand.
-func NewCmdCreate(fSys fs.FileSystem, uf ifc.KunstructuredFactory) *cobra.Command {
-	opts := createFlags{path: "."}
+// A --from-helm-output of - reads from stdIn.
+func NewCmdCreate(
+	stdIn io.Reader, fSys fs.FileSystem,
+	uf ifc.KunstructuredFactory) *cobra.Command {
+	opts := createFlags{path: ".", stdin: stdIn}
 	c := &cobra.Command{
 		Use:   "create",
 		Short: "Create a new kustomization in the current directory",
@@ -46,6 +56,9 @@ func NewCmdCreate(fSys fs.FileSystem, uf ifc.KunstructuredFactory) *cobra.Comman
 
 	# Create a new kustomization with multiple resources and fields set.
 	kustomize create --resources deployment.yaml,service.yaml,../base --namespace staging --nameprefix acme-
+
+	# Create a new kustomization from the output of helm, with a file per resource.
+	helm template ./chart | kustomize create --from-helm-output - --factor-labels --factor-namespace
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runCreate(opts, fSys, uf)
@@ -91,6 +104,21 @@ func NewCmdCreate(fSys fs.FileSystem, uf ifc.KunstructuredFactory) *cobra.Comman
 		"recursive",
 		false,
 		"Enable recursive directory searching for resource auto-detection.")
+	c.Flags().StringVar(
+		&opts.fromStream,
+		"from-helm-output",
+		"",
+		"Split a multi-document YAML file, or - for stdin, into a file per resource to add to the kustomization file.")
+	c.Flags().BoolVar(
+		&opts.factorLabels,
+		"factor-labels",
+		false,
+		"Move the labels all resources from --from-helm-output share into the kustomization file.")
+	c.Flags().BoolVar(
+		&opts.factorNamespace,
+		"factor-namespace",
+		false,
+		"Move the namespace all resources from --from-helm-output share into the kustomization file.")
 	return c
 }
 
@@ -118,6 +146,19 @@ func runCreate(opts createFlags, fSys fs.FileSystem, uf ifc.KunstructuredFactory
 			resources = append(resources, resource)
 		}
 	}
+	var split *splitStream
+	if opts.fromStream != "" {
+		data, err := readStream(fSys, opts.fromStream, opts.stdin)
+		if err != nil {
+			return err
+		}
+		split, err = splitResources(
+			fSys, uf, opts.path, data, opts.factorLabels, opts.factorNamespace)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, split.resources...)
+	}
 	f, err := fSys.Create("kustomization.yaml")
 	if err != nil {
 		return err
@@ -145,9 +186,25 @@ func runCreate(opts createFlags, fSys fs.FileSystem, uf ifc.KunstructuredFactory
 		return err
 	}
 	m.CommonLabels = labels
+	if split != nil {
+		if m.Namespace == "" {
+			m.Namespace = split.namespace
+		}
+		if len(split.labels) > 0 {
+			m.Labels = []types.Label{{Pairs: split.labels}}
+		}
+	}
 	return mf.Write(m)
 }
 
+// readStream reads the named file, or in if the name is -.
+func readStream(fSys fs.FileSystem, name string, in io.Reader) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(in)
+	}
+	return fSys.ReadFile(name)
+}
+
 func detectResources(fSys fs.FileSystem, uf ifc.KunstructuredFactory, base string, recursive bool) ([]string, error) {
 	var paths []string
 	err := fSys.Walk(base, func(path string, info os.FileInfo, err error) error {
@@ -5,6 +5,7 @@ package create
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/irairdon/kustomize/v3/k8sdeps/kunstruct"
@@ -28,7 +29,7 @@ func readKustomizationFS(t *testing.T, fakeFS fs.FileSystem) *types.Kustomizatio
 }
 func TestCreateNoArgs(t *testing.T) {
 	fakeFS := fs.MakeFakeFS()
-	cmd := NewCmdCreate(fakeFS, factory)
+	cmd := NewCmdCreate(nil, fakeFS, factory)
 	err := cmd.RunE(cmd, []string{})
 	if err != nil {
 		t.Errorf("unexpected cmd error: %v", err)
@@ -193,3 +194,155 @@ func TestCreateWithDetectRecursive(t *testing.T) {
 		t.Fatalf("expected %+v but got %+v", expected, m.Resources)
 	}
 }
+
+const helmOutput = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+  labels:
+    app: web
+    chart: web-1.0.0
+spec:
+  ports:
+  - port: 80
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+  labels:
+    app: web
+    chart: web-1.0.0
+    tier: front
+spec:
+  replicas: 1
+---
+# Source: web/templates/clusterrole.yaml
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: "system:web"
+  labels:
+    app: web
+    chart: web-1.0.0
+`
+
+func TestCreateFromHelmOutput(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	opts := createFlags{
+		path:            ".",
+		fromStream:      "-",
+		stdin:           strings.NewReader(helmOutput),
+		factorLabels:    true,
+		factorNamespace: true,
+	}
+	err := runCreate(opts, fakeFS, factory)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	m := readKustomizationFS(t, fakeFS)
+	expected := []string{
+		"service_web.yaml", "deployment_web.yaml", "clusterrole_system-web.yaml"}
+	if !reflect.DeepEqual(m.Resources, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, m.Resources)
+	}
+	if m.Namespace != "shop" {
+		t.Errorf("expected namespace shop, got %q", m.Namespace)
+	}
+	labels := map[string]string{"app": "web", "chart": "web-1.0.0"}
+	if len(m.Labels) != 1 || !reflect.DeepEqual(m.Labels[0].Pairs, labels) {
+		t.Errorf("expected labels %v, got %+v", labels, m.Labels)
+	}
+	content, err := fakeFS.ReadFile("deployment_web.yaml")
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expectedContent := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    tier: front
+  name: web
+spec:
+  replicas: 1
+`
+	if string(content) != expectedContent {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expectedContent, content)
+	}
+}
+
+func TestCreateFromHelmOutputFile(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteFile("all.yaml", []byte(helmOutput+`---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: other
+`))
+	opts := createFlags{
+		path:            ".",
+		fromStream:      "all.yaml",
+		factorNamespace: true,
+	}
+	err := runCreate(opts, fakeFS, factory)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	m := readKustomizationFS(t, fakeFS)
+	expected := []string{"service_web.yaml", "deployment_web.yaml",
+		"clusterrole_system-web.yaml", "other_service_web.yaml"}
+	if !reflect.DeepEqual(m.Resources, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, m.Resources)
+	}
+	if m.Namespace != "" || len(m.Labels) != 0 {
+		t.Errorf("expected nothing factored, got namespace %q and labels %+v",
+			m.Namespace, m.Labels)
+	}
+
+	fakeFS.RemoveAll("kustomization.yaml")
+	err = runCreate(opts, fakeFS, factory)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("expected an error about existing files, got %v", err)
+	}
+}
+
+func TestCreateFromHelmOutputStdin(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	cmd := NewCmdCreate(strings.NewReader(helmOutput), fakeFS, factory)
+	cmd.Flags().Set("from-helm-output", "-")
+	err := cmd.RunE(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	m := readKustomizationFS(t, fakeFS)
+	if len(m.Resources) != 3 {
+		t.Fatalf("expected 3 resources, got %+v", m.Resources)
+	}
+}
+
+func TestCreateFromHelmOutputWritesNothingOnConflict(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteFile("deployment_web.yaml", []byte("kept"))
+	opts := createFlags{
+		path:       ".",
+		fromStream: "-",
+		stdin:      strings.NewReader(helmOutput),
+	}
+	err := runCreate(opts, fakeFS, factory)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected an error about existing files, got %v", err)
+	}
+	if fakeFS.Exists("service_web.yaml") {
+		t.Errorf("expected no file written before the conflict")
+	}
+	content, _ := fakeFS.ReadFile("deployment_web.yaml")
+	if string(content) != "kept" {
+		t.Errorf("expected existing file untouched, got %s", content)
+	}
+}
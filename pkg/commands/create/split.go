@@ -0,0 +1,169 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package create
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/resid"
+	"sigs.k8s.io/yaml"
+)
+
+// splitStream holds what splitting a stream of
+// resources into files made.
+type splitStream struct {
+	// resources are the files written, in stream order.
+	resources []string
+
+	// labels were on every resource, so were
+	// taken off them to put in the kustomization.
+	labels map[string]string
+
+	// namespace held every namespaced resource, so
+	// was taken off them to put in the kustomization.
+	namespace string
+}
+
+// splitResources writes each resource in data, e.g. the
+// output of helm template, to a file of its own in dir.
+func splitResources(
+	fSys fs.FileSystem, uf ifc.KunstructuredFactory, dir string,
+	data []byte, factorLabels, factorNamespace bool) (*splitStream, error) {
+	objs, err := uf.SliceFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no resources to split")
+	}
+	result := &splitStream{}
+	if factorLabels {
+		result.labels = factorCommonLabels(objs)
+	}
+	if factorNamespace {
+		result.namespace = factorCommonNamespace(objs)
+	}
+	// Name every file, and check none exists,
+	// before writing any of them.
+	taken := make(map[string]bool)
+	for _, obj := range objs {
+		name := fileName(resid.NewResIdWithNamespace(
+			obj.GetGvk(), obj.GetName(), namespaceOf(obj)), taken)
+		path := filepath.Join(dir, name)
+		if fSys.Exists(path) {
+			return nil, fmt.Errorf("%s already exists", path)
+		}
+		result.resources = append(result.resources, path)
+	}
+	for i, obj := range objs {
+		out, err := yaml.Marshal(obj.Map())
+		if err != nil {
+			return nil, err
+		}
+		err = fSys.WriteFile(result.resources[i], out)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// factorCommonLabels takes the labels every object
+// has, with the same value, off the objects.
+func factorCommonLabels(objs []ifc.Kunstructured) map[string]string {
+	common := objs[0].GetLabels()
+	for _, obj := range objs[1:] {
+		labels := obj.GetLabels()
+		for k, v := range common {
+			if w, ok := labels[k]; !ok || w != v {
+				delete(common, k)
+			}
+		}
+	}
+	if len(common) == 0 {
+		return nil
+	}
+	for _, obj := range objs {
+		labels := obj.GetLabels()
+		for k := range common {
+			delete(labels, k)
+		}
+		if len(labels) == 0 {
+			labels = nil
+		}
+		obj.SetLabels(labels)
+	}
+	return common
+}
+
+// factorCommonNamespace takes the namespace off the
+// objects if every namespaced object is in it, and
+// no cluster wide object names one.
+func factorCommonNamespace(objs []ifc.Kunstructured) string {
+	common := ""
+	for _, obj := range objs {
+		ns := namespaceOf(obj)
+		if !obj.GetGvk().IsNamespaceableKind() {
+			if ns != "" {
+				return ""
+			}
+			continue
+		}
+		if ns == "" || (common != "" && ns != common) {
+			return ""
+		}
+		common = ns
+	}
+	if common == "" {
+		return ""
+	}
+	for _, obj := range objs {
+		obj.SetNamespace("")
+	}
+	return common
+}
+
+func namespaceOf(obj ifc.Kunstructured) string {
+	ns, _ := obj.GetString("metadata.namespace")
+	return ns
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// fileName names the file of the resource with the given
+// id after its kind and name, adding its namespace, then
+// its group, if that's needed to tell it apart from a
+// resource already named.
+func fileName(id resid.ResId, taken map[string]bool) string {
+	candidates := [][]string{
+		{id.Kind, id.Name},
+		{id.Namespace, id.Kind, id.Name},
+		{id.Group, id.Namespace, id.Kind, id.Name},
+	}
+	var name string
+	for _, parts := range candidates {
+		var kept []string
+		for _, p := range parts {
+			if p != "" {
+				kept = append(kept, p)
+			}
+		}
+		name = unsafeFileChars.ReplaceAllString(
+			strings.ToLower(strings.Join(kept, "_")), "-")
+		if !taken[name] {
+			break
+		}
+	}
+	base := name
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	taken[name] = true
+	return name + ".yaml"
+}
@@ -0,0 +1,220 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package diff implements the 'diff' command, which
+// compares the build output of two kustomizations.
+package diff
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/git"
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/loader"
+	"github.com/irairdon/kustomize/v3/pkg/plugins"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/target"
+)
+
+// Options contain the options for running a diff.
+type Options struct {
+	pathA          string
+	pathB          string
+	fromRef        string
+	toRef          string
+	loadRestrictor loader.LoadRestrictorFunc
+}
+
+var examples = `
+To compare the output of two kustomizations, run
+
+  kustomize diff overlays/staging overlays/production
+
+To compare an overlay in a local git repository as it was
+at a given branch, tag or commit with the working copy, run
+
+  kustomize diff overlays/production --from-ref v1.2.0
+
+To compare an overlay at two git refs, run
+
+  kustomize diff overlays/production --from-ref v1.2.0 --to-ref master
+
+The path may also be a URL to a remote kustomization, e.g.
+
+  kustomize diff github.com/someOrg/someRepo/overlays/prod \
+    --from-ref v1.2.0 --to-ref v1.3.0
+
+Resources are matched by group, version, kind, namespace
+and name, and the diff fails if two resources on one side
+share all of these.  Added, removed and changed resources
+are printed as a unified diff of their YAML.
+`
+
+// NewCmdDiff creates a new diff command.
+func NewCmdDiff(
+	out io.Writer, fSys fs.FileSystem,
+	v ifc.Validator, rf *resmap.Factory,
+	ptf resmap.PatchFactory) *cobra.Command {
+	var o Options
+
+	pluginConfig := plugins.DefaultPluginConfig()
+	pl := plugins.NewLoader(pluginConfig, rf)
+
+	cmd := &cobra.Command{
+		Use:          "diff {pathA} [{pathB}]",
+		Short:        "Print the differences between the output of two kustomizations",
+		Example:      examples,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			return o.RunDiff(out, v, fSys, rf, ptf, pl)
+		},
+	}
+	cmd.Flags().StringVar(
+		&o.fromRef, "from-ref", "",
+		"Git branch, tag or commit at which to build the "+
+			"kustomization being compared from.")
+	cmd.Flags().StringVar(
+		&o.toRef, "to-ref", "",
+		"Git branch, tag or commit at which to build the "+
+			"kustomization being compared to.  "+
+			"Defaults to the kustomization as found on disk.")
+	loader.AddFlagLoadRestrictor(cmd.Flags())
+	plugins.AddFlagEnablePlugins(
+		cmd.Flags(), &pluginConfig.Enabled)
+	return cmd
+}
+
+// Validate validates diff command.
+func (o *Options) Validate(args []string) (err error) {
+	if o.fromRef == "" && o.toRef == "" {
+		if len(args) != 2 {
+			return errors.New(
+				"specify two kustomization paths to compare")
+		}
+		o.pathA = args[0]
+		o.pathB = args[1]
+	} else {
+		if o.fromRef == "" {
+			return errors.New("--to-ref requires --from-ref")
+		}
+		if len(args) != 1 {
+			return errors.New(
+				"specify one kustomization path when comparing git refs")
+		}
+		o.pathA = args[0]
+		o.pathB = args[0]
+	}
+	o.loadRestrictor, err = loader.ValidateFlagLoadRestrictor()
+	return err
+}
+
+// RunDiff runs diff command.
+func (o *Options) RunDiff(
+	out io.Writer, v ifc.Validator, fSys fs.FileSystem,
+	rf *resmap.Factory, ptf resmap.PatchFactory,
+	pl *plugins.Loader) error {
+	a, err := o.build(v, fSys, rf, ptf, pl, o.pathA, o.fromRef)
+	if err != nil {
+		return err
+	}
+	b, err := o.build(v, fSys, rf, ptf, pl, o.pathB, o.toRef)
+	if err != nil {
+		return err
+	}
+	return writeDiff(out, a, b)
+}
+
+func (o *Options) build(
+	v ifc.Validator, fSys fs.FileSystem,
+	rf *resmap.Factory, ptf resmap.PatchFactory,
+	pl *plugins.Loader, path, ref string) (resmap.ResMap, error) {
+	ldr, err := o.makeLoader(v, fSys, path, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer ldr.Cleanup()
+	kt, err := target.NewKustTarget(ldr, rf, ptf, pl)
+	if err != nil {
+		return nil, err
+	}
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		return nil, errors.Wrapf(err, "building '%s'", describe(path, ref))
+	}
+	return m, nil
+}
+
+func (o *Options) makeLoader(
+	v ifc.Validator, fSys fs.FileSystem,
+	path, ref string) (ifc.Loader, error) {
+	if ref == "" {
+		return loader.NewLoader(o.loadRestrictor, v, path, fSys)
+	}
+	repoSpec, err := repoSpecAtRef(fSys, path, ref)
+	if err != nil {
+		return nil, err
+	}
+	return loader.NewLoaderAtRepoSpec(v, repoSpec, fSys)
+}
+
+// repoSpecAtRef returns a RepoSpec for the given path
+// at the given ref.  The path may be a remote URL, or a
+// directory inside a local git repository.
+func repoSpecAtRef(
+	fSys fs.FileSystem, path, ref string) (*git.RepoSpec, error) {
+	repoSpec, err := git.NewRepoSpecFromUrl(path)
+	if err == nil {
+		return repoSpec.WithRef(ref), nil
+	}
+	dir, f, err := fSys.CleanedAbs(path)
+	if err != nil {
+		return nil, err
+	}
+	if f != "" {
+		return nil, fmt.Errorf(
+			"got file '%s', but '%s' must be a directory", f, path)
+	}
+	top, err := gitTopLevel(fSys, dir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(top.String(), dir.String())
+	if err != nil {
+		return nil, err
+	}
+	if rel == "." {
+		rel = ""
+	}
+	return git.NewRepoSpecFromLocalRepo(top.String(), rel, ref), nil
+}
+
+// gitTopLevel returns the nearest directory at or
+// above dir holding a .git entry.
+func gitTopLevel(
+	fSys fs.FileSystem, dir fs.ConfirmedDir) (fs.ConfirmedDir, error) {
+	for d := dir; ; d = fs.ConfirmedDir(filepath.Dir(d.String())) {
+		if fSys.Exists(d.Join(".git")) {
+			return d, nil
+		}
+		if filepath.Dir(d.String()) == d.String() {
+			return "", fmt.Errorf(
+				"'%s' is not inside a git repository", dir)
+		}
+	}
+}
+
+func describe(path, ref string) string {
+	if ref == "" {
+		return path
+	}
+	return path + "@" + ref
+}
@@ -0,0 +1,337 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/k8sdeps/kunstruct"
+	"github.com/irairdon/kustomize/v3/k8sdeps/transformer"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/loader"
+	"github.com/irairdon/kustomize/v3/pkg/plugins"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/validators"
+)
+
+func TestDiffValidate(t *testing.T) {
+	var cases = []struct {
+		name    string
+		args    []string
+		fromRef string
+		toRef   string
+		pathA   string
+		pathB   string
+		erMsg   string
+	}{
+		{"twoPaths", []string{"a", "b"}, "", "", "a", "b", ""},
+		{"onePath", []string{"a"}, "", "", "", "",
+			"specify two kustomization paths to compare"},
+		{"refs", []string{"a"}, "v1", "v2", "a", "a", ""},
+		{"fromRefOnly", []string{"a"}, "v1", "", "a", "a", ""},
+		{"toRefOnly", []string{"a"}, "", "v2", "", "",
+			"--to-ref requires --from-ref"},
+		{"refsTwoPaths", []string{"a", "b"}, "v1", "", "", "",
+			"specify one kustomization path when comparing git refs"},
+	}
+	for _, c := range cases {
+		o := Options{fromRef: c.fromRef, toRef: c.toRef}
+		err := o.Validate(c.args)
+		if c.erMsg != "" {
+			if err == nil || err.Error() != c.erMsg {
+				t.Errorf("%s: expected error %q, got %v", c.name, c.erMsg, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if o.pathA != c.pathA || o.pathB != c.pathB {
+			t.Errorf("%s: unexpected paths %q, %q", c.name, o.pathA, o.pathB)
+		}
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	fSys := fs.MakeFakeFS()
+	fSys.WriteFile("/app/base/kustomization.yaml", []byte(`
+resources:
+- deployment.yaml
+- service.yaml
+`))
+	fSys.WriteFile("/app/base/deployment.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.15
+`))
+	fSys.WriteFile("/app/base/service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	fSys.WriteFile("/app/prod/kustomization.yaml", []byte(`
+resources:
+- ../base
+- configmap.yaml
+patchesStrategicMerge:
+- patch.yaml
+images:
+- name: nginx
+  newTag: "1.16"
+`))
+	fSys.WriteFile("/app/prod/configmap.yaml", []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`))
+	fSys.WriteFile("/app/prod/patch.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+$patch: delete
+`))
+
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	o := Options{
+		pathA:          "/app/base",
+		pathB:          "/app/prod",
+		loadRestrictor: loader.RestrictionRootOnly,
+	}
+	var out bytes.Buffer
+	err := o.RunDiff(
+		&out, validators.MakeFakeValidator(), fSys, rf,
+		transformer.NewFactoryImpl(),
+		plugins.NewLoader(plugins.DefaultPluginConfig(), rf))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `--- a/apps_v1_Deployment|~X|web
++++ b/apps_v1_Deployment|~X|web
+@@ -3,9 +3,9 @@
+ metadata:
+   name: web
+ spec:
+-  replicas: 1
++  replicas: 3
+   template:
+     spec:
+       containers:
+-      - image: nginx:1.15
++      - image: nginx:1.16
+         name: web
+--- a/~G_v1_Service|~X|web
++++ /dev/null
+@@ -1,4 +0,0 @@
+-apiVersion: v1
+-kind: Service
+-metadata:
+-  name: web
+--- /dev/null
++++ b/~G_v1_ConfigMap|~X|web
+@@ -0,0 +1,4 @@
++apiVersion: v1
++kind: ConfigMap
++metadata:
++  name: web
+`
+	if out.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, out.String())
+	}
+}
+
+func TestRunDiffGitRefs(t *testing.T) {
+	gitProgram, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("no git program on path")
+	}
+	dir, err := ioutil.TempDir("", "kustomize-diff-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	run := func(args ...string) {
+		cmd := exec.Command(gitProgram, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com",
+			"GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(level string) {
+		err := os.MkdirAll(filepath.Join(dir, "app"), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(
+			filepath.Join(dir, "app", "kustomization.yaml"),
+			[]byte("resources:\n- configmap.yaml\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(
+			filepath.Join(dir, "app", "configmap.yaml"), []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  level: `+level+`
+`), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("init", "-q")
+	write("info")
+	run("add", ".")
+	run("commit", "-q", "-m", "one")
+	run("tag", "v1")
+	write("debug")
+	run("commit", "-q", "-a", "-m", "two")
+	run("tag", "v2")
+	write("warn")
+
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	diff := func(fromRef, toRef string) string {
+		o := Options{
+			pathA:          filepath.Join(dir, "app"),
+			pathB:          filepath.Join(dir, "app"),
+			fromRef:        fromRef,
+			toRef:          toRef,
+			loadRestrictor: loader.RestrictionRootOnly,
+		}
+		var out bytes.Buffer
+		err := o.RunDiff(
+			&out, validators.MakeFakeValidator(), fs.MakeRealFS(), rf,
+			transformer.NewFactoryImpl(),
+			plugins.NewLoader(plugins.DefaultPluginConfig(), rf))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return out.String()
+	}
+	expected := func(from, to string) string {
+		return `--- a/~G_v1_ConfigMap|~X|settings
++++ b/~G_v1_ConfigMap|~X|settings
+@@ -1,6 +1,6 @@
+ apiVersion: v1
+ data:
+-  level: ` + from + `
++  level: ` + to + `
+ kind: ConfigMap
+ metadata:
+   name: settings
+`
+	}
+	if got := diff("v1", "v2"); got != expected("info", "debug") {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected("info", "debug"), got)
+	}
+	if got := diff("v1", ""); got != expected("info", "warn") {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected("info", "warn"), got)
+	}
+}
+
+func TestWriteDiffAmbiguousIds(t *testing.T) {
+	rf := resmap.NewFactory(resource.NewFactory(
+		kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	a, err := rf.NewResMapFromBytes([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: one
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: two
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := a.DeepCopy()
+	a.Resources()[1].SetName("one")
+	var out bytes.Buffer
+	err = writeDiff(&out, a, b)
+	if err == nil || !strings.Contains(err.Error(),
+		"2 resources in a have the id ~G_v1_ConfigMap|~X|one") {
+		t.Fatalf("expected an ambiguous id error, got %v", err)
+	}
+	err = writeDiff(&out, b, a)
+	if err == nil || !strings.Contains(err.Error(), "2 resources in b") {
+		t.Fatalf("expected an ambiguous id error, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no output, got %s", out.String())
+	}
+}
+
+func TestWriteUnifiedSeparateHunks(t *testing.T) {
+	a := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"}
+	b := []string{"1", "two", "3", "4", "5", "6", "7", "8", "9", "10", "11", "twelve"}
+	var out bytes.Buffer
+	err := writeUnified(&out, "a/x", "b/x", a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `--- a/x
++++ b/x
+@@ -1,5 +1,5 @@
+ 1
+-2
++two
+ 3
+ 4
+ 5
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-12
++twelve
+`
+	if out.String() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, out.String())
+	}
+}
+
+func TestWriteUnifiedEqual(t *testing.T) {
+	var out bytes.Buffer
+	err := writeUnified(&out, "a/x", "b/x", []string{"a"}, []string{"a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("expected no output, got %s", out.String())
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Lines of unchanged context around each hunk.
+	contextLines = 3
+	devNull      = "/dev/null"
+)
+
+// writeDiff writes a unified diff of the resources in
+// b relative to those in a.  Resources are matched by
+// current id.  Removed resources are reported in the
+// order they appear in a, followed by added resources
+// in the order they appear in b.  A current id held by
+// more than one resource on either side is an error, as
+// such resources can't be matched.
+func writeDiff(out io.Writer, a, b resmap.ResMap) error {
+	if err := checkUniqueIds("a", a); err != nil {
+		return err
+	}
+	if err := checkUniqueIds("b", b); err != nil {
+		return err
+	}
+	for _, ra := range a.Resources() {
+		id := ra.CurId()
+		linesA, err := asLines(ra)
+		if err != nil {
+			return err
+		}
+		nameB := devNull
+		var linesB []string
+		matches := b.GetMatchingResourcesByCurrentId(id.Equals)
+		if len(matches) == 1 {
+			nameB = "b/" + id.String()
+			linesB, err = asLines(matches[0])
+			if err != nil {
+				return err
+			}
+		}
+		err = writeUnified(out, "a/"+id.String(), nameB, linesA, linesB)
+		if err != nil {
+			return err
+		}
+	}
+	for _, rb := range b.Resources() {
+		id := rb.CurId()
+		if len(a.GetMatchingResourcesByCurrentId(id.Equals)) > 0 {
+			continue
+		}
+		linesB, err := asLines(rb)
+		if err != nil {
+			return err
+		}
+		err = writeUnified(out, devNull, "b/"+id.String(), nil, linesB)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkUniqueIds(side string, m resmap.ResMap) error {
+	for _, r := range m.Resources() {
+		id := r.CurId()
+		if n := len(m.GetMatchingResourcesByCurrentId(id.Equals)); n > 1 {
+			return fmt.Errorf(
+				"%d resources in %s have the id %s, so they can't be told apart",
+				n, side, id)
+		}
+	}
+	return nil
+}
+
+func asLines(r *resource.Resource) ([]string, error) {
+	y, err := yaml.Marshal(r.Map())
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(y), "\n"), "\n"), nil
+}
+
+// edit is one line of an edit script; kind is
+// ' ' for a kept line, '-' for a deleted line
+// and '+' for an inserted line.
+type edit struct {
+	kind byte
+	text string
+}
+
+// editScript returns a minimal edit script turning
+// a into b, computed from a longest common subsequence.
+func editScript(a, b []string) []edit {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var result []edit
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, edit{'-', a[i]})
+			i++
+		default:
+			result = append(result, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, edit{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		result = append(result, edit{'+', b[j]})
+	}
+	return result
+}
+
+// writeUnified writes the differences between a and b
+// in unified diff format, writing nothing if they're equal.
+func writeUnified(
+	out io.Writer, nameA, nameB string, a, b []string) error {
+	edits := editScript(a, b)
+	var changes []int
+	for k, e := range edits {
+		if e.kind != ' ' {
+			changes = append(changes, k)
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	// posA[k] and posB[k] count the lines of a and b
+	// consumed before edit k.
+	posA := make([]int, len(edits)+1)
+	posB := make([]int, len(edits)+1)
+	for k, e := range edits {
+		posA[k+1] = posA[k]
+		posB[k+1] = posB[k]
+		if e.kind != '+' {
+			posA[k+1]++
+		}
+		if e.kind != '-' {
+			posB[k+1]++
+		}
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", nameA, nameB)
+	for c := 0; c < len(changes); {
+		start := changes[c] - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := changes[c] + 1
+		c++
+		// Merge changes whose context would overlap.
+		for c < len(changes) && changes[c]-end <= 2*contextLines {
+			end = changes[c] + 1
+			c++
+		}
+		end += contextLines
+		if end > len(edits) {
+			end = len(edits)
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			hunkRange(posA[start], posA[end]),
+			hunkRange(posB[start], posB[end]))
+		for _, e := range edits[start:end] {
+			buf.WriteByte(e.kind)
+			buf.WriteString(e.text)
+			buf.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(out, buf.String())
+	return err
+}
+
+// hunkRange formats the line range [from, to) of
+// a hunk header; line numbers are one-based, but an
+// empty range names the line preceding it.
+func hunkRange(from, to int) string {
+	if to == from {
+		return fmt.Sprintf("%d,0", from)
+	}
+	if to-from == 1 {
+		return fmt.Sprintf("%d", from+1)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
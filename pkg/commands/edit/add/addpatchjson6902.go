@@ -0,0 +1,104 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package add
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type addPatchJson6902Options struct {
+	path   string
+	target types.PatchTarget
+}
+
+// newCmdAddPatchJson6902 adds a json patch to the kustomization file.
+func newCmdAddPatchJson6902(fsys fs.FileSystem) *cobra.Command {
+	var o addPatchJson6902Options
+
+	cmd := &cobra.Command{
+		Use:   "patchjson6902",
+		Short: "Add a json patch, and the resource it patches, to the kustomization file.",
+		Example: `
+		add patchjson6902 {filepath} --group apps --version v1 --kind Deployment --name my-deployment`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunAddPatchJson6902(fsys)
+		},
+	}
+	addPatchTargetFlags(cmd, &o.target)
+	return cmd
+}
+
+func addPatchTargetFlags(cmd *cobra.Command, t *types.PatchTarget) {
+	cmd.Flags().StringVar(&t.Group, "group", "",
+		"group of the resource to patch")
+	cmd.Flags().StringVar(&t.Version, "version", "",
+		"version of the resource to patch")
+	cmd.Flags().StringVar(&t.Kind, "kind", "",
+		"kind of the resource to patch")
+	cmd.Flags().StringVar(&t.Name, "name", "",
+		"name of the resource to patch")
+	cmd.Flags().StringVar(&t.Namespace, "namespace", "",
+		"namespace of the resource to patch")
+}
+
+// Validate validates addPatchJson6902 command.
+func (o *addPatchJson6902Options) Validate(args []string) error {
+	if len(args) != 1 {
+		return errors.New("must specify one json patch file")
+	}
+	if o.target.Kind == "" || o.target.Name == "" {
+		return errors.New("must specify the kind and name of the resource to patch")
+	}
+	o.path = args[0]
+	return nil
+}
+
+// Complete completes addPatchJson6902 command.
+func (o *addPatchJson6902Options) Complete(cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// RunAddPatchJson6902 runs addPatchJson6902 command (do real work).
+func (o *addPatchJson6902Options) RunAddPatchJson6902(fSys fs.FileSystem) error {
+	if !fSys.Exists(o.path) {
+		return fmt.Errorf("json patch file %s doesn't exist", o.path)
+	}
+
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range m.PatchesJson6902 {
+		if p.Path == o.path && p.Target != nil && *p.Target == o.target {
+			log.Printf("patchjson6902 %s already in kustomization file", o.path)
+			return nil
+		}
+	}
+	target := o.target
+	m.PatchesJson6902 = append(m.PatchesJson6902,
+		types.PatchJson6902{Target: &target, Path: o.path})
+
+	return mf.Write(m)
+}
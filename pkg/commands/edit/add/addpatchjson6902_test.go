@@ -0,0 +1,75 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package add
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestAddPatchJson6902(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteFile("patch.yaml", []byte(`- op: remove
+  path: /spec/replicas
+`))
+	fakeFS.WriteTestKustomizationWith([]byte(`# Patches for the deployments
+patchesJson6902:
+- path: other.yaml
+  target:
+    kind: Deployment
+    name: other
+`))
+
+	for i := 0; i < 2; i++ {
+		// adding an existing patch doesn't return an error
+		cmd := newCmdAddPatchJson6902(fakeFS)
+		cmd.SetArgs([]string{"patch.yaml",
+			"--group", "apps", "--version", "v1",
+			"--kind", "Deployment", "--name", "my-deployment"})
+		err := cmd.Execute()
+		if err != nil {
+			t.Fatalf("unexpected cmd error: %v", err)
+		}
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `# Patches for the deployments
+patchesJson6902:
+- path: other.yaml
+  target:
+    kind: Deployment
+    name: other
+- path: patch.yaml
+  target:
+    group: apps
+    kind: Deployment
+    name: my-deployment
+    version: v1
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
+
+func TestAddPatchJson6902NoFile(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomization()
+
+	cmd := newCmdAddPatchJson6902(fakeFS)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"patch.yaml", "--kind", "Deployment", "--name", "d"})
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "json patch file patch.yaml doesn't exist" {
+		t.Errorf("incorrect error: %v", err.Error())
+	}
+}
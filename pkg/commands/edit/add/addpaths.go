@@ -0,0 +1,136 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package add
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/commands/util"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+// pathsField is a kustomization field holding a list of file paths.
+type pathsField struct {
+	// kind names one entry of the field, e.g. generator.
+	kind  string
+	field func(m *types.Kustomization) *[]string
+}
+
+var (
+	generatorsField = pathsField{"generator",
+		func(m *types.Kustomization) *[]string { return &m.Generators }}
+	transformersField = pathsField{"transformer",
+		func(m *types.Kustomization) *[]string { return &m.Transformers }}
+	configurationsField = pathsField{"configuration",
+		func(m *types.Kustomization) *[]string { return &m.Configurations }}
+	crdsField = pathsField{"crd",
+		func(m *types.Kustomization) *[]string { return &m.Crds }}
+)
+
+type addPathsOptions struct {
+	field     pathsField
+	filePaths []string
+}
+
+// newCmdAddGenerator adds the name of a generator plugin
+// config file to the kustomization file.
+func newCmdAddGenerator(fsys fs.FileSystem) *cobra.Command {
+	return newCmdAddPaths(fsys, generatorsField,
+		"Add the name of a file configuring a generator plugin to the kustomization file.")
+}
+
+// newCmdAddTransformer adds the name of a transformer plugin
+// config file to the kustomization file.
+func newCmdAddTransformer(fsys fs.FileSystem) *cobra.Command {
+	return newCmdAddPaths(fsys, transformersField,
+		"Add the name of a file configuring a transformer plugin to the kustomization file.")
+}
+
+// newCmdAddConfiguration adds the name of a transformer
+// configuration file to the kustomization file.
+func newCmdAddConfiguration(fsys fs.FileSystem) *cobra.Command {
+	return newCmdAddPaths(fsys, configurationsField,
+		"Add the name of a transformer configuration file to the kustomization file.")
+}
+
+// newCmdAddCrd adds the name of a file containing a
+// CRD to the kustomization file.
+func newCmdAddCrd(fsys fs.FileSystem) *cobra.Command {
+	return newCmdAddPaths(fsys, crdsField,
+		"Add the name of a file containing a CRD to the kustomization file.")
+}
+
+func newCmdAddPaths(fsys fs.FileSystem, f pathsField, short string) *cobra.Command {
+	o := addPathsOptions{field: f}
+
+	cmd := &cobra.Command{
+		Use:   f.kind,
+		Short: short,
+		Example: fmt.Sprintf(`
+		add %s {filepath}`, f.kind),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunAddPaths(fsys)
+		},
+	}
+	return cmd
+}
+
+// Validate validates addPaths command.
+func (o *addPathsOptions) Validate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("must specify a " + o.field.kind + " file")
+	}
+	o.filePaths = args
+	return nil
+}
+
+// Complete completes addPaths command.
+func (o *addPathsOptions) Complete(cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// RunAddPaths runs addPaths command (do real work).
+func (o *addPathsOptions) RunAddPaths(fSys fs.FileSystem) error {
+	paths, err := util.GlobPatterns(fSys, o.filePaths)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	list := o.field.field(m)
+	for _, path := range paths {
+		if kustfile.StringInSlice(path, *list) {
+			log.Printf("%s %s already in kustomization file", o.field.kind, path)
+			continue
+		}
+		*list = append(*list, path)
+	}
+
+	return mf.Write(m)
+}
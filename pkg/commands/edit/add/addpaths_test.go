@@ -0,0 +1,68 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package add
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestAddPaths(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteFile("gen.yaml", []byte(""))
+	fakeFS.WriteFile("crd1.yaml", []byte(""))
+	fakeFS.WriteFile("crd2.yaml", []byte(""))
+	fakeFS.WriteTestKustomizationWith([]byte(`# The generators
+generators:
+- other.yaml
+`))
+
+	cmd := newCmdAddGenerator(fakeFS)
+	err := cmd.RunE(cmd, []string{"gen.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	cmd = newCmdAddCrd(fakeFS)
+	err = cmd.RunE(cmd, []string{"crd*.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	// adding an existing generator doesn't return an error
+	cmd = newCmdAddGenerator(fakeFS)
+	err = cmd.RunE(cmd, []string{"gen.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `# The generators
+generators:
+- other.yaml
+- gen.yaml
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+crds:
+- crd1.yaml
+- crd2.yaml
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
+
+func TestAddPathsNoArgs(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+
+	cmd := newCmdAddTransformer(fakeFS)
+	err := cmd.Execute()
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+	if err.Error() != "must specify a transformer file" {
+		t.Errorf("incorrect error: %v", err.Error())
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package add
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type addVarOptions struct {
+	v types.Var
+}
+
+// newCmdAddVar adds a var to the kustomization file.
+func newCmdAddVar(fsys fs.FileSystem) *cobra.Command {
+	var o addVarOptions
+
+	cmd := &cobra.Command{
+		Use:   "var",
+		Short: "Add a var to the kustomization file.",
+		Example: `
+		add var MY_SERVICE_NAME --kind Service --name my-service
+		add var MY_PORT --kind Service --version v1 --name my-service --fieldpath spec.ports[0].port`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunAddVar(fsys)
+		},
+	}
+	cmd.Flags().StringVar(&o.v.ObjRef.Group, "group", "",
+		"group of the resource holding the value")
+	cmd.Flags().StringVar(&o.v.ObjRef.Version, "version", "",
+		"version of the resource holding the value")
+	cmd.Flags().StringVar(&o.v.ObjRef.Kind, "kind", "",
+		"kind of the resource holding the value")
+	cmd.Flags().StringVar(&o.v.ObjRef.Name, "name", "",
+		"name of the resource holding the value")
+	cmd.Flags().StringVar(&o.v.ObjRef.Namespace, "namespace", "",
+		"namespace of the resource holding the value")
+	cmd.Flags().StringVar(&o.v.FieldRef.FieldPath, "fieldpath", "",
+		"path to the field holding the value, metadata.name if not set")
+	return cmd
+}
+
+// Validate validates addVar command.
+func (o *addVarOptions) Validate(args []string) error {
+	if len(args) != 1 {
+		return errors.New("must specify one var name")
+	}
+	if o.v.ObjRef.Kind == "" || o.v.ObjRef.Name == "" {
+		return errors.New("must specify the kind and name of the resource holding the value")
+	}
+	o.v.Name = args[0]
+	return nil
+}
+
+// Complete completes addVar command.
+func (o *addVarOptions) Complete(cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// RunAddVar runs addVar command (do real work).
+func (o *addVarOptions) RunAddVar(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range m.Vars {
+		if v.Name == o.v.Name {
+			return fmt.Errorf("var %s already in kustomization file", o.v.Name)
+		}
+	}
+	m.Vars = append(m.Vars, o.v)
+
+	return mf.Write(m)
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package add
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestAddVar(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`# The resources
+resources:
+- service.yaml
+`))
+
+	cmd := newCmdAddVar(fakeFS)
+	cmd.SetArgs([]string{"SERVICE_PORT",
+		"--kind", "Service", "--version", "v1", "--name", "my-service",
+		"--fieldpath", "spec.ports[0].port"})
+	err := cmd.Execute()
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `# The resources
+resources:
+- service.yaml
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+vars:
+- fieldref:
+    fieldPath: spec.ports[0].port
+  name: SERVICE_PORT
+  objref:
+    kind: Service
+    name: my-service
+    version: v1
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+
+	cmd = newCmdAddVar(fakeFS)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"SERVICE_PORT", "--kind", "Service", "--name", "other"})
+	err = cmd.Execute()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "var SERVICE_PORT already in kustomization file" {
+		t.Errorf("incorrect error: %v", err.Error())
+	}
+}
+
+func TestAddVarNoTarget(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomization()
+
+	cmd := newCmdAddVar(fakeFS)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"SERVICE_NAME", "--kind", "Service"})
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "must specify the kind and name of the resource holding the value" {
+		t.Errorf("incorrect error: %v", err.Error())
+	}
+}
@@ -40,6 +40,20 @@ func NewCmdAdd(
 
 	# Adds one or more commonAnnotations to the kustomization
 	kustomize edit add annotation {annotationKey1:annotationValue1},{annotationKey2:annotationValue2}
+
+	# Adds a var to the kustomization
+	kustomize edit add var NAME --kind Service --name my-service
+
+	# Adds a json patch, and the resource it patches, to the kustomization
+	kustomize edit add patchjson6902 <filepath> --kind Deployment --name my-deployment
+
+	# Adds generator or transformer plugin config files to the kustomization
+	kustomize edit add generator <filepath>
+	kustomize edit add transformer <filepath>
+
+	# Adds transformer configuration or CRD files to the kustomization
+	kustomize edit add configuration <filepath>
+	kustomize edit add crd <filepath>
 `,
 		Args: cobra.MinimumNArgs(1),
 	}
@@ -51,6 +65,12 @@ func NewCmdAdd(
 		newCmdAddBase(fSys),
 		newCmdAddLabel(fSys, ldr.Validator().MakeLabelValidator()),
 		newCmdAddAnnotation(fSys, ldr.Validator().MakeAnnotationValidator()),
+		newCmdAddVar(fSys),
+		newCmdAddPatchJson6902(fSys),
+		newCmdAddGenerator(fSys),
+		newCmdAddTransformer(fSys),
+		newCmdAddConfiguration(fSys),
+		newCmdAddCrd(fSys),
 	)
 	return c
 }
@@ -6,17 +6,21 @@ package edit
 import (
 	"github.com/spf13/cobra"
 	"github.com/irairdon/kustomize/v3/pkg/commands/edit/add"
+	"github.com/irairdon/kustomize/v3/pkg/commands/edit/derive"
 	"github.com/irairdon/kustomize/v3/pkg/commands/edit/fix"
+	"github.com/irairdon/kustomize/v3/pkg/commands/edit/lock"
 	"github.com/irairdon/kustomize/v3/pkg/commands/edit/remove"
 	"github.com/irairdon/kustomize/v3/pkg/commands/edit/set"
 	"github.com/irairdon/kustomize/v3/pkg/fs"
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
 	"github.com/irairdon/kustomize/v3/pkg/loader"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
 )
 
 // NewCmdEdit returns an instance of 'edit' subcommand.
 func NewCmdEdit(
-	fSys fs.FileSystem, v ifc.Validator, kf ifc.KunstructuredFactory) *cobra.Command {
+	fSys fs.FileSystem, v ifc.Validator, kf ifc.KunstructuredFactory,
+	rf *resmap.Factory, ptf resmap.PatchFactory) *cobra.Command {
 	c := &cobra.Command{
 		Use:   "edit",
 		Short: "Edits a kustomization file",
@@ -30,6 +34,12 @@ func NewCmdEdit(
 
 	# Sets the namesuffix field
 	kustomize edit set namesuffix <suffix-value>
+
+	# Pins remote bases to commits
+	kustomize edit lock
+
+	# Derives the fields and patches that turn a base into a forked copy
+	kustomize edit derive ../base forked.yaml
 `,
 		Args: cobra.MinimumNArgs(1),
 	}
@@ -37,7 +47,9 @@ func NewCmdEdit(
 	c.AddCommand(
 		add.NewCmdAdd(fSys, loader.NewFileLoaderAtCwd(v, fSys), kf),
 		set.NewCmdSet(fSys, v),
-		fix.NewCmdFix(fSys),
+		fix.NewCmdFix(fSys, v, rf, ptf),
+		derive.NewCmdDerive(fSys, v, rf, ptf),
+		lock.NewCmdLock(fSys, v),
 		remove.NewCmdRemove(fSys, loader.NewFileLoaderAtCwd(v, fSys)),
 	)
 	return c
@@ -0,0 +1,177 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package derive implements `kustomize edit derive`.
+package derive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/loader"
+	"github.com/irairdon/kustomize/v3/pkg/plugins"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/target"
+)
+
+type deriveOptions struct {
+	base    string
+	desired string
+	// dir holds the kustomization to derive into.
+	dir string
+}
+
+// deriver holds what deriving an overlay needs to build.
+type deriver struct {
+	fSys fs.FileSystem
+	v    ifc.Validator
+	rf   *resmap.Factory
+	ptf  resmap.PatchFactory
+	out  io.Writer
+}
+
+// NewCmdDerive returns an instance of 'derive' subcommand.
+func NewCmdDerive(
+	fSys fs.FileSystem, v ifc.Validator,
+	rf *resmap.Factory, ptf resmap.PatchFactory) *cobra.Command {
+	o := deriveOptions{dir: "."}
+	cmd := &cobra.Command{
+		Use:   "derive {base} {desired}",
+		Short: "Derive the fields and patches that turn a base into the desired resources",
+		Long: `Derive the fields and patches that turn the build of a base into the
+resources in a file, e.g. a hand edited copy of the base, and write them
+to the kustomization file in the current directory.
+
+The namespace, namePrefix, nameSuffix, images and replicas fields are set
+where every resource agrees on them, and patch files are written for the
+rest: strategic merge patches for built-in kinds, JSON patches for others.
+Resources only in the desired file are added as resources, and those only
+in the base are deleted by patches.`,
+		Example: `
+	# Turn a forked copy of a base into an overlay of it
+	kustomize edit derive ../base forked.yaml
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			d := &deriver{
+				fSys: fSys, v: v, rf: rf, ptf: ptf, out: cmd.OutOrStdout()}
+			return o.RunDerive(d)
+		},
+	}
+	return cmd
+}
+
+// Validate validates derive command.
+func (o *deriveOptions) Validate(args []string) error {
+	if len(args) != 2 {
+		return errors.New("must specify a base and a file of desired resources")
+	}
+	o.base = args[0]
+	o.desired = args[1]
+	return nil
+}
+
+// RunDerive runs derive command (do real work).
+func (o *deriveOptions) RunDerive(d *deriver) error {
+	mf, err := kustfile.NewKustomizationFileInDir(d.fSys, o.dir)
+	if err != nil {
+		return err
+	}
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+	base, err := d.build(filepath.Join(o.dir, o.base))
+	if err != nil {
+		return err
+	}
+	data, err := d.fSys.ReadFile(o.desired)
+	if err != nil {
+		return err
+	}
+	desired, err := d.rf.NewResMapFromBytes(data)
+	if err != nil {
+		return err
+	}
+
+	if !kustfile.StringInSlice(o.base, m.Resources) {
+		m.Resources = append(m.Resources, o.base)
+	}
+	f := deriveFields(base, desired)
+	f.apply(m)
+	err = mf.Write(m)
+	if err != nil {
+		return err
+	}
+
+	// Build the overlay with just the fields, and
+	// patch whatever they didn't take care of.
+	built, err := d.build(o.dir)
+	if err != nil {
+		return err
+	}
+	err = d.derivePatches(o.dir, m, f, built, desired)
+	if err != nil {
+		return err
+	}
+	err = mf.Write(m)
+	if err != nil {
+		return err
+	}
+
+	final, err := d.build(o.dir)
+	if err != nil {
+		return err
+	}
+	return d.report(final, desired)
+}
+
+func (d *deriver) build(path string) (resmap.ResMap, error) {
+	ldr, err := loader.NewLoader(
+		loader.RestrictionRootOnly, d.v, path, d.fSys)
+	if err != nil {
+		return nil, err
+	}
+	defer ldr.Cleanup()
+	kt, err := target.NewKustTarget(
+		ldr, d.rf, d.ptf,
+		plugins.NewLoader(plugins.DefaultPluginConfig(), d.rf))
+	if err != nil {
+		return nil, err
+	}
+	return kt.MakeCustomizedResMap()
+}
+
+// report says which desired resources the
+// overlay doesn't reproduce.
+func (d *deriver) report(final, desired resmap.ResMap) error {
+	pairs, onlyFinal, onlyDesired := pairByName(final, desired)
+	rn := renames(pairs)
+	differ := 0
+	for _, p := range pairs {
+		if !p.equal(rn) {
+			differ++
+			fmt.Fprintf(d.out, "%s differs from the desired %s\n",
+				p.built.CurId(), p.desired.CurId())
+		}
+	}
+	for _, r := range onlyFinal {
+		fmt.Fprintf(d.out, "%s isn't desired\n", r.CurId())
+	}
+	for _, r := range onlyDesired {
+		fmt.Fprintf(d.out, "%s is missing\n", r.CurId())
+	}
+	fmt.Fprintf(d.out, "the overlay reproduces %d of %d desired resources\n",
+		len(pairs)-differ, desired.Size())
+	return nil
+}
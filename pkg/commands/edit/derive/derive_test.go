@@ -0,0 +1,212 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package derive
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/k8sdeps/kunstruct"
+	"github.com/irairdon/kustomize/v3/k8sdeps/transformer"
+	"github.com/irairdon/kustomize/v3/k8sdeps/validator"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+)
+
+func TestDerive(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteFile("/app/base/kustomization.yaml", []byte(`
+resources:
+- resources.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - level=info
+`))
+	fakeFS.WriteFile("/app/base/resources.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.16
+        envFrom:
+        - configMapRef:
+            name: settings
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: web
+spec:
+  size: 1
+  color: red
+`))
+	fakeFS.WriteFile("/app/overlay/kustomization.yaml", []byte(``))
+	fakeFS.WriteFile("/app/forked.yaml", []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: prod-settings-abcdefghij
+  namespace: prod
+data:
+  level: debug
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-web
+  namespace: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.17
+        envFrom:
+        - configMapRef:
+            name: prod-settings-abcdefghij
+        env:
+        - name: MODE
+          value: prod
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: prod-web
+  namespace: prod
+spec:
+  size: 2
+  color: red
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: prod-robot
+  namespace: prod
+`))
+
+	uf := kunstruct.NewKunstructuredFactoryImpl()
+	ptf := transformer.NewFactoryImpl()
+	var out bytes.Buffer
+	d := &deriver{
+		fSys: fakeFS,
+		v:    validator.NewKustValidator(),
+		rf:   resmap.NewFactory(resource.NewFactory(uf), ptf),
+		ptf:  ptf,
+		out:  &out,
+	}
+	o := deriveOptions{
+		base:    "../base",
+		desired: "/app/forked.yaml",
+		dir:     "/app/overlay",
+	}
+	err := o.RunDerive(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `set namespace to prod
+set namePrefix to prod-
+set image nginx
+set replicas of web to 3
+the overlay reproduces 4 of 4 desired resources
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, out.String())
+	}
+
+	kf, err := kustfile.NewKustomizationFileInDir(fakeFS, "/app/overlay")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := fakeFS.ReadFile(kf.Path())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"- patch_deployment_web.yaml",
+		"- patch_configmap_settings.yaml",
+		"- delete_service_web.yaml",
+		"- serviceaccount_robot.yaml",
+		"path: patch_widget_web.yaml",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected kustomization to contain %q:\n%s", want, content)
+		}
+	}
+	for file, expected := range map[string]string{
+		"patch_widget_web.yaml": `- op: replace
+  path: /spec/size
+  value: 2
+`,
+		"patch_configmap_settings.yaml": `apiVersion: v1
+data:
+  level: debug
+kind: ConfigMap
+metadata:
+  name: settings
+`,
+		"serviceaccount_robot.yaml": `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: robot
+`,
+	} {
+		content, err := fakeFS.ReadFile("/app/overlay/" + file)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(content) != expected {
+			t.Errorf("expected %s:\n%s\nbut got:\n%s", file, expected, content)
+		}
+	}
+}
+
+func TestDiffOps(t *testing.T) {
+	a := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"a/b":   "x",
+			"gone":  true,
+			"ports": []interface{}{int64(80), int64(443)},
+			"hosts": []interface{}{"a"},
+		},
+	}
+	b := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"a/b":   "y",
+			"new":   "z",
+			"ports": []interface{}{int64(80), int64(8443)},
+			"hosts": []interface{}{"a", "b"},
+		},
+	}
+	expected := []map[string]interface{}{
+		{"op": "replace", "path": "/spec/a~1b", "value": "y"},
+		{"op": "remove", "path": "/spec/gone"},
+		{"op": "replace", "path": "/spec/hosts", "value": []interface{}{"a", "b"}},
+		{"op": "add", "path": "/spec/new", "value": "z"},
+		{"op": "replace", "path": "/spec/ports/1", "value": int64(8443)},
+	}
+	if ops := diffOps("", a, b); !reflect.DeepEqual(ops, expected) {
+		t.Errorf("expected %v, got %v", expected, ops)
+	}
+}
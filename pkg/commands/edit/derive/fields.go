@@ -0,0 +1,353 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package derive
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/image"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+// fields are the kustomization fields that every pair
+// of a base resource and a desired one agrees on.
+type fields struct {
+	namespace string
+	prefix    string
+	suffix    string
+	images    []image.Image
+	replicas  []types.Replica
+}
+
+func (f *fields) apply(m *types.Kustomization) {
+	if f.namespace != "" {
+		m.Namespace = f.namespace
+	}
+	if f.prefix != "" {
+		m.NamePrefix = f.prefix
+	}
+	if f.suffix != "" {
+		m.NameSuffix = f.suffix
+	}
+	m.Images = append(m.Images, f.images...)
+	m.Replicas = append(m.Replicas, f.replicas...)
+}
+
+// pair is a resource as built, and as desired.
+type pair struct {
+	built   *resource.Resource
+	desired *resource.Resource
+}
+
+// hashSuffix matches the suffix build adds
+// to the names of generated resources.
+var hashSuffix = regexp.MustCompile(`-[a-z0-9]{10}$`)
+
+func hashed(r *resource.Resource) bool {
+	return r.NeedHashSuffix() && !r.Skips(resource.SkipHash)
+}
+
+// stem returns the name of r before build added a hash suffix.
+func stem(r *resource.Resource) string {
+	if hashed(r) {
+		return hashSuffix.ReplaceAllString(r.GetName(), "")
+	}
+	return r.GetName()
+}
+
+// names returns the names a desired resource pairing
+// with r could have, given how r is named.
+func names(r, desired *resource.Resource) []string {
+	result := []string{desired.GetName()}
+	if hashed(r) && hashSuffix.MatchString(desired.GetName()) {
+		result = append(result,
+			hashSuffix.ReplaceAllString(desired.GetName(), ""))
+	}
+	return result
+}
+
+// pairBy pairs each built resource with the first unpaired
+// desired resource that matches it, returning the pairs and
+// the resources of each side left unpaired.
+func pairBy(
+	built, desired resmap.ResMap,
+	matches func(b, d *resource.Resource) bool) (
+	pairs []pair, onlyBuilt, onlyDesired []*resource.Resource) {
+	used := make(map[*resource.Resource]bool)
+	for _, b := range built.Resources() {
+		found := false
+		for _, d := range desired.Resources() {
+			if used[d] || !matches(b, d) {
+				continue
+			}
+			used[d] = true
+			pairs = append(pairs, pair{built: b, desired: d})
+			found = true
+			break
+		}
+		if !found {
+			onlyBuilt = append(onlyBuilt, b)
+		}
+	}
+	for _, d := range desired.Resources() {
+		if !used[d] {
+			onlyDesired = append(onlyDesired, d)
+		}
+	}
+	return
+}
+
+// pairByName pairs resources with the same
+// id, give or take a hash suffix.
+func pairByName(built, desired resmap.ResMap) (
+	[]pair, []*resource.Resource, []*resource.Resource) {
+	return pairBy(built, desired, func(b, d *resource.Resource) bool {
+		if !b.CurId().IsNsEquals(d.CurId()) || !b.GetGvk().Equals(d.GetGvk()) {
+			return false
+		}
+		for _, n := range names(b, d) {
+			if n == stem(b) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// deriveFields finds the fields that turn the base
+// into what's desired, as far as they can.
+func deriveFields(base, desired resmap.ResMap) *fields {
+	f := &fields{}
+	f.prefix, f.suffix = deriveAffixes(base, desired)
+	pairs, _, _ := pairBy(base, desired, func(b, d *resource.Resource) bool {
+		if !b.GetGvk().Equals(d.GetGvk()) {
+			return false
+		}
+		for _, n := range names(b, d) {
+			if n == f.prefix+stem(b)+f.suffix {
+				return true
+			}
+		}
+		return false
+	})
+	f.namespace = deriveNamespace(pairs)
+	f.images = deriveImages(pairs)
+	f.replicas = deriveReplicas(pairs)
+	return f
+}
+
+// deriveAffixes returns the name prefix and suffix
+// that pair the most base resources with desired ones.
+func deriveAffixes(base, desired resmap.ResMap) (string, string) {
+	counts := make(map[[2]string]int)
+	for _, b := range base.Resources() {
+		s := stem(b)
+		seen := make(map[[2]string]bool)
+		for _, d := range desired.Resources() {
+			if !b.GetGvk().Equals(d.GetGvk()) {
+				continue
+			}
+			for _, n := range names(b, d) {
+				for i := strings.Index(n, s); i >= 0 && s != ""; {
+					seen[[2]string{n[:i], n[i+len(s):]}] = true
+					j := strings.Index(n[i+1:], s)
+					if j < 0 {
+						break
+					}
+					i += j + 1
+				}
+			}
+		}
+		for affixes := range seen {
+			counts[affixes]++
+		}
+	}
+	var best [2]string
+	bestCount := 0
+	for affixes, c := range counts {
+		if c > bestCount || (c == bestCount && shorter(affixes, best)) {
+			best, bestCount = affixes, c
+		}
+	}
+	return best[0], best[1]
+}
+
+func shorter(a, b [2]string) bool {
+	la, lb := len(a[0])+len(a[1]), len(b[0])+len(b[1])
+	if la != lb {
+		return la < lb
+	}
+	return a[0]+"|"+a[1] < b[0]+"|"+b[1]
+}
+
+// deriveNamespace returns the namespace all desired
+// namespaced resources are in, if the base has any
+// resource elsewhere.
+func deriveNamespace(pairs []pair) string {
+	namespace := ""
+	moved := false
+	for _, p := range pairs {
+		if !p.desired.GetGvk().IsNamespaceableKind() {
+			continue
+		}
+		ns := p.desired.GetNamespace()
+		if ns == "" || (namespace != "" && ns != namespace) {
+			return ""
+		}
+		namespace = ns
+		moved = moved || p.built.GetNamespace() != ns
+	}
+	if !moved {
+		return ""
+	}
+	return namespace
+}
+
+// deriveImages returns an images entry for each image
+// name that's changed the same way everywhere it's used.
+func deriveImages(pairs []pair) []image.Image {
+	changes := make(map[string]map[string]bool)
+	changed := make(map[string]bool)
+	for _, p := range pairs {
+		desired := imagesByPath(p.desired.Map())
+		for path, old := range imagesByPath(p.built.Map()) {
+			name, _, _ := splitImage(old)
+			if changes[name] == nil {
+				changes[name] = make(map[string]bool)
+			}
+			now, ok := desired[path]
+			if !ok {
+				now = old
+			}
+			changes[name][now] = true
+			changed[name] = changed[name] || now != old
+		}
+	}
+	var result []image.Image
+	for name, to := range changes {
+		if len(to) != 1 || !changed[name] {
+			continue
+		}
+		for now := range to {
+			if img, ok := imageChange(name, now); ok {
+				result = append(result, img)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// imageChange returns the images entry turning images
+// named name into now, if there's anything to change.
+func imageChange(name, now string) (image.Image, bool) {
+	newName, tag, digest := splitImage(now)
+	img := image.Image{Name: name}
+	if newName != name {
+		img.NewName = newName
+	}
+	switch {
+	case digest != "":
+		img.Digest = digest
+	case tag != "":
+		img.NewTag = tag
+	}
+	return img, img.NewName != "" || img.NewTag != "" || img.Digest != ""
+}
+
+// imagesByPath returns the images of the containers
+// in obj, by the path to them.
+func imagesByPath(obj map[string]interface{}) map[string]string {
+	result := make(map[string]string)
+	var walk func(v interface{}, path string)
+	walk = func(v interface{}, path string) {
+		switch x := v.(type) {
+		case map[string]interface{}:
+			for k, item := range x {
+				if l, ok := item.([]interface{}); ok &&
+					(k == "containers" || k == "initContainers") {
+					for i, c := range l {
+						if m, ok := c.(map[string]interface{}); ok {
+							if img, ok := m["image"].(string); ok {
+								result[fmt.Sprintf("%s/%s/%d", path, k, i)] = img
+							}
+						}
+					}
+					continue
+				}
+				walk(item, path+"/"+k)
+			}
+		case []interface{}:
+			for i, item := range x {
+				walk(item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+	walk(obj, "")
+	return result
+}
+
+// splitImage splits an image into its name, tag and digest.
+func splitImage(img string) (name, tag, digest string) {
+	if i := strings.Index(img, "@"); i >= 0 {
+		img, digest = img[:i], img[i+1:]
+	}
+	if i := strings.LastIndex(img, ":"); i > strings.LastIndex(img, "/") {
+		img, tag = img[:i], img[i+1:]
+	}
+	return img, tag, digest
+}
+
+// replicaKinds are the kinds the replicas field reaches.
+var replicaKinds = map[string]bool{
+	"Deployment":            true,
+	"ReplicationController": true,
+	"ReplicaSet":            true,
+	"StatefulSet":           true,
+}
+
+// deriveReplicas returns a replicas entry for each
+// resource whose count changed, unless another
+// resource of the same name wants another count.
+func deriveReplicas(pairs []pair) []types.Replica {
+	counts := make(map[string]map[int64]bool)
+	for _, p := range pairs {
+		if !replicaKinds[p.built.GetKind()] {
+			continue
+		}
+		was, err := p.built.GetInt64("spec.replicas")
+		if err != nil {
+			continue
+		}
+		now, err := p.desired.GetInt64("spec.replicas")
+		if err != nil || now == was {
+			continue
+		}
+		name := p.built.OrgId().Name
+		if counts[name] == nil {
+			counts[name] = make(map[int64]bool)
+		}
+		counts[name][now] = true
+	}
+	var result []types.Replica
+	for name, c := range counts {
+		if len(c) != 1 {
+			continue
+		}
+		for count := range c {
+			result = append(result, types.Replica{Name: name, Count: count})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
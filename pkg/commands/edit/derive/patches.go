@@ -0,0 +1,296 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package derive
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/patch"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// derivePatches writes a patch for each built resource that
+// isn't as desired, a deleting patch for each built resource
+// that isn't desired at all, and a resource file for each
+// desired resource that wasn't built, listing them all in m.
+func (d *deriver) derivePatches(
+	dir string, m *types.Kustomization, f *fields,
+	built, desired resmap.ResMap) error {
+	for _, s := range f.describe() {
+		fmt.Fprintln(d.out, s)
+	}
+	pairs, onlyBuilt, onlyDesired := pairByName(built, desired)
+	rn := renames(pairs)
+	taken := make(map[string]bool)
+	for _, p := range pairs {
+		want := d.rf.RF().FromMap(normalized(p.desired, rn))
+		smp, ok, err := d.ptf.DerivePatch(p.built, want)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if len(smp) == 0 {
+				continue
+			}
+			name, err := d.writeFile(dir, taken, withHeader(smp, p.built),
+				"patch", p.built.GetKind(), p.built.OrgId().Name)
+			if err != nil {
+				return err
+			}
+			m.PatchesStrategicMerge = patch.Append(m.PatchesStrategicMerge, name)
+			continue
+		}
+		ops := diffOps("", p.built.Map(), want.Map())
+		if len(ops) == 0 {
+			continue
+		}
+		name, err := d.writeFile(dir, taken, ops,
+			"patch", p.built.GetKind(), p.built.OrgId().Name)
+		if err != nil {
+			return err
+		}
+		id := p.built.OrgId()
+		m.PatchesJson6902 = append(m.PatchesJson6902, types.PatchJson6902{
+			Target: &types.PatchTarget{
+				Gvk: id.Gvk, Namespace: id.Namespace, Name: id.Name},
+			Path: name,
+		})
+	}
+	for _, r := range onlyBuilt {
+		del := withHeader(map[string]interface{}{"$patch": "delete"}, r)
+		name, err := d.writeFile(dir, taken, del,
+			"delete", r.GetKind(), r.OrgId().Name)
+		if err != nil {
+			return err
+		}
+		m.PatchesStrategicMerge = patch.Append(m.PatchesStrategicMerge, name)
+	}
+	for _, r := range onlyDesired {
+		c := f.unapply(r)
+		name, err := d.writeFile(dir, taken, c.Map(), c.GetKind(), c.GetName())
+		if err != nil {
+			return err
+		}
+		m.Resources = append(m.Resources, name)
+	}
+	return nil
+}
+
+func (f *fields) describe() []string {
+	var result []string
+	if f.namespace != "" {
+		result = append(result, "set namespace to "+f.namespace)
+	}
+	if f.prefix != "" {
+		result = append(result, "set namePrefix to "+f.prefix)
+	}
+	if f.suffix != "" {
+		result = append(result, "set nameSuffix to "+f.suffix)
+	}
+	for _, img := range f.images {
+		result = append(result, "set image "+img.Name)
+	}
+	for _, r := range f.replicas {
+		result = append(result, fmt.Sprintf(
+			"set replicas of %s to %d", r.Name, r.Count))
+	}
+	return result
+}
+
+// unapply returns the desired resource r as it must be
+// listed in the overlay for the fields to turn it back
+// into r, asking the transformers to skip it if they can't.
+func (f *fields) unapply(r *resource.Resource) *resource.Resource {
+	c := r.DeepCopy()
+	var skips []string
+	name := c.GetName()
+	if f.prefix != "" {
+		if strings.HasPrefix(name, f.prefix) {
+			name = strings.TrimPrefix(name, f.prefix)
+		} else {
+			skips = append(skips, resource.SkipPrefix)
+		}
+	}
+	if f.suffix != "" {
+		if strings.HasSuffix(name, f.suffix) {
+			name = strings.TrimSuffix(name, f.suffix)
+		} else {
+			skips = append(skips, resource.SkipSuffix)
+		}
+	}
+	c.SetName(name)
+	if f.namespace != "" && c.GetGvk().IsNamespaceableKind() {
+		if c.GetNamespace() == f.namespace {
+			c.SetNamespace("")
+		} else {
+			skips = append(skips, resource.SkipNamespace)
+		}
+	}
+	if len(skips) > 0 {
+		a := c.GetAnnotations()
+		if a == nil {
+			a = make(map[string]string)
+		}
+		a[resource.SkipAnnotation] = strings.Join(skips, ",")
+		c.SetAnnotations(a)
+	}
+	return c
+}
+
+// withHeader adds to a strategic merge patch what
+// picks the resource r to patch.
+func withHeader(
+	p map[string]interface{}, r *resource.Resource) map[string]interface{} {
+	id := r.OrgId()
+	p["apiVersion"], _ = r.GetString("apiVersion")
+	p["kind"] = id.Kind
+	meta, _ := p["metadata"].(map[string]interface{})
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	meta["name"] = id.Name
+	if id.Namespace != "" {
+		meta["namespace"] = id.Namespace
+	}
+	p["metadata"] = meta
+	return p
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// writeFile writes content to a new file in dir named
+// after the given parts, returning the file's name.
+func (d *deriver) writeFile(
+	dir string, taken map[string]bool, content interface{},
+	parts ...string) (string, error) {
+	base := unsafeFileChars.ReplaceAllString(
+		strings.ToLower(strings.Join(parts, "_")), "-")
+	name := base + ".yaml"
+	for i := 2; taken[name] || d.fSys.Exists(filepath.Join(dir, name)); i++ {
+		name = fmt.Sprintf("%s-%d.yaml", base, i)
+	}
+	taken[name] = true
+	out, err := yaml.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return name, d.fSys.WriteFile(filepath.Join(dir, name), out)
+}
+
+// renames maps the names of hashed desired resources to
+// the names of the built resources they pair with, since
+// build, not a patch, decides the hash.
+func renames(pairs []pair) map[string]string {
+	result := make(map[string]string)
+	for _, p := range pairs {
+		if hashed(p.built) && p.desired.GetName() != p.built.GetName() {
+			result[p.desired.GetName()] = p.built.GetName()
+		}
+	}
+	return result
+}
+
+// normalized returns the map of a copy of r, in which
+// strings naming a hashed resource are renamed.
+func normalized(
+	r *resource.Resource, renames map[string]string) map[string]interface{} {
+	m := r.DeepCopy().Map()
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch x := v.(type) {
+		case string:
+			if n, ok := renames[x]; ok {
+				return n
+			}
+		case map[string]interface{}:
+			for k, item := range x {
+				x[k] = walk(item)
+			}
+		case []interface{}:
+			for i, item := range x {
+				x[i] = walk(item)
+			}
+		}
+		return v
+	}
+	walk(m)
+	return m
+}
+
+func (p pair) equal(renames map[string]string) bool {
+	return reflect.DeepEqual(p.built.Map(), normalized(p.desired, renames))
+}
+
+// diffOps returns the JSON patch operations turning a into
+// b.  Lists of different lengths are replaced whole.
+func diffOps(path string, a, b interface{}) []map[string]interface{} {
+	op := func(name string, p string, v interface{}) map[string]interface{} {
+		result := map[string]interface{}{"op": name, "path": p}
+		if name != "remove" {
+			result["value"] = v
+		}
+		return result
+	}
+	var result []map[string]interface{}
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for k := range x {
+			keys[k] = true
+		}
+		for k := range y {
+			keys[k] = true
+		}
+		var sorted []string
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			p := path + "/" + escapePointer(k)
+			xv, inX := x[k]
+			yv, inY := y[k]
+			switch {
+			case !inY:
+				result = append(result, op("remove", p, nil))
+			case !inX:
+				result = append(result, op("add", p, yv))
+			default:
+				result = append(result, diffOps(p, xv, yv)...)
+			}
+		}
+		return result
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			break
+		}
+		for i := range x {
+			result = append(result,
+				diffOps(path+"/"+strconv.Itoa(i), x[i], y[i])...)
+		}
+		return result
+	}
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+	return append(result, op("replace", path, b))
+}
+
+func escapePointer(s string) string {
+	return strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+}
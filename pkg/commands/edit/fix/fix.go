@@ -17,40 +17,263 @@ limitations under the License.
 package fix
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+
 	"github.com/spf13/cobra"
 	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
 	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/git"
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/loader"
+	"github.com/irairdon/kustomize/v3/pkg/plugins"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/target"
+	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+	"sigs.k8s.io/yaml"
 )
 
+type fixOptions struct {
+	recursive bool
+}
+
 // NewCmdFix returns an instance of 'fix' subcommand.
-func NewCmdFix(fSys fs.FileSystem) *cobra.Command {
+func NewCmdFix(
+	fSys fs.FileSystem, v ifc.Validator,
+	rf *resmap.Factory, ptf resmap.PatchFactory) *cobra.Command {
+	var o fixOptions
 	cmd := &cobra.Command{
 		Use:   "fix",
 		Short: "Fix the missing fields in kustomization file",
-		Long:  "",
+		Long: `Fix the missing fields in kustomization file, and move
+deprecated fields to the fields that replace them.
+
+If the kustomization builds before fixing, fix builds it again
+afterwards, and changes nothing if the output differs.`,
 		Example: `
 	# Fix the missing and deprecated fields in kustomization file
 	kustomize edit fix
 
+	# Also fix the kustomization files of all local bases
+	kustomize edit fix --recursive
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return RunFix(fSys)
+			f := &fixer{
+				fSys: fSys, v: v, rf: rf, ptf: ptf,
+				seen: make(map[string]bool)}
+			return o.RunFix(f, cmd.OutOrStdout())
 		},
 	}
+	cmd.Flags().BoolVar(&o.recursive, "recursive", false,
+		"also fix the kustomization files of local bases")
 	return cmd
 }
 
 // RunFix runs `fix` command
-func RunFix(fSys fs.FileSystem) error {
-	mf, err := kustfile.NewKustomizationFile(fSys)
+func (o *fixOptions) RunFix(f *fixer, out io.Writer) error {
+	top, err := f.read(".")
 	if err != nil {
 		return err
 	}
+	// Bases are only read when fixed, or when the
+	// migrations of the top kustomization look into them.
+	if o.recursive || len(top.k.Vars) > 0 || len(top.k.PatchesJson6902) > 0 {
+		f.walkBases(top)
+	}
+	var report bytes.Buffer
+	for _, u := range f.unreadable {
+		fmt.Fprintf(&report, "%s\n", u)
+	}
+	before, buildErr := f.build()
+	if buildErr != nil {
+		fmt.Fprintf(&report,
+			"%s: not checked against the build output: %v\n",
+			top.kf.Path(), buildErr)
+	}
+	nodes := f.nodes
+	if !o.recursive {
+		nodes = nodes[:1]
+	}
+	originals := make(map[string][]byte)
+	for _, n := range nodes {
+		fixed := false
+		for _, m := range migrations {
+			lines, err := m.apply(f, n)
+			if err != nil {
+				return err
+			}
+			for _, l := range lines {
+				fmt.Fprintf(&report, "%s: %s: %s\n", n.kf.Path(), m.name, l)
+				fixed = true
+			}
+		}
+		if !fixed {
+			fmt.Fprintf(&report, "%s: up to date\n", n.kf.Path())
+		}
+		originals[n.kf.Path()], err = f.fSys.ReadFile(n.kf.Path())
+		if err != nil {
+			return err
+		}
+		err = n.kf.Write(n.k)
+		if err != nil {
+			return err
+		}
+	}
+	if buildErr == nil {
+		after, err := f.build()
+		if err != nil {
+			err = fmt.Errorf("the fixed kustomization fails to build: %v", err)
+		} else if !bytes.Equal(after, before) {
+			err = fmt.Errorf("fixing would change the build output")
+		}
+		if err != nil {
+			for path, data := range originals {
+				if werr := f.fSys.WriteFile(path, data); werr != nil {
+					return werr
+				}
+			}
+			return fmt.Errorf(
+				"%v, so the kustomization files are left as they were", err)
+		}
+	}
+	_, err = out.Write(report.Bytes())
+	return err
+}
 
-	m, err := mf.Read()
+type kustFile interface {
+	Read() (*types.Kustomization, error)
+	Write(*types.Kustomization) error
+	Path() string
+}
+
+// node is a kustomization file in the tree fix walks.
+type node struct {
+	dir string
+	kf  kustFile
+	k   *types.Kustomization
+	// raw holds the top level fields as written,
+	// before reading fixed any of them.
+	raw map[string]interface{}
+}
+
+// rawLen returns the number of entries the
+// field had as written.
+func (n *node) rawLen(field string) int {
+	if l, ok := n.raw[field].([]interface{}); ok {
+		return len(l)
+	}
+	return 0
+}
+
+// fixer holds the kustomization in the current
+// directory, followed by those of its local bases.
+type fixer struct {
+	fSys  fs.FileSystem
+	v     ifc.Validator
+	rf    *resmap.Factory
+	ptf   resmap.PatchFactory
+	nodes []*node
+	seen  map[string]bool
+	// unreadable says which bases couldn't be read.
+	unreadable []string
+}
+
+// read reads the kustomization in dir.
+func (f *fixer) read(dir string) (*node, error) {
+	dir = filepath.Clean(dir)
+	f.seen[dir] = true
+	kf, err := kustfile.NewKustomizationFileInDir(f.fSys, dir)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	data, err := f.fSys.ReadFile(kf.Path())
+	if err != nil {
+		return nil, err
+	}
+	n := &node{dir: dir, kf: kf}
+	err = yaml.Unmarshal(data, &n.raw)
+	if err != nil {
+		return nil, err
+	}
+	n.k, err = kf.Read()
+	if err != nil {
+		return nil, err
+	}
+	f.nodes = append(f.nodes, n)
+	return n, nil
+}
 
-	return mf.Write(m)
+// walkBases reads the kustomizations in the directories
+// n lists as resources, depth first, noting those that
+// can't be read rather than failing.
+func (f *fixer) walkBases(n *node) {
+	for _, r := range n.k.Resources {
+		p := filepath.Clean(filepath.Join(n.dir, r))
+		if !f.fSys.IsDir(p) || f.seen[p] {
+			continue
+		}
+		b, err := f.read(p)
+		if err != nil {
+			f.unreadable = append(f.unreadable,
+				fmt.Sprintf("%s: skipped: %v", p, err))
+			continue
+		}
+		f.walkBases(b)
+	}
+}
+
+// build returns the output of building the current
+// directory, without remote bases.
+func (f *fixer) build() ([]byte, error) {
+	ldr, err := loader.NewLoaderWithCloner(
+		loader.RestrictionRootOnly, f.v, ".", f.fSys, noRemoteCloner)
+	if err != nil {
+		return nil, err
+	}
+	defer ldr.Cleanup()
+	kt, err := target.NewKustTarget(
+		ldr, f.rf, f.ptf,
+		plugins.NewLoader(plugins.DefaultPluginConfig(), f.rf))
+	if err != nil {
+		return nil, err
+	}
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		return nil, err
+	}
+	return m.AsYaml()
+}
+
+// noRemoteCloner refuses to clone; fix doesn't
+// go to the network to check a build.
+func noRemoteCloner(repoSpec *git.RepoSpec) error {
+	return fmt.Errorf("remote base %s isn't fetched", repoSpec.Raw())
+}
+
+// transformerConfig returns the default transformer
+// config merged with the configurations of every
+// kustomization in the tree.
+func (f *fixer) transformerConfig() (*config.TransformerConfig, error) {
+	tc := config.MakeDefaultConfig()
+	for _, n := range f.nodes {
+		for _, c := range n.k.Configurations {
+			p := filepath.Join(n.dir, c)
+			data, err := f.fSys.ReadFile(p)
+			if err != nil {
+				return nil, err
+			}
+			var t config.TransformerConfig
+			if err := yaml.Unmarshal(data, &t); err != nil {
+				return nil, fmt.Errorf("unable to read %s: %v", p, err)
+			}
+			tc, err = tc.Merge(&t)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tc, nil
 }
@@ -17,17 +17,31 @@ limitations under the License.
 package fix
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 
+	"github.com/irairdon/kustomize/v3/k8sdeps/kunstruct"
+	"github.com/irairdon/kustomize/v3/k8sdeps/transformer"
+	"github.com/irairdon/kustomize/v3/k8sdeps/validator"
 	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/spf13/cobra"
 )
 
+func newCmdFix(fSys fs.FileSystem) *cobra.Command {
+	ptf := transformer.NewFactoryImpl()
+	rf := resmap.NewFactory(
+		resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl()), ptf)
+	return NewCmdFix(fSys, validator.NewKustValidator(), rf, ptf)
+}
+
 func TestFix(t *testing.T) {
 	fakeFS := fs.MakeFakeFS()
 	fakeFS.WriteTestKustomizationWith([]byte(`nameprefix: some-prefix-`))
 
-	cmd := NewCmdFix(fakeFS)
+	cmd := newCmdFix(fakeFS)
 	err := cmd.RunE(cmd, nil)
 	if err != nil {
 		t.Errorf("unexpected cmd error: %v", err)
@@ -43,3 +57,536 @@ func TestFix(t *testing.T) {
 		t.Errorf("expected kind in kustomization")
 	}
 }
+
+func TestFixMigrations(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`resources:
+- resources.yaml
+commonLabels:
+  app: web
+imageTags:
+- name: nginx
+  newTag: "1.17"
+patchesJson6902:
+- target:
+    group: apps
+    version: v1
+    kind: Deployment
+    name: web
+  path: replicas.yaml
+- target:
+    version: v1
+    kind: Service
+    name: web
+  patch: |-
+    - op: replace
+      path: /spec/selector
+      value: {app: web-v2}
+`))
+	fakeFS.WriteFile("replicas.yaml", []byte(`
+- op: replace
+  path: /spec/replicas
+  value: 3
+`))
+	fakeFS.WriteFile("resources.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+`))
+
+	var out bytes.Buffer
+	cmd := newCmdFix(fakeFS)
+	cmd.SetOutput(&out)
+	err := cmd.RunE(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `resources:
+- resources.yaml
+images:
+- name: nginx
+  newTag: "1.17"
+patchesJson6902:
+- target:
+    version: v1
+    kind: Service
+    name: web
+  patch: |-
+    - op: replace
+      path: /spec/selector
+      value: {app: web-v2}
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+labels:
+- includeSelectors: true
+  pairs:
+    app: web
+patches:
+- path: replicas.yaml
+  target:
+    group: apps
+    kind: Deployment
+    name: web
+    version: v1
+`
+	if string(content) != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, content)
+	}
+	summary := `kustomization.yaml: imageTags to images: moved 1 image
+kustomization.yaml: patchesJson6902 to patches: moved 1 patch
+kustomization.yaml: patchesJson6902 to patches: kept the patch of Service web: it changes /spec/selector, which commonLabels also sets
+kustomization.yaml: commonLabels to labels: moved 1 label
+`
+	if out.String() != summary {
+		t.Errorf("expected summary:\n%s\nbut got:\n%s", summary, out.String())
+	}
+}
+
+func TestFixRecursive(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`
+resources:
+- base
+- deployment.yaml
+`))
+	fakeFS.WriteFile("deployment.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+`))
+	fakeFS.WriteFile("base/kustomization.yaml", []byte(`
+bases:
+- ../common
+`))
+	fakeFS.WriteFile("common/kustomization.yaml", []byte(`
+commonLabels:
+  app: web
+`))
+
+	run := func(args ...string) string {
+		var out bytes.Buffer
+		cmd := newCmdFix(fakeFS)
+		cmd.SetOutput(&out)
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("unexpected cmd error: %v", err)
+		}
+		return out.String()
+	}
+
+	summary := run()
+	if summary != "kustomization.yaml: up to date\n" {
+		t.Errorf("unexpected summary without --recursive:\n%s", summary)
+	}
+	content, _ := fakeFS.ReadFile("common/kustomization.yaml")
+	if !strings.Contains(string(content), "commonLabels") {
+		t.Errorf("expected common to be left alone, got:\n%s", content)
+	}
+
+	summary = run("--recursive")
+	expected := `kustomization.yaml: up to date
+base/kustomization.yaml: bases to resources: moved 1 base
+common/kustomization.yaml: commonLabels to labels: moved 1 label
+`
+	if summary != expected {
+		t.Errorf("expected summary:\n%s\nbut got:\n%s", expected, summary)
+	}
+	content, _ = fakeFS.ReadFile("base/kustomization.yaml")
+	if !strings.Contains(string(content), "resources:\n- ../common\n") {
+		t.Errorf("expected base to list common as a resource, got:\n%s", content)
+	}
+}
+
+func TestFixVars(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`resources:
+- base
+vars:
+- name: SERVICE
+  objref:
+    apiVersion: v1
+    kind: Service
+    name: web
+- name: PORT
+  objref:
+    apiVersion: v1
+    kind: Service
+    name: web
+  fieldref:
+    fieldPath: spec.ports[0].port
+`))
+	fakeFS.WriteFile("base/kustomization.yaml", []byte(`
+resources:
+- resources.yaml
+`))
+	fakeFS.WriteFile("base/resources.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: client
+spec:
+  template:
+    spec:
+      containers:
+      - name: client
+        args:
+        - $(SERVICE)
+        - --port=$(PORT)
+`))
+
+	var out bytes.Buffer
+	cmd := newCmdFix(fakeFS)
+	cmd.SetOutput(&out)
+	err := cmd.RunE(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `resources:
+- base
+vars:
+- name: PORT
+  objref:
+    apiVersion: v1
+    kind: Service
+    name: web
+  fieldref:
+    fieldPath: spec.ports[0].port
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+replacements:
+- source:
+    kind: Service
+    name: web
+    version: v1
+  targets:
+  - fieldPaths:
+    - spec.template.spec.containers[name=client].args[0]
+    select:
+      group: apps
+      kind: Deployment
+      name: client
+      version: v1
+`
+	if string(content) != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, content)
+	}
+	summary := `kustomization.yaml: vars to replacements: moved 1 var
+kustomization.yaml: vars to replacements: kept PORT: ` +
+		"it's used inside a string, or with a default or function, in base/resources.yaml\n"
+	if out.String() != summary {
+		t.Errorf("expected summary:\n%s\nbut got:\n%s", summary, out.String())
+	}
+}
+
+func TestFixVarsVarReference(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`resources:
+- resources.yaml
+configurations:
+- varreference.yaml
+vars:
+- name: SERVICE
+  objref:
+    apiVersion: v1
+    kind: Service
+    name: web
+`))
+	fakeFS.WriteFile("varreference.yaml", []byte(`
+varReference:
+- path: spec/endpoint
+  kind: Probe
+`))
+	fakeFS.WriteFile("resources.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: notes
+data:
+  service: $(SERVICE)
+---
+apiVersion: example.com/v1
+kind: Probe
+metadata:
+  name: check
+spec:
+  endpoint: $(SERVICE)
+`))
+
+	var out bytes.Buffer
+	cmd := newCmdFix(fakeFS)
+	cmd.SetOutput(&out)
+	err := cmd.RunE(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `resources:
+- resources.yaml
+configurations:
+- varreference.yaml
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+replacements:
+- source:
+    kind: Service
+    name: web
+    version: v1
+  targets:
+  - fieldPaths:
+    - spec.endpoint
+    select:
+      group: example.com
+      kind: Probe
+      name: check
+      version: v1
+`
+	if string(content) != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, content)
+	}
+	summary := `kustomization.yaml: vars to replacements: moved 1 var
+kustomization.yaml: vars to replacements: kept $(SERVICE) in resources.yaml: ` +
+		"vars aren't expanded there\n"
+	if out.String() != summary {
+		t.Errorf("expected summary:\n%s\nbut got:\n%s", summary, out.String())
+	}
+}
+
+func TestFixKeepsJsonPatchesOfLabels(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`resources:
+- deployment.yaml
+commonLabels:
+  app: web
+patchesJson6902:
+- target:
+    group: apps
+    version: v1
+    kind: Deployment
+    name: web
+  patch: |-
+    - op: replace
+      path: /spec/template/metadata/labels
+      value: {tier: frontend}
+`))
+	fakeFS.WriteFile("deployment.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        tier: backend
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`))
+
+	var out bytes.Buffer
+	cmd := newCmdFix(fakeFS)
+	cmd.SetOutput(&out)
+	err := cmd.RunE(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	if !strings.Contains(string(content), "patchesJson6902:") ||
+		strings.Contains(string(content), "patches:") {
+		t.Errorf("expected the json patch to be kept, got:\n%s", content)
+	}
+	summary := `kustomization.yaml: patchesJson6902 to patches: kept the patch of ` +
+		`Deployment web: it changes /spec/template/metadata/labels, which commonLabels also sets
+kustomization.yaml: commonLabels to labels: moved 1 label
+`
+	if out.String() != summary {
+		t.Errorf("expected summary:\n%s\nbut got:\n%s", summary, out.String())
+	}
+}
+
+func TestFixVarsKeepsHashedSources(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`resources:
+- deployment.yaml
+configMapGenerator:
+- name: cm
+  literals:
+  - level=info
+vars:
+- name: CM_NAME
+  objref:
+    apiVersion: v1
+    kind: ConfigMap
+    name: cm
+  fieldref:
+    fieldPath: metadata.name
+`))
+	fakeFS.WriteFile("deployment.yaml", []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        env:
+        - name: SETTINGS
+          value: $(CM_NAME)
+`))
+	original, _ := fakeFS.ReadTestKustomization()
+
+	var out bytes.Buffer
+	cmd := newCmdFix(fakeFS)
+	cmd.SetOutput(&out)
+	err := cmd.RunE(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := string(original) + `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+`
+	if string(content) != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, content)
+	}
+	summary := `kustomization.yaml: vars to replacements: ` +
+		"kept CM_NAME: its source ConfigMap cm is generated, and gets a hash suffix\n"
+	if out.String() != summary {
+		t.Errorf("expected summary:\n%s\nbut got:\n%s", summary, out.String())
+	}
+}
+
+func TestFixRefusesToChangeTheBuild(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`resources:
+- service.yaml
+`))
+	fakeFS.WriteFile("service.yaml", []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`))
+	original, _ := fakeFS.ReadTestKustomization()
+
+	saved := migrations
+	defer func() { migrations = saved }()
+	migrations = append(migrations, migration{
+		version: len(saved) + 1,
+		name:    "a broken migration",
+		apply: func(_ *fixer, n *node) ([]string, error) {
+			n.k.NamePrefix = "broken-"
+			return []string{"set a prefix"}, nil
+		},
+	})
+
+	var out bytes.Buffer
+	cmd := newCmdFix(fakeFS)
+	cmd.SetOutput(&out)
+	err := cmd.RunE(cmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "fixing would change the build output") {
+		t.Fatalf("expected a changed build error, got %v", err)
+	}
+	content, _ := fakeFS.ReadTestKustomization()
+	if string(content) != string(original) {
+		t.Errorf("expected the kustomization to be left alone, got:\n%s", content)
+	}
+	if out.String() != "" {
+		t.Errorf("expected no summary, got:\n%s", out.String())
+	}
+}
+
+func TestFixOnlyReadsBasesWhenNeeded(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`resources:
+- base
+namePrefix: some-
+`))
+	fakeFS.WriteFile("base/README.md", []byte(`no kustomization here`))
+
+	run := func() (string, error) {
+		var out bytes.Buffer
+		cmd := newCmdFix(fakeFS)
+		cmd.SetOutput(&out)
+		err := cmd.RunE(cmd, nil)
+		return out.String(), err
+	}
+
+	summary, err := run()
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	if !strings.HasSuffix(summary, "kustomization.yaml: up to date\n") ||
+		strings.Contains(summary, "skipped") {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+
+	fakeFS.WriteTestKustomizationWith([]byte(`resources:
+- base
+vars:
+- name: SERVICE
+  objref:
+    apiVersion: v1
+    kind: Service
+    name: web
+`))
+	summary, err = run()
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	if !strings.HasPrefix(summary, "base: skipped: ") ||
+		!strings.Contains(summary,
+			"kustomization.yaml: vars to replacements: kept SERVICE: not every base could be read\n") {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package fix
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/gvk"
+	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// migration moves a kustomization off a deprecated field.
+type migration struct {
+	// version numbers the migration; migrations
+	// run in order, so a later one sees the
+	// fields an earlier one wrote.
+	version int
+
+	// name says which field moves where.
+	name string
+
+	// apply migrates the kustomization in dir, returning
+	// a line for each thing it moved or had to keep.
+	apply func(f *fixer, n *node) ([]string, error)
+}
+
+// migrations is the registry fix applies, in version order.
+// Append new migrations with the next version number.
+var migrations = []migration{
+	{version: 1, name: "bases to resources", apply: migrateBases},
+	{version: 2, name: "imageTags to images", apply: migrateImageTags},
+	{version: 3, name: "patchesJson6902 to patches", apply: migratePatchesJson6902},
+	{version: 4, name: "commonLabels to labels", apply: migrateCommonLabels},
+	{version: 5, name: "vars to replacements", apply: migrateVars},
+}
+
+// migrateBases reports the bases that reading the
+// kustomization already folded into its resources.
+func migrateBases(_ *fixer, n *node) ([]string, error) {
+	if c := n.rawLen("bases"); c > 0 {
+		return []string{fmt.Sprintf("moved %s", plural(c, "base"))}, nil
+	}
+	return nil, nil
+}
+
+// migrateImageTags reports the imageTags that reading
+// the kustomization already renamed to images.
+func migrateImageTags(_ *fixer, n *node) ([]string, error) {
+	if c := n.rawLen("imageTags"); c > 0 {
+		return []string{fmt.Sprintf("moved %s", plural(c, "image"))}, nil
+	}
+	return nil, nil
+}
+
+// migratePatchesJson6902 turns JSON patches into patches
+// with a target selector. Patches run before the namespace,
+// name, label and annotation transformers, while JSON
+// patches run after them, so a JSON patch touching a field
+// those transformers set in this kustomization is kept.
+func migratePatchesJson6902(f *fixer, n *node) ([]string, error) {
+	if len(n.k.PatchesJson6902) == 0 {
+		return nil, nil
+	}
+	tc, err := f.transformerConfig()
+	if err != nil {
+		return nil, err
+	}
+	fields := transformedFields(n.k, tc)
+	var result []string
+	var kept []types.PatchJson6902
+	moved := 0
+	for _, p := range n.k.PatchesJson6902 {
+		if p.Target == nil {
+			kept = append(kept, p)
+			continue
+		}
+		ops, err := f.jsonPatchOps(n.dir, p)
+		if err != nil {
+			return nil, err
+		}
+		if path, field := overlap(ops, p.Target.Gvk, fields); field != "" {
+			kept = append(kept, p)
+			result = append(result, fmt.Sprintf(
+				"kept the patch of %s %s: it changes %s, which %s also sets",
+				p.Target.Kind, p.Target.Name, path, field))
+			continue
+		}
+		n.k.Patches = append(n.k.Patches, types.Patch{
+			Path:  p.Path,
+			Patch: p.Patch,
+			Target: &types.Selector{
+				Gvk:       p.Target.Gvk,
+				Namespace: p.Target.Namespace,
+				Name:      p.Target.Name,
+			},
+		})
+		moved++
+	}
+	n.k.PatchesJson6902 = kept
+	if moved > 0 {
+		result = append([]string{
+			fmt.Sprintf("moved %s", plural(moved, "patch"))}, result...)
+	}
+	return result, nil
+}
+
+// transformedFields returns, by kustomization field, the
+// field specs of the transformers that run between patches
+// and JSON patches, for the fields k sets.
+func transformedFields(
+	k *types.Kustomization,
+	tc *config.TransformerConfig) map[string][]config.FieldSpec {
+	result := make(map[string][]config.FieldSpec)
+	if k.Namespace != "" {
+		result["namespace"] = tc.NameSpace
+	}
+	if k.NamePrefix != "" {
+		result["namePrefix"] = tc.NamePrefix
+	}
+	if k.NameSuffix != "" {
+		result["nameSuffix"] = tc.NameSuffix
+	}
+	if len(k.CommonLabels) > 0 {
+		result["commonLabels"] = tc.CommonLabels
+	}
+	if len(k.Labels) > 0 {
+		result["labels"] = tc.CommonLabels
+	}
+	if len(k.CommonAnnotations) > 0 {
+		result["commonAnnotations"] = tc.CommonAnnotations
+	}
+	return result
+}
+
+// overlap returns the path of the first op changing a field
+// of the given fields, or one holding it, in an object of
+// kind g, and the kustomization field setting it.
+func overlap(
+	ops []map[string]interface{}, g gvk.Gvk,
+	fields map[string][]config.FieldSpec) (string, string) {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, op := range ops {
+		for _, k := range []string{"path", "from"} {
+			s, ok := op[k].(string)
+			if !ok {
+				continue
+			}
+			for _, name := range names {
+				for _, fs := range fields[name] {
+					if g.IsSelected(&fs.Gvk) &&
+						pathsOverlap(pointerSegments(s), fs.PathSlice()) {
+						return s, name
+					}
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+// pointerSegments splits a JSON pointer into its
+// unescaped segments.
+func pointerSegments(pointer string) []string {
+	var result []string
+	for _, seg := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		seg = strings.Replace(seg, "~1", "/", -1)
+		result = append(result, strings.Replace(seg, "~0", "~", -1))
+	}
+	return result
+}
+
+// pathsOverlap returns true if the field at the JSON pointer
+// segments and that at the field spec path are the same, or
+// one holds the other.  A field spec segment ending in []
+// names a list, which the pointer indexes.
+func pathsOverlap(pointer, path []string) bool {
+	i := 0
+	for _, seg := range path {
+		if i == len(pointer) {
+			return true
+		}
+		if pointer[i] != strings.TrimSuffix(seg, "[]") {
+			return false
+		}
+		i++
+		if strings.HasSuffix(seg, "[]") && i < len(pointer) {
+			i++
+		}
+	}
+	return true
+}
+
+func (f *fixer) jsonPatchOps(
+	dir string, p types.PatchJson6902) ([]map[string]interface{}, error) {
+	in := []byte(p.Patch)
+	if p.Path != "" {
+		var err error
+		in, err = f.fSys.ReadFile(filepath.Join(dir, p.Path))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var ops []map[string]interface{}
+	if err := yaml.Unmarshal(in, &ops); err != nil {
+		return nil, fmt.Errorf(
+			"unable to read json patch of %s in %s: %v", p.Target.Name, dir, err)
+	}
+	return ops, nil
+}
+
+// migrateCommonLabels turns commonLabels into a labels entry
+// that includes selectors, ahead of any other entry, since
+// commonLabels were applied before labels.
+func migrateCommonLabels(_ *fixer, n *node) ([]string, error) {
+	if len(n.k.CommonLabels) == 0 {
+		return nil, nil
+	}
+	c := len(n.k.CommonLabels)
+	n.k.Labels = append([]types.Label{{
+		Pairs:            n.k.CommonLabels,
+		IncludeSelectors: true,
+	}}, n.k.Labels...)
+	n.k.CommonLabels = nil
+	return []string{fmt.Sprintf("moved %s", plural(c, "label"))}, nil
+}
+
+func plural(c int, noun string) string {
+	if c == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "ch") || strings.HasSuffix(noun, "s") {
+		return fmt.Sprintf("%d %ses", c, noun)
+	}
+	return fmt.Sprintf("%d %ss", c, noun)
+}
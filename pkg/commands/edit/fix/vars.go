@@ -0,0 +1,416 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package fix
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/gvk"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// varUse is a field of a resource file holding $(NAME).
+type varUse struct {
+	file string
+	obj  map[string]interface{}
+	path string
+}
+
+// varUses are the fields of the resource files in a
+// tree that mention a var.
+type varUses struct {
+	// whole are the fields vars expand in
+	// whose value is $(NAME).
+	whole []varUse
+	// partial is the file of the first field vars expand
+	// in using the var any other way, e.g. inside a longer
+	// string, if any.
+	partial string
+	// literal is the file of the first field vars don't
+	// expand in, e.g. ConfigMap data, whose value is
+	// $(NAME), if any.
+	literal string
+}
+
+// migrateVars turns the vars of the kustomization fix runs
+// in into replacements. Vars resolve against the whole
+// build, and replacements run last in the kustomization
+// declaring them, so vars only move when declared at the
+// top. A var only moves if every use of it in the resource
+// files of the tree is a whole field; replacements can't
+// rewrite part of a string the way vars do. Only the fields
+// of the varReference config, the default merged with the
+// configurations of the tree, are uses; vars don't expand
+// elsewhere, so $(NAME) there is left as it is. Vars resolve
+// after hash suffixes are added, and replacements before,
+// so a var only moves if its source is in a resource file
+// and doesn't get a hash suffix.
+func migrateVars(f *fixer, n *node) ([]string, error) {
+	if len(n.k.Vars) == 0 {
+		return nil, nil
+	}
+	if n != f.nodes[0] {
+		return []string{fmt.Sprintf(
+			"kept %s: vars in a base resolve in whatever builds it",
+			plural(len(n.k.Vars), "var"))}, nil
+	}
+	objs, err := f.resourceObjects()
+	if err != nil {
+		return nil, err
+	}
+	patches, err := f.patchTexts()
+	if err != nil {
+		return nil, err
+	}
+	tc, err := f.transformerConfig()
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	var kept []types.Var
+	moved := 0
+	for _, v := range n.k.Vars {
+		uses := findVarUses(objs, tc.VarReference, v.Name)
+		if uses.literal != "" {
+			result = append(result, fmt.Sprintf(
+				"kept $(%s) in %s: vars aren't expanded there",
+				v.Name, uses.literal))
+		}
+		var why string
+		switch {
+		case len(f.unreadable) > 0:
+			why = "not every base could be read"
+		case f.isGenerated(v.ObjRef):
+			why = fmt.Sprintf("its source %s %s is generated, "+
+				"and gets a hash suffix", v.ObjRef.Kind, v.ObjRef.Name)
+		case !hasObject(objs, v.ObjRef, false):
+			why = fmt.Sprintf("its source %s %s isn't in a resource file",
+				v.ObjRef.Kind, v.ObjRef.Name)
+		case hasObject(objs, v.ObjRef, true):
+			why = fmt.Sprintf("its source %s %s gets a hash suffix",
+				v.ObjRef.Kind, v.ObjRef.Name)
+		case uses.partial != "":
+			why = "it's used inside a string, or with a default or function, in " + uses.partial
+		case strings.Contains(patches, "$("+v.Name):
+			why = "it's used in a patch"
+		case len(uses.whole) == 0:
+			why = "no resource file uses it"
+		}
+		if why != "" {
+			kept = append(kept, v)
+			result = append(result, fmt.Sprintf("kept %s: %s", v.Name, why))
+			continue
+		}
+		n.k.Replacements = append(n.k.Replacements, varReplacement(v, uses.whole))
+		moved++
+	}
+	n.k.Vars = kept
+	if moved > 0 {
+		result = append([]string{
+			fmt.Sprintf("moved %s", plural(moved, "var"))}, result...)
+	}
+	return result, nil
+}
+
+// isGenerated returns true if a ConfigMap or Secret
+// generator in the tree, adding a hash suffix, makes
+// the object ref names.
+func (f *fixer) isGenerated(ref types.Target) bool {
+	for _, n := range f.nodes {
+		if n.k.GeneratorOptions != nil &&
+			n.k.GeneratorOptions.DisableNameSuffixHash {
+			continue
+		}
+		var names []string
+		switch ref.Kind {
+		case "ConfigMap":
+			for _, g := range n.k.ConfigMapGenerator {
+				names = append(names, g.Name)
+			}
+		case "Secret":
+			for _, g := range n.k.SecretGenerator {
+				names = append(names, g.Name)
+			}
+		}
+		for _, name := range names {
+			if name == ref.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasObject returns true if objs hold the object ref
+// names, and, if needsHash, it asks for a hash suffix.
+func hasObject(
+	objs map[string][]map[string]interface{},
+	ref types.Target, needsHash bool) bool {
+	for _, list := range objs {
+		for _, obj := range list {
+			s := objSelector(obj)
+			if s.Kind != ref.Kind || s.Name != ref.Name ||
+				ref.Namespace != "" && s.Namespace != ref.Namespace {
+				continue
+			}
+			if !needsHash {
+				return true
+			}
+			meta, _ := obj["metadata"].(map[string]interface{})
+			annotations, _ := meta["annotations"].(map[string]interface{})
+			if annotations[resource.NeedsHashAnnotation] == "true" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func varReplacement(v types.Var, uses []varUse) types.Replacement {
+	source := &types.ReplSource{
+		Selector: types.Selector{
+			Gvk:       v.ObjRef.Gvk,
+			Namespace: v.ObjRef.Namespace,
+			Name:      v.ObjRef.Name,
+		},
+		FieldPath: v.FieldRef.FieldPath,
+	}
+	if source.Group == "" && source.Version == "" {
+		source.Group, source.Version = groupVersion(v.ObjRef.APIVersion)
+	}
+	var targets []*types.ReplTarget
+	index := make(map[string]*types.ReplTarget)
+	for _, u := range uses {
+		s := objSelector(u.obj)
+		key := fmt.Sprintf("%v", *s)
+		t, ok := index[key]
+		if !ok {
+			t = &types.ReplTarget{Select: s}
+			index[key] = t
+			targets = append(targets, t)
+		}
+		t.FieldPaths = append(t.FieldPaths, u.path)
+	}
+	return types.Replacement{Source: source, Targets: targets}
+}
+
+func objSelector(obj map[string]interface{}) *types.Selector {
+	s := &types.Selector{}
+	apiVersion, _ := obj["apiVersion"].(string)
+	s.Group, s.Version = groupVersion(apiVersion)
+	s.Kind, _ = obj["kind"].(string)
+	if meta, ok := obj["metadata"].(map[string]interface{}); ok {
+		s.Name, _ = meta["name"].(string)
+		s.Namespace, _ = meta["namespace"].(string)
+	}
+	return s
+}
+
+func groupVersion(apiVersion string) (string, string) {
+	if i := strings.Index(apiVersion, "/"); i >= 0 {
+		return apiVersion[:i], apiVersion[i+1:]
+	}
+	return "", apiVersion
+}
+
+// findVarUses returns the fields of objs mentioning
+// the var name, given the varReference specs.
+func findVarUses(
+	objs map[string][]map[string]interface{},
+	specs []config.FieldSpec, name string) varUses {
+	ref := "$(" + name + ")"
+	mention := regexp.MustCompile(`\$\(` + regexp.QuoteMeta(name) + `[)|:]`)
+	var uses varUses
+	var files []string
+	for file := range objs {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		for _, obj := range objs[file] {
+			walkStrings(obj, "", nil, func(path string, keys []string, s string) {
+				expands := isVarReference(specs, obj, keys)
+				switch {
+				case s == ref && expands:
+					uses.whole = append(uses.whole, varUse{file: file, obj: obj, path: path})
+				case s == ref:
+					if uses.literal == "" {
+						uses.literal = file
+					}
+				case uses.partial == "" && expands && mention.MatchString(s):
+					uses.partial = file
+				}
+			})
+		}
+	}
+	return uses
+}
+
+// isVarReference returns true if vars expand in the field
+// of obj at keys, i.e. a varReference spec selects obj and
+// names the field, or the list or map of strings holding it.
+func isVarReference(
+	specs []config.FieldSpec, obj map[string]interface{}, keys []string) bool {
+	s := objSelector(obj)
+	id := gvk.Gvk{Group: s.Group, Version: s.Version, Kind: s.Kind}
+	for _, fs := range specs {
+		if !id.IsSelected(&fs.Gvk) {
+			continue
+		}
+		p := fs.PathSlice()
+		if len(keys) != len(p) && len(keys) != len(p)+1 {
+			continue
+		}
+		matched := true
+		for i := range p {
+			if strings.TrimSuffix(p[i], "[]") != keys[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// walkStrings calls fn with the replacement field path, the
+// map keys leading to it and the value of each string below v.
+func walkStrings(
+	v interface{}, path string, keys []string,
+	fn func(path string, keys []string, s string)) {
+	switch x := v.(type) {
+	case string:
+		fn(path, keys, x)
+	case map[string]interface{}:
+		var sorted []string
+		for k := range x {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			walkStrings(x[k], joinPath(path, pathKey(k)),
+				append(keys[:len(keys):len(keys)], k), fn)
+		}
+	case []interface{}:
+		for i, item := range x {
+			seg := "[" + strconv.Itoa(i) + "]"
+			if m, ok := item.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok && isPlainKey(name) {
+					seg = "[name=" + name + "]"
+				}
+			}
+			walkStrings(item, path+seg, keys, fn)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" || strings.HasPrefix(key, "[") {
+		return path + key
+	}
+	return path + "." + key
+}
+
+func pathKey(k string) string {
+	if isPlainKey(k) {
+		return k
+	}
+	return "[" + k + "]"
+}
+
+func isPlainKey(k string) bool {
+	return !strings.ContainsAny(k, ".[]=")
+}
+
+// resourceObjects returns the objects in the resource
+// files of every kustomization in the tree, by file.
+func (f *fixer) resourceObjects() (map[string][]map[string]interface{}, error) {
+	result := make(map[string][]map[string]interface{})
+	for _, n := range f.nodes {
+		for _, r := range n.k.Resources {
+			p := filepath.Join(n.dir, r)
+			if f.fSys.IsDir(p) || !f.fSys.Exists(p) {
+				continue
+			}
+			data, err := f.fSys.ReadFile(p)
+			if err != nil {
+				return nil, err
+			}
+			objs, err := decodeObjects(data)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read %s: %v", p, err)
+			}
+			result[p] = objs
+		}
+	}
+	return result, nil
+}
+
+func decodeObjects(data []byte) ([]map[string]interface{}, error) {
+	decoder := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 1024)
+	var result []map[string]interface{}
+	for {
+		var obj map[string]interface{}
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(obj) > 0 {
+			result = append(result, obj)
+		}
+	}
+}
+
+// patchTexts returns the text of every patch in the tree.
+func (f *fixer) patchTexts() (string, error) {
+	var b strings.Builder
+	add := func(dir, pathOrPatch string) error {
+		p := filepath.Join(dir, pathOrPatch)
+		if !strings.Contains(pathOrPatch, "\n") && f.fSys.Exists(p) {
+			data, err := f.fSys.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			pathOrPatch = string(data)
+		}
+		b.WriteString(pathOrPatch)
+		return nil
+	}
+	for _, n := range f.nodes {
+		var all []string
+		for _, p := range n.k.PatchesStrategicMerge {
+			all = append(all, string(p))
+		}
+		for _, p := range n.k.PatchesJson6902 {
+			all = append(all, p.Path, p.Patch)
+		}
+		for _, p := range n.k.Patches {
+			all = append(all, p.Path, p.Patch)
+		}
+		for _, p := range all {
+			if p == "" {
+				continue
+			}
+			if err := add(n.dir, p); err != nil {
+				return "", err
+			}
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package lock
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/git"
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/loader"
+	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+type lockOptions struct {
+	dir     string
+	cloner  git.Cloner
+	resolve git.RefResolver
+}
+
+// NewCmdLock returns an instance of 'lock' subcommand.
+func NewCmdLock(fSys fs.FileSystem, v ifc.Validator) *cobra.Command {
+	o := lockOptions{
+		dir:     loader.CWD,
+		cloner:  git.DefaultCloner,
+		resolve: git.DefaultRefResolver,
+	}
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Pins remote bases to commits in " + git.LockFileName,
+		Long: `Resolves the ref of every remote base, and of every remote
+base of those bases, to a commit, and records the commits in
+` + git.LockFileName + ` next to the kustomization file.
+
+Builds of a kustomization with a lock use the pinned commits,
+and fail if a ref has since moved on.  Run this command again
+to take up the new commits.`,
+		Example: `
+	# Pin remote bases to the commits their refs name now
+	kustomize edit lock
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("lock takes no arguments")
+			}
+			return o.RunLock(cmd.OutOrStdout(), fSys, v)
+		},
+	}
+	return cmd
+}
+
+// RunLock runs lock command (does real work).
+func (o *lockOptions) RunLock(
+	out io.Writer, fSys fs.FileSystem, v ifc.Validator) error {
+	ldr, err := loader.NewLoaderWithLock(
+		loader.RestrictionRootOnly, v, o.dir, fSys, o.cloner,
+		loader.LockPolicy{Resolve: o.resolve, Update: true})
+	if err != nil {
+		return err
+	}
+	defer ldr.Cleanup()
+	if err = visitBases(ldr); err != nil {
+		return err
+	}
+	if err = loader.SaveLock(ldr); err != nil {
+		return err
+	}
+	l, err := git.ReadLock(fSys, ldr.Root())
+	if err != nil {
+		return err
+	}
+	if l == nil || len(l.Remotes) == 0 {
+		fmt.Fprintln(out, "no remote bases to lock")
+		return nil
+	}
+	for _, r := range l.Remotes {
+		fmt.Fprintf(out, "%s %s\n", r.Commit, r.Url)
+	}
+	return nil
+}
+
+// visitBases loads every base reachable from
+// the kustomization at the loader's root.
+func visitBases(ldr ifc.Loader) error {
+	k, err := readKustomization(ldr)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	paths = append(paths, k.Resources...)
+	paths = append(paths, k.Generators...)
+	paths = append(paths, k.Transformers...)
+	for _, path := range paths {
+		_, errNotRemote := git.NewRepoSpecFromUrl(path)
+		subLdr, err := ldr.New(path)
+		if err != nil {
+			if errNotRemote == nil {
+				return err
+			}
+			// Not a directory, so a file of resources.
+			continue
+		}
+		err = visitBases(subLdr)
+		subLdr.Cleanup()
+		if err != nil {
+			return errors.Wrapf(err, "visiting '%s'", path)
+		}
+	}
+	return nil
+}
+
+func readKustomization(ldr ifc.Loader) (*types.Kustomization, error) {
+	for _, n := range pgmconfig.KustomizationFileNames {
+		content, err := ldr.Load(n)
+		if err != nil {
+			continue
+		}
+		var k types.Kustomization
+		err = yaml.Unmarshal(types.FixKustomizationPreUnmarshalling(content), &k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading %s in '%s'", n, ldr.Root())
+		}
+		k.FixKustomizationPostUnmarshalling()
+		return &k, nil
+	}
+	return nil, fmt.Errorf("no kustomization file in '%s'", ldr.Root())
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package lock
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/git"
+	"github.com/irairdon/kustomize/v3/pkg/validators"
+)
+
+const (
+	baseCommit   = "a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1"
+	nestedCommit = "b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2"
+	otherCommit  = "c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3"
+)
+
+// fakeRemotes clones repos, each holding one
+// kustomization, into fresh directories.
+type fakeRemotes struct {
+	t       *testing.T
+	fSys    fs.FileSystem
+	clones  int
+	content map[string]string
+	commits map[string]string
+}
+
+func (f *fakeRemotes) clone(rs *git.RepoSpec) error {
+	if rs.Ref != f.commits[rs.OrgRepo] {
+		f.t.Fatalf("%s cloned at %s rather than its pinned commit", rs.OrgRepo, rs.Ref)
+	}
+	f.clones++
+	dir := fmt.Sprintf("/clones/%d", f.clones)
+	f.fSys.WriteFile(filepath.Join(dir, rs.Path, "kustomization.yaml"),
+		[]byte(f.content[rs.OrgRepo]))
+	rs.Dir = fs.ConfirmedDir(dir)
+	return nil
+}
+
+func (f *fakeRemotes) resolve(rs *git.RepoSpec) (string, error) {
+	return f.commits[rs.OrgRepo], nil
+}
+
+func TestRunLock(t *testing.T) {
+	fSys := fs.MakeFakeFS()
+	fSys.WriteFile("/app/kustomization.yaml", []byte(`
+resources:
+- deployment.yaml
+- local
+- github.com/org/base/prod?ref=v1
+`))
+	fSys.WriteFile("/app/deployment.yaml", []byte("kind: Deployment\n"))
+	fSys.WriteFile("/app/local/kustomization.yaml", []byte(`
+bases:
+- github.com/org/other
+`))
+	fSys.WriteFile("/app/kustomization.lock", []byte(`
+remotes:
+- url: github.com/org/gone
+  commit: `+otherCommit+`
+`))
+	f := &fakeRemotes{
+		t:    t,
+		fSys: fSys,
+		content: map[string]string{
+			"org/base":   "resources:\n- github.com/org/nested?ref=master\n",
+			"org/nested": "resources: []\n",
+			"org/other":  "resources: []\n",
+		},
+		commits: map[string]string{
+			"org/base":   baseCommit,
+			"org/nested": nestedCommit,
+			"org/other":  otherCommit,
+		},
+	}
+	o := lockOptions{dir: "/app", cloner: f.clone, resolve: f.resolve}
+	var out bytes.Buffer
+	err := o.RunLock(&out, fSys, validators.MakeFakeValidator())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := baseCommit + " github.com/org/base/prod?ref=v1\n" +
+		nestedCommit + " github.com/org/nested?ref=master\n" +
+		otherCommit + " github.com/org/other\n"
+	if out.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+	b, _ := fSys.ReadFile("/app/kustomization.lock")
+	if strings.Contains(string(b), "gone") ||
+		!strings.Contains(string(b), "url: github.com/org/nested?ref=master\n") {
+		t.Fatalf("unexpected lock:\n%s", b)
+	}
+
+	// A ref moving on is picked up.
+	f.commits["org/nested"] = otherCommit
+	out.Reset()
+	err = o.RunLock(&out, fSys, validators.MakeFakeValidator())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), otherCommit+" github.com/org/nested") {
+		t.Fatalf("expected updated commit, got\n%s", out.String())
+	}
+}
+
+func TestRunLockWithoutRemotes(t *testing.T) {
+	fSys := fs.MakeFakeFS()
+	fSys.WriteFile("/app/kustomization.yaml", []byte("resources:\n- a.yaml\n"))
+	o := lockOptions{dir: "/app"}
+	var out bytes.Buffer
+	err := o.RunLock(&out, fSys, validators.MakeFakeValidator())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "no remote bases to lock\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	if fSys.Exists("/app/kustomization.lock") {
+		t.Fatalf("unexpected lock file")
+	}
+}
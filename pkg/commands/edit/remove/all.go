@@ -43,6 +43,25 @@ func NewCmdRemove(
 
 	# Removes one or more commonAnnotations from the kustomization file
 	kustomize edit remove annotation {annotationKey1},{annotationKey2}
+
+	# Removes the replica counts of resources from the kustomization file
+	kustomize edit remove replicas {name} {name}
+
+	# Removes vars from the kustomization file
+	kustomize edit remove var {name} {name}
+
+	# Removes json patches from the kustomization file
+	kustomize edit remove patchjson6902 <filepath>
+
+	# Removes generator, transformer, configuration or CRD files from the kustomization file
+	kustomize edit remove generator {filepath}
+	kustomize edit remove transformer {filepath}
+	kustomize edit remove configuration {filepath}
+	kustomize edit remove crd {filepath}
+
+	# Removes the generatorOptions or inventory field from the kustomization file
+	kustomize edit remove generatoroptions
+	kustomize edit remove inventory
 `,
 		Args: cobra.MinimumNArgs(1),
 	}
@@ -51,6 +70,15 @@ func NewCmdRemove(
 		newCmdRemoveLabel(fsys, ldr.Validator().MakeLabelNameValidator()),
 		newCmdRemoveAnnotation(fsys, ldr.Validator().MakeAnnotationNameValidator()),
 		newCmdRemovePatch(fsys),
+		newCmdRemoveReplicas(fsys),
+		newCmdRemoveVar(fsys),
+		newCmdRemovePatchJson6902(fsys),
+		newCmdRemoveGenerator(fsys),
+		newCmdRemoveTransformer(fsys),
+		newCmdRemoveConfiguration(fsys),
+		newCmdRemoveCrd(fsys),
+		newCmdRemoveGeneratorOptions(fsys),
+		newCmdRemoveInventory(fsys),
 	)
 	return c
 }
@@ -0,0 +1,44 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"errors"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+)
+
+// newCmdRemoveGeneratorOptions removes the generatorOptions field from the kustomization file.
+func newCmdRemoveGeneratorOptions(fsys fs.FileSystem) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generatoroptions",
+		Short: "Removes the generatorOptions field from " + pgmconfig.KustomizationFileNames[0],
+		Example: `
+		remove generatoroptions`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("generatoroptions takes no arguments")
+			}
+			mf, err := kustfile.NewKustomizationFile(fsys)
+			if err != nil {
+				return err
+			}
+			m, err := mf.Read()
+			if err != nil {
+				return err
+			}
+			if m.GeneratorOptions == nil {
+				log.Printf("generatorOptions not in kustomization file")
+				return nil
+			}
+			m.GeneratorOptions = nil
+			return mf.Write(m)
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestRemoveGeneratorOptions(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+generatorOptions:
+  disableNameSuffixHash: true
+# The app
+resources:
+- app.yaml
+`))
+
+	for i := 0; i < 2; i++ {
+		// removing missing generatorOptions doesn't return an error
+		cmd := newCmdRemoveGeneratorOptions(fakeFS)
+		err := cmd.RunE(cmd, nil)
+		if err != nil {
+			t.Fatalf("unexpected cmd error: %v", err)
+		}
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# The app
+resources:
+- app.yaml
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"errors"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+)
+
+// newCmdRemoveInventory removes the inventory field from the kustomization file.
+func newCmdRemoveInventory(fsys fs.FileSystem) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "Removes the inventory field from " + pgmconfig.KustomizationFileNames[0],
+		Example: `
+		remove inventory`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return errors.New("inventory takes no arguments")
+			}
+			mf, err := kustfile.NewKustomizationFile(fsys)
+			if err != nil {
+				return err
+			}
+			m, err := mf.Read()
+			if err != nil {
+				return err
+			}
+			if m.Inventory == nil {
+				log.Printf("inventory not in kustomization file")
+				return nil
+			}
+			m.Inventory = nil
+			return mf.Write(m)
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestRemoveInventory(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# The app
+resources:
+- app.yaml
+inventory:
+  type: ConfigMap
+  configMap:
+    name: applied
+`))
+
+	cmd := newCmdRemoveInventory(fakeFS)
+	err := cmd.RunE(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# The app
+resources:
+- app.yaml
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
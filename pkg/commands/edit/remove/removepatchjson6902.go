@@ -0,0 +1,93 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type removePatchJson6902Options struct {
+	patchFilePaths []string
+}
+
+// newCmdRemovePatchJson6902 removes json patches from the kustomization file.
+func newCmdRemovePatchJson6902(fsys fs.FileSystem) *cobra.Command {
+	var o removePatchJson6902Options
+
+	cmd := &cobra.Command{
+		Use:   "patchjson6902",
+		Short: "Removes one or more json patches from " + pgmconfig.KustomizationFileNames[0],
+		Example: `
+		remove patchjson6902 {filepath}`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunRemovePatchJson6902(fsys)
+		},
+	}
+	return cmd
+}
+
+// Validate validates removePatchJson6902 command.
+func (o *removePatchJson6902Options) Validate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("must specify a json patch file")
+	}
+	o.patchFilePaths = args
+	return nil
+}
+
+// Complete completes removePatchJson6902 command.
+func (o *removePatchJson6902Options) Complete(cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// RunRemovePatchJson6902 runs removePatchJson6902 command (do real work).
+func (o *removePatchJson6902Options) RunRemovePatchJson6902(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, p := range m.PatchesJson6902 {
+		paths = append(paths, p.Path)
+	}
+	paths, err = globPatterns(paths, o.patchFilePaths)
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	kept := make([]types.PatchJson6902, 0, len(m.PatchesJson6902))
+	for _, p := range m.PatchesJson6902 {
+		if p.Path != "" && kustfile.StringInSlice(p.Path, paths) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+
+	m.PatchesJson6902 = kept
+	return mf.Write(m)
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestRemovePatchJson6902(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# JSON patches
+patchesJson6902:
+- path: patch1.yaml
+  target:
+    kind: Deployment
+    name: web
+- path: patch2.yaml
+  target:
+    kind: Deployment
+    name: worker
+`))
+
+	cmd := newCmdRemovePatchJson6902(fakeFS)
+	err := cmd.RunE(cmd, []string{"patch1.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# JSON patches
+patchesJson6902:
+- path: patch2.yaml
+  target:
+    kind: Deployment
+    name: worker
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
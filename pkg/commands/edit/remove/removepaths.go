@@ -0,0 +1,135 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+// pathsField is a kustomization field holding a list of file paths.
+type pathsField struct {
+	// kind names one entry of the field, e.g. generator.
+	kind  string
+	field func(m *types.Kustomization) *[]string
+}
+
+var (
+	generatorsField = pathsField{"generator",
+		func(m *types.Kustomization) *[]string { return &m.Generators }}
+	transformersField = pathsField{"transformer",
+		func(m *types.Kustomization) *[]string { return &m.Transformers }}
+	configurationsField = pathsField{"configuration",
+		func(m *types.Kustomization) *[]string { return &m.Configurations }}
+	crdsField = pathsField{"crd",
+		func(m *types.Kustomization) *[]string { return &m.Crds }}
+)
+
+type removePathsOptions struct {
+	field     pathsField
+	filePaths []string
+}
+
+// newCmdRemoveGenerator removes generator plugin config
+// file paths from the kustomization file.
+func newCmdRemoveGenerator(fsys fs.FileSystem) *cobra.Command {
+	return newCmdRemovePaths(fsys, generatorsField)
+}
+
+// newCmdRemoveTransformer removes transformer plugin config
+// file paths from the kustomization file.
+func newCmdRemoveTransformer(fsys fs.FileSystem) *cobra.Command {
+	return newCmdRemovePaths(fsys, transformersField)
+}
+
+// newCmdRemoveConfiguration removes transformer configuration
+// file paths from the kustomization file.
+func newCmdRemoveConfiguration(fsys fs.FileSystem) *cobra.Command {
+	return newCmdRemovePaths(fsys, configurationsField)
+}
+
+// newCmdRemoveCrd removes CRD file paths from the kustomization file.
+func newCmdRemoveCrd(fsys fs.FileSystem) *cobra.Command {
+	return newCmdRemovePaths(fsys, crdsField)
+}
+
+func newCmdRemovePaths(fsys fs.FileSystem, f pathsField) *cobra.Command {
+	o := removePathsOptions{field: f}
+
+	cmd := &cobra.Command{
+		Use: f.kind,
+		Short: fmt.Sprintf("Removes one or more %s file paths from %s",
+			f.kind, pgmconfig.KustomizationFileNames[0]),
+		Example: fmt.Sprintf(`
+		remove %[1]s my-%[1]s.yaml
+		remove %[1]s %[1]ss/*.yaml
+		`, f.kind),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunRemovePaths(fsys)
+		},
+	}
+	return cmd
+}
+
+// Validate validates removePaths command.
+func (o *removePathsOptions) Validate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("must specify a " + o.field.kind + " file")
+	}
+	o.filePaths = args
+	return nil
+}
+
+// Complete completes removePaths command.
+func (o *removePathsOptions) Complete(cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// RunRemovePaths runs removePaths command (do real work).
+func (o *removePathsOptions) RunRemovePaths(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	list := o.field.field(m)
+	paths, err := globPatterns(*list, o.filePaths)
+	if err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	kept := make([]string, 0, len(*list))
+	for _, path := range *list {
+		if kustfile.StringInSlice(path, paths) {
+			continue
+		}
+		kept = append(kept, path)
+	}
+
+	*list = kept
+	return mf.Write(m)
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestRemovePaths(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Plugins
+transformers:
+- a.yaml
+- b.yaml
+- plugins/c.yaml
+# Custom kinds
+crds:
+- crd.yaml
+`))
+
+	cmd := newCmdRemoveTransformer(fakeFS)
+	err := cmd.RunE(cmd, []string{"a.yaml", "plugins/*"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	// removing a path that isn't there doesn't return an error
+	cmd = newCmdRemoveCrd(fakeFS)
+	err = cmd.RunE(cmd, []string{"other.yaml"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Plugins
+transformers:
+- b.yaml
+# Custom kinds
+crds:
+- crd.yaml
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
+
+func TestRemovePathsNoArgs(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+
+	cmd := newCmdRemoveConfiguration(fakeFS)
+	err := cmd.RunE(cmd, nil)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "must specify a configuration file" {
+		t.Errorf("incorrect error: %v", err.Error())
+	}
+}
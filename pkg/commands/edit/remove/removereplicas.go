@@ -0,0 +1,96 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type removeReplicasOptions struct {
+	names []string
+}
+
+// newCmdRemoveReplicas removes replica counts from the kustomization file.
+func newCmdRemoveReplicas(fsys fs.FileSystem) *cobra.Command {
+	var o removeReplicasOptions
+
+	cmd := &cobra.Command{
+		Use:   "replicas",
+		Short: "Removes the replica counts of one or more resources from " + pgmconfig.KustomizationFileNames[0],
+		Example: `
+		remove replicas my-deployment
+		remove replicas my-deployment my-statefulset
+		remove replicas 'web-*'
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunRemoveReplicas(fsys)
+		},
+	}
+	return cmd
+}
+
+// Validate validates removeReplicas command.
+func (o *removeReplicasOptions) Validate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("must specify a resource name")
+	}
+	o.names = args
+	return nil
+}
+
+// Complete completes removeReplicas command.
+func (o *removeReplicasOptions) Complete(cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// RunRemoveReplicas runs removeReplicas command (do real work).
+func (o *removeReplicasOptions) RunRemoveReplicas(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, r := range m.Replicas {
+		names = append(names, r.Name)
+	}
+	names, err = globPatterns(names, o.names)
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	kept := make([]types.Replica, 0, len(m.Replicas))
+	for _, r := range m.Replicas {
+		if kustfile.StringInSlice(r.Name, names) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	m.Replicas = kept
+	return mf.Write(m)
+}
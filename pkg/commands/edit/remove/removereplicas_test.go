@@ -0,0 +1,45 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestRemoveReplicas(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Scaled for production
+replicas:
+- name: web-a
+  count: 2
+- name: web-b
+  count: 2
+- name: worker
+  count: 1
+`))
+
+	cmd := newCmdRemoveReplicas(fakeFS)
+	err := cmd.RunE(cmd, []string{"web-*"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Scaled for production
+replicas:
+- name: worker
+  count: 1
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
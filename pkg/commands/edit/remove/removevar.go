@@ -0,0 +1,96 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type removeVarOptions struct {
+	names []string
+}
+
+// newCmdRemoveVar removes vars from the kustomization file.
+func newCmdRemoveVar(fsys fs.FileSystem) *cobra.Command {
+	var o removeVarOptions
+
+	cmd := &cobra.Command{
+		Use:   "var",
+		Short: "Removes one or more vars from " + pgmconfig.KustomizationFileNames[0],
+		Example: `
+		remove var MY_SERVICE_NAME
+		remove var MY_SERVICE_NAME MY_SERVICE_PORT
+		remove var 'MY_SERVICE_*'
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunRemoveVar(fsys)
+		},
+	}
+	return cmd
+}
+
+// Validate validates removeVar command.
+func (o *removeVarOptions) Validate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("must specify a var name")
+	}
+	o.names = args
+	return nil
+}
+
+// Complete completes removeVar command.
+func (o *removeVarOptions) Complete(cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// RunRemoveVar runs removeVar command (do real work).
+func (o *removeVarOptions) RunRemoveVar(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, v := range m.Vars {
+		names = append(names, v.Name)
+	}
+	names, err = globPatterns(names, o.names)
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	kept := make([]types.Var, 0, len(m.Vars))
+	for _, v := range m.Vars {
+		if kustfile.StringInSlice(v.Name, names) {
+			continue
+		}
+		kept = append(kept, v)
+	}
+
+	m.Vars = kept
+	return mf.Write(m)
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package remove
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestRemoveVar(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Injected into the app's args
+vars:
+- name: DB_HOST
+  objref:
+    kind: Service
+    name: db
+    version: v1
+- name: CACHE_HOST
+  objref:
+    kind: Service
+    name: cache
+    version: v1
+  fieldref:
+    fieldpath: metadata.name
+`))
+
+	cmd := newCmdRemoveVar(fakeFS)
+	err := cmd.RunE(cmd, []string{"DB_HOST", "OTHER"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Injected into the app's args
+vars:
+- name: CACHE_HOST
+  objref:
+    kind: Service
+    name: cache
+    version: v1
+  fieldref:
+    fieldpath: metadata.name
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
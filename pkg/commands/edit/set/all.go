@@ -34,6 +34,21 @@ func NewCmdSet(fsys fs.FileSystem, v ifc.Validator) *cobra.Command {
 
 	# Sets the namesuffix field
 	kustomize edit set namesuffix <suffix-value>
+
+	# Sets the replica counts of resources
+	kustomize edit set replicas <name>=<count>
+
+	# Changes where a var takes its value from
+	kustomize edit set var NAME --name <resource-name>
+
+	# Changes the resource a json patch applies to
+	kustomize edit set patchjson6902 <filepath> --name <resource-name>
+
+	# Sets the generatorOptions field
+	kustomize edit set generatoroptions --disable-name-suffix-hash
+
+	# Sets the inventory field
+	kustomize edit set inventory --name <configmap-name>
 `,
 		Args: cobra.MinimumNArgs(1),
 	}
@@ -43,6 +58,11 @@ func NewCmdSet(fsys fs.FileSystem, v ifc.Validator) *cobra.Command {
 		newCmdSetNameSuffix(fsys),
 		newCmdSetNamespace(fsys, v),
 		newCmdSetImage(fsys),
+		newCmdSetReplicas(fsys),
+		newCmdSetVar(fsys),
+		newCmdSetPatchJson6902(fsys),
+		newCmdSetGeneratorOptions(fsys),
+		newCmdSetInventory(fsys),
 	)
 	return c
 }
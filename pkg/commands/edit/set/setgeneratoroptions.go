@@ -0,0 +1,124 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/commands/util"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type setGeneratorOptionsOptions struct {
+	disableNameSuffixHash bool
+	labels                string
+	annotations           string
+	// set holds what to change in the generator options, per flag given.
+	set []func(g *types.GeneratorOptions)
+}
+
+// newCmdSetGeneratorOptions sets the generatorOptions field in the kustomization.
+func newCmdSetGeneratorOptions(fsys fs.FileSystem) *cobra.Command {
+	var o setGeneratorOptionsOptions
+
+	cmd := &cobra.Command{
+		Use:   "generatoroptions",
+		Short: "Sets the options of the generators in the kustomization file",
+		Example: `
+The command
+  set generatoroptions --disable-name-suffix-hash --labels app:web
+will add
+
+generatorOptions:
+  disableNameSuffixHash: true
+  labels:
+    app: web
+
+to the kustomization file if it doesn't exist, and overwrite just
+the options given if it does.  Labels and annotations given replace
+the previous ones.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunSetGeneratorOptions(fsys)
+		},
+	}
+	cmd.Flags().BoolVar(&o.disableNameSuffixHash, "disable-name-suffix-hash", false,
+		"disable the hash suffix on the names of generated resources")
+	cmd.Flags().StringVar(&o.labels, "labels", "",
+		"labels to add to generated resources, e.g. {key1:value1},{key2:value2}")
+	cmd.Flags().StringVar(&o.annotations, "annotations", "",
+		"annotations to add to generated resources, e.g. {key1:value1},{key2:value2}")
+	return cmd
+}
+
+// Validate validates setGeneratorOptions command.
+func (o *setGeneratorOptionsOptions) Validate(args []string) error {
+	if len(args) != 0 {
+		return errors.New("generatoroptions takes no arguments, only flags")
+	}
+	return nil
+}
+
+// Complete completes setGeneratorOptions command.
+func (o *setGeneratorOptionsOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.set = nil
+	if cmd.Flags().Changed("disable-name-suffix-hash") {
+		o.set = append(o.set, func(g *types.GeneratorOptions) {
+			g.DisableNameSuffixHash = o.disableNameSuffixHash
+		})
+	}
+	if cmd.Flags().Changed("labels") {
+		labels, err := util.ConvertToMap(o.labels, "label")
+		if err != nil {
+			return err
+		}
+		o.set = append(o.set, func(g *types.GeneratorOptions) {
+			g.Labels = labels
+		})
+	}
+	if cmd.Flags().Changed("annotations") {
+		annotations, err := util.ConvertToMap(o.annotations, "annotation")
+		if err != nil {
+			return err
+		}
+		o.set = append(o.set, func(g *types.GeneratorOptions) {
+			g.Annotations = annotations
+		})
+	}
+	if len(o.set) == 0 {
+		return errors.New("must specify an option to set")
+	}
+	return nil
+}
+
+// RunSetGeneratorOptions runs setGeneratorOptions command.
+func (o *setGeneratorOptionsOptions) RunSetGeneratorOptions(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	if m.GeneratorOptions == nil {
+		m.GeneratorOptions = &types.GeneratorOptions{}
+	}
+	for _, set := range o.set {
+		set(m.GeneratorOptions)
+	}
+	return mf.Write(m)
+}
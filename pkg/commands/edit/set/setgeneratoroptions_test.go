@@ -0,0 +1,83 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestSetGeneratorOptions(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Keep generated names stable
+generatorOptions:
+  disableNameSuffixHash: true
+  labels:
+    app: web
+`))
+
+	cmd := newCmdSetGeneratorOptions(fakeFS)
+	cmd.SetArgs([]string{"--annotations", "owner:team-a"})
+	err := cmd.Execute()
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Keep generated names stable
+generatorOptions:
+  annotations:
+    owner: team-a
+  disableNameSuffixHash: true
+  labels:
+    app: web
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+
+	cmd = newCmdSetGeneratorOptions(fakeFS)
+	cmd.SetArgs([]string{"--disable-name-suffix-hash=false", "--labels", ""})
+	err = cmd.Execute()
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err = fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected = `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Keep generated names stable
+generatorOptions:
+  annotations:
+    owner: team-a
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
+
+func TestSetGeneratorOptionsNoFlags(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomization()
+
+	cmd := newCmdSetGeneratorOptions(fakeFS)
+	cmd.SetOutput(ioutil.Discard)
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "must specify an option to set" {
+		t.Errorf("incorrect error: %v", err.Error())
+	}
+}
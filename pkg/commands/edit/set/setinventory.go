@@ -0,0 +1,85 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type setInventoryOptions struct {
+	inventory types.Inventory
+}
+
+// newCmdSetInventory sets the inventory field in the kustomization.
+func newCmdSetInventory(fsys fs.FileSystem) *cobra.Command {
+	var o setInventoryOptions
+
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "Sets the inventory object in the kustomization file",
+		Example: `
+The command
+  set inventory --name my-inventory --namespace default
+will add
+
+inventory:
+  type: ConfigMap
+  configMap:
+    name: my-inventory
+    namespace: default
+
+to the kustomization file if it doesn't exist,
+and overwrite it if it does.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			return o.RunSetInventory(fsys)
+		},
+	}
+	cmd.Flags().StringVar(&o.inventory.Type, "type", "ConfigMap",
+		"type of the inventory object")
+	cmd.Flags().StringVar(&o.inventory.ConfigMap.Name, "name", "",
+		"name of the inventory object")
+	cmd.Flags().StringVar(&o.inventory.ConfigMap.Namespace, "namespace", "",
+		"namespace of the inventory object")
+	return cmd
+}
+
+// Validate validates setInventory command.
+func (o *setInventoryOptions) Validate(args []string) error {
+	if len(args) != 0 {
+		return errors.New("inventory takes no arguments, only flags")
+	}
+	if o.inventory.Type != "ConfigMap" {
+		return errors.New("inventory type must be ConfigMap")
+	}
+	if o.inventory.ConfigMap.Name == "" {
+		return errors.New("must specify the name of the inventory object")
+	}
+	return nil
+}
+
+// RunSetInventory runs setInventory command.
+func (o *setInventoryOptions) RunSetInventory(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	inventory := o.inventory
+	m.Inventory = &inventory
+	return mf.Write(m)
+}
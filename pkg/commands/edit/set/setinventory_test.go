@@ -0,0 +1,61 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestSetInventory(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Tracks what was applied
+inventory:
+  type: ConfigMap
+  configMap:
+    name: old
+`))
+
+	cmd := newCmdSetInventory(fakeFS)
+	cmd.SetArgs([]string{"--name", "applied", "--namespace", "default"})
+	err := cmd.Execute()
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Tracks what was applied
+inventory:
+  type: ConfigMap
+  configMap:
+    name: applied
+    namespace: default
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
+
+func TestSetInventoryNoName(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomization()
+
+	cmd := newCmdSetInventory(fakeFS)
+	cmd.SetOutput(ioutil.Discard)
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "must specify the name of the inventory object" {
+		t.Errorf("incorrect error: %v", err.Error())
+	}
+}
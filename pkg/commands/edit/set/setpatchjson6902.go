@@ -0,0 +1,121 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type setPatchJson6902Options struct {
+	path  string
+	flags types.PatchTarget
+	// set holds what to change in the target, per flag given.
+	set []func(t *types.PatchTarget)
+}
+
+// newCmdSetPatchJson6902 changes the resource a json patch in the kustomization applies to.
+func newCmdSetPatchJson6902(fsys fs.FileSystem) *cobra.Command {
+	var o setPatchJson6902Options
+
+	cmd := &cobra.Command{
+		Use:   "patchjson6902",
+		Short: "Changes the resource a json patch in the kustomization file applies to",
+		Example: `
+The command
+  set patchjson6902 patch.yaml --name other-deployment
+will change the name of the resource the json patch in
+patch.yaml applies to, leaving the rest of its target as it was.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunSetPatchJson6902(fsys)
+		},
+	}
+	cmd.Flags().StringVar(&o.flags.Group, "group", "",
+		"group of the resource to patch")
+	cmd.Flags().StringVar(&o.flags.Version, "version", "",
+		"version of the resource to patch")
+	cmd.Flags().StringVar(&o.flags.Kind, "kind", "",
+		"kind of the resource to patch")
+	cmd.Flags().StringVar(&o.flags.Name, "name", "",
+		"name of the resource to patch")
+	cmd.Flags().StringVar(&o.flags.Namespace, "namespace", "",
+		"namespace of the resource to patch")
+	return cmd
+}
+
+// Validate validates setPatchJson6902 command.
+func (o *setPatchJson6902Options) Validate(args []string) error {
+	if len(args) != 1 {
+		return errors.New("must specify one json patch file")
+	}
+	o.path = args[0]
+	return nil
+}
+
+// Complete completes setPatchJson6902 command.
+func (o *setPatchJson6902Options) Complete(cmd *cobra.Command, args []string) error {
+	f := &o.flags
+	setters := map[string]func(t *types.PatchTarget){
+		"group":     func(t *types.PatchTarget) { t.Group = f.Group },
+		"version":   func(t *types.PatchTarget) { t.Version = f.Version },
+		"kind":      func(t *types.PatchTarget) { t.Kind = f.Kind },
+		"name":      func(t *types.PatchTarget) { t.Name = f.Name },
+		"namespace": func(t *types.PatchTarget) { t.Namespace = f.Namespace },
+	}
+	o.set = nil
+	for flag, set := range setters {
+		if cmd.Flags().Changed(flag) {
+			o.set = append(o.set, set)
+		}
+	}
+	if len(o.set) == 0 {
+		return errors.New("must specify what to change in the target")
+	}
+	return nil
+}
+
+// RunSetPatchJson6902 runs setPatchJson6902 command.
+func (o *setPatchJson6902Options) RunSetPatchJson6902(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range m.PatchesJson6902 {
+		p := &m.PatchesJson6902[i]
+		if p.Path != o.path {
+			continue
+		}
+		if p.Target == nil {
+			p.Target = &types.PatchTarget{}
+		}
+		for _, set := range o.set {
+			set(p.Target)
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("patchjson6902 %s not in kustomization file", o.path)
+	}
+	return mf.Write(m)
+}
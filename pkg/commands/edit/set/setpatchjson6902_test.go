@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestSetPatchJson6902(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Drops the replicas
+patchesJson6902:
+- path: patch.yaml
+  target:
+    group: apps
+    version: v1
+    kind: Deployment
+    name: web
+`))
+
+	cmd := newCmdSetPatchJson6902(fakeFS)
+	cmd.SetArgs([]string{"patch.yaml", "--kind", "StatefulSet", "--name", "db"})
+	err := cmd.Execute()
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Drops the replicas
+patchesJson6902:
+- path: patch.yaml
+  target:
+    group: apps
+    version: v1
+    kind: StatefulSet
+    name: db
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
+
+func TestSetPatchJson6902Missing(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomization()
+
+	cmd := newCmdSetPatchJson6902(fakeFS)
+	cmd.SetOutput(ioutil.Discard)
+	cmd.SetArgs([]string{"patch.yaml", "--name", "db"})
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if err.Error() != "patchjson6902 patch.yaml not in kustomization file" {
+		t.Errorf("incorrect error: %v", err.Error())
+	}
+}
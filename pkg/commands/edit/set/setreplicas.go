@@ -0,0 +1,98 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type setReplicasOptions struct {
+	replicas []types.Replica
+}
+
+// newCmdSetReplicas sets the replica counts of resources in the kustomization.
+func newCmdSetReplicas(fsys fs.FileSystem) *cobra.Command {
+	var o setReplicasOptions
+
+	cmd := &cobra.Command{
+		Use:   "replicas",
+		Short: "Sets the replica counts of resources in the kustomization file",
+		Example: `
+The command
+  set replicas my-deployment=3 my-statefulset=1
+will add
+
+replicas:
+- name: my-deployment
+  count: 3
+- name: my-statefulset
+  count: 1
+
+to the kustomization file if it doesn't exist,
+and overwrite the count of an entry with the same name if it does.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			return o.RunSetReplicas(fsys)
+		},
+	}
+	return cmd
+}
+
+// Validate validates setReplicas command.
+func (o *setReplicasOptions) Validate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("no replicas specified")
+	}
+	o.replicas = nil
+	for _, arg := range args {
+		s := strings.Split(arg, separator)
+		if len(s) != 2 || s[0] == "" {
+			return fmt.Errorf("invalid format of replicas %s, use <name>=<count>", arg)
+		}
+		count, err := strconv.ParseInt(s[1], 10, 64)
+		if err != nil || count < 0 {
+			return fmt.Errorf("invalid replica count %s for %s", s[1], s[0])
+		}
+		o.replicas = append(o.replicas, types.Replica{Name: s[0], Count: count})
+	}
+	return nil
+}
+
+// RunSetReplicas runs setReplicas command.
+func (o *setReplicasOptions) RunSetReplicas(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range o.replicas {
+		found := false
+		for i := range m.Replicas {
+			if m.Replicas[i].Name == r.Name && m.Replicas[i].Selector == nil {
+				m.Replicas[i].Count = r.Count
+				found = true
+			}
+		}
+		if !found {
+			m.Replicas = append(m.Replicas, r)
+		}
+	}
+	return mf.Write(m)
+}
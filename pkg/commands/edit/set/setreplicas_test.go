@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestSetReplicas(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Scaled for production
+replicas:
+- name: web
+  count: 2
+- name: worker
+  count: 1
+`))
+
+	cmd := newCmdSetReplicas(fakeFS)
+	err := cmd.RunE(cmd, []string{"worker=5", "db=1"})
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Scaled for production
+replicas:
+- name: web
+  count: 2
+- name: worker
+  count: 5
+- count: 1
+  name: db
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
+
+func TestSetReplicasInvalid(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomization()
+
+	for arg, msg := range map[string]string{
+		"web":     "invalid format of replicas web, use <name>=<count>",
+		"web=two": "invalid replica count two for web",
+		"web=-1":  "invalid replica count -1 for web",
+	} {
+		cmd := newCmdSetReplicas(fakeFS)
+		err := cmd.RunE(cmd, []string{arg})
+		if err == nil {
+			t.Fatalf("expected an error for %s", arg)
+		}
+		if err.Error() != msg {
+			t.Errorf("incorrect error: %v", err.Error())
+		}
+	}
+}
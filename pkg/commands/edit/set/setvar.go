@@ -0,0 +1,116 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/irairdon/kustomize/v3/pkg/commands/kustfile"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+)
+
+type setVarOptions struct {
+	name  string
+	flags types.Var
+	// set holds what to change in the var, per flag given.
+	set []func(v *types.Var)
+}
+
+// newCmdSetVar changes the resource or field a var in the kustomization refers to.
+func newCmdSetVar(fsys fs.FileSystem) *cobra.Command {
+	var o setVarOptions
+
+	cmd := &cobra.Command{
+		Use:   "var",
+		Short: "Changes where a var in the kustomization file takes its value from",
+		Example: `
+The command
+  set var MY_SERVICE_NAME --name other-service --namespace staging
+will change the name and namespace of the resource the var
+MY_SERVICE_NAME refers to, leaving the rest of the var as it was.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate(args)
+			if err != nil {
+				return err
+			}
+			err = o.Complete(cmd, args)
+			if err != nil {
+				return err
+			}
+			return o.RunSetVar(fsys)
+		},
+	}
+	cmd.Flags().StringVar(&o.flags.ObjRef.Group, "group", "",
+		"group of the resource holding the value")
+	cmd.Flags().StringVar(&o.flags.ObjRef.Version, "version", "",
+		"version of the resource holding the value")
+	cmd.Flags().StringVar(&o.flags.ObjRef.Kind, "kind", "",
+		"kind of the resource holding the value")
+	cmd.Flags().StringVar(&o.flags.ObjRef.Name, "name", "",
+		"name of the resource holding the value")
+	cmd.Flags().StringVar(&o.flags.ObjRef.Namespace, "namespace", "",
+		"namespace of the resource holding the value")
+	cmd.Flags().StringVar(&o.flags.FieldRef.FieldPath, "fieldpath", "",
+		"path to the field holding the value")
+	return cmd
+}
+
+// Validate validates setVar command.
+func (o *setVarOptions) Validate(args []string) error {
+	if len(args) != 1 {
+		return errors.New("must specify one var name")
+	}
+	o.name = args[0]
+	return nil
+}
+
+// Complete completes setVar command.
+func (o *setVarOptions) Complete(cmd *cobra.Command, args []string) error {
+	f := &o.flags
+	setters := map[string]func(v *types.Var){
+		"group":     func(v *types.Var) { v.ObjRef.Group = f.ObjRef.Group },
+		"version":   func(v *types.Var) { v.ObjRef.Version = f.ObjRef.Version },
+		"kind":      func(v *types.Var) { v.ObjRef.Kind = f.ObjRef.Kind },
+		"name":      func(v *types.Var) { v.ObjRef.Name = f.ObjRef.Name },
+		"namespace": func(v *types.Var) { v.ObjRef.Namespace = f.ObjRef.Namespace },
+		"fieldpath": func(v *types.Var) { v.FieldRef.FieldPath = f.FieldRef.FieldPath },
+	}
+	o.set = nil
+	for flag, set := range setters {
+		if cmd.Flags().Changed(flag) {
+			o.set = append(o.set, set)
+		}
+	}
+	if len(o.set) == 0 {
+		return errors.New("must specify what to change in the var")
+	}
+	return nil
+}
+
+// RunSetVar runs setVar command.
+func (o *setVarOptions) RunSetVar(fSys fs.FileSystem) error {
+	mf, err := kustfile.NewKustomizationFile(fSys)
+	if err != nil {
+		return err
+	}
+	m, err := mf.Read()
+	if err != nil {
+		return err
+	}
+
+	for i := range m.Vars {
+		if m.Vars[i].Name != o.name {
+			continue
+		}
+		for _, set := range o.set {
+			set(&m.Vars[i])
+		}
+		return mf.Write(m)
+	}
+	return fmt.Errorf("var %s not in kustomization file", o.name)
+}
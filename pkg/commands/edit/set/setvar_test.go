@@ -0,0 +1,78 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package set
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+func TestSetVar(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomizationWith([]byte(`apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Where the app finds its database
+vars:
+- name: DB_HOST
+  objref:
+    kind: Service
+    name: db
+    version: v1
+  fieldref:
+    fieldpath: metadata.name
+`))
+
+	cmd := newCmdSetVar(fakeFS)
+	cmd.SetArgs([]string{"DB_HOST", "--name", "other-db", "--namespace", "data"})
+	err := cmd.Execute()
+	if err != nil {
+		t.Fatalf("unexpected cmd error: %v", err)
+	}
+	content, err := fakeFS.ReadTestKustomization()
+	if err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	expected := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+# Where the app finds its database
+vars:
+- name: DB_HOST
+  objref:
+    kind: Service
+    name: other-db
+    namespace: data
+    version: v1
+  fieldref:
+    fieldpath: metadata.name
+`
+	if string(content) != expected {
+		t.Errorf("expected\n%s\nbut got\n%s", expected, content)
+	}
+}
+
+func TestSetVarErrors(t *testing.T) {
+	fakeFS := fs.MakeFakeFS()
+	fakeFS.WriteTestKustomization()
+
+	for _, tc := range []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{"DB_HOST"}, "must specify what to change in the var"},
+		{[]string{"DB_HOST", "--name", "db"}, "var DB_HOST not in kustomization file"},
+	} {
+		cmd := newCmdSetVar(fakeFS)
+		cmd.SetOutput(ioutil.Discard)
+		cmd.SetArgs(tc.args)
+		err := cmd.Execute()
+		if err == nil {
+			t.Fatalf("expected an error for %v", tc.args)
+		}
+		if err.Error() != tc.msg {
+			t.Errorf("incorrect error: %v", err.Error())
+		}
+	}
+}
@@ -20,10 +20,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io"
 	"log"
+	"path/filepath"
 	"reflect"
-	"regexp"
 	"strings"
 
 	"github.com/irairdon/kustomize/v3/pkg/fs"
@@ -58,6 +57,7 @@ func determineFieldOrder() []string {
 		"Namespace",
 		"Crds",
 		"CommonLabels",
+		"Labels",
 		"CommonAnnotations",
 		"PatchesStrategicMerge",
 		"PatchesJson6902",
@@ -66,6 +66,7 @@ func determineFieldOrder() []string {
 		"SecretGenerator",
 		"GeneratorOptions",
 		"Vars",
+		"Replacements",
 		"Images",
 		"Replicas",
 		"Configurations",
@@ -97,26 +98,15 @@ func determineFieldOrder() []string {
 	return result
 }
 
-// commentedField records the comment associated with a kustomization field
-// field has to be a recognized kustomization field
-// comment can be empty
-type commentedField struct {
-	field   string
-	comment []byte
-}
-
-func (cf *commentedField) appendComment(comment []byte) {
-	cf.comment = append(cf.comment, comment...)
-}
-
-func squash(x [][]byte) []byte {
-	return bytes.Join(x, []byte(``))
-}
-
 type kustomizationFile struct {
-	path           string
-	fSys           fs.FileSystem
-	originalFields []*commentedField
+	dir  string
+	path string
+	fSys fs.FileSystem
+	// original is the file as read, if it could be parsed.
+	original *block
+	// crlf is true if the file as read ended its
+	// lines with \r\n, to be restored on write.
+	crlf bool
 }
 
 // NewKustomizationFile returns a new instance.
@@ -129,10 +119,28 @@ func NewKustomizationFile(fSys fs.FileSystem) (*kustomizationFile, error) { // n
 	return mf, nil
 }
 
+// NewKustomizationFileInDir returns a new instance for
+// the kustomization file in the given directory.
+func NewKustomizationFileInDir(
+	fSys fs.FileSystem, dir string) (*kustomizationFile, error) { // nolint
+	mf := &kustomizationFile{fSys: fSys, dir: dir}
+	err := mf.validate()
+	if err != nil {
+		return nil, err
+	}
+	return mf, nil
+}
+
+// Path returns the path to the kustomization file.
+func (mf *kustomizationFile) Path() string {
+	return mf.path
+}
+
 func (mf *kustomizationFile) validate() error {
 	match := 0
 	var path []string
 	for _, kfilename := range pgmconfig.KustomizationFileNames {
+		kfilename = filepath.Join(mf.dir, kfilename)
 		if mf.fSys.Exists(kfilename) {
 			match += 1
 			path = append(path, kfilename)
@@ -141,7 +149,8 @@ func (mf *kustomizationFile) validate() error {
 
 	switch match {
 	case 0:
-		return fmt.Errorf("Missing kustomization file '%s'.\n", pgmconfig.KustomizationFileNames[0])
+		return fmt.Errorf("Missing kustomization file '%s'.\n",
+			filepath.Join(mf.dir, pgmconfig.KustomizationFileNames[0]))
 	case 1:
 		mf.path = path[0]
 	default:
@@ -159,6 +168,8 @@ func (mf *kustomizationFile) Read() (*types.Kustomization, error) {
 	if err != nil {
 		return nil, err
 	}
+	mf.crlf = bytes.Contains(data, []byte("\r\n"))
+	data = bytes.Replace(data, []byte("\r\n"), []byte("\n"), -1)
 	data = types.FixKustomizationPreUnmarshalling(data)
 	var k types.Kustomization
 	err = yaml.Unmarshal(data, &k)
@@ -166,10 +177,9 @@ func (mf *kustomizationFile) Read() (*types.Kustomization, error) {
 		return nil, err
 	}
 	k.FixKustomizationPostUnmarshalling()
-	err = mf.parseCommentedFields(data)
-	if err != nil {
-		return nil, err
-	}
+	// A file that can't be parsed into a tree is still
+	// read, but is written back without its comments.
+	mf.original, _ = parseDocument(data)
 	return &k, err
 }
 
@@ -181,6 +191,9 @@ func (mf *kustomizationFile) Write(kustomization *types.Kustomization) error {
 	if err != nil {
 		return err
 	}
+	if mf.crlf {
+		data = bytes.Replace(data, []byte("\n"), []byte("\r\n"), -1)
+	}
 	return mf.fSys.WriteFile(mf.path, data)
 }
 
@@ -194,89 +207,18 @@ func StringInSlice(str string, list []string) bool {
 	return false
 }
 
-func (mf *kustomizationFile) parseCommentedFields(content []byte) error {
-	buffer := bytes.NewBuffer(content)
-	var comments [][]byte
-
-	line, err := buffer.ReadBytes('\n')
-	for err == nil {
-		if isCommentOrBlankLine(line) {
-			comments = append(comments, line)
-		} else {
-			matched, field := findMatchedField(line)
-			if matched {
-				mf.originalFields = append(mf.originalFields, &commentedField{field: field, comment: squash(comments)})
-				comments = [][]byte{}
-			} else if len(comments) > 0 {
-				mf.originalFields[len(mf.originalFields)-1].appendComment(squash(comments))
-				comments = [][]byte{}
-			}
-		}
-		line, err = buffer.ReadBytes('\n')
-	}
-
-	if err != io.EOF {
-		return err
-	}
-	return nil
-}
-
-// marshal converts a kustomization to a byte stream.
+// marshal converts a kustomization to a byte stream,
+// merging it into the file as read, if there's one.
 func (mf *kustomizationFile) marshal(kustomization *types.Kustomization) ([]byte, error) {
-	var output []byte
-	for _, comment := range mf.originalFields {
-		output = append(output, comment.comment...)
-		content, err := marshalField(comment.field, kustomization)
-		if err != nil {
-			return content, err
-		}
-		output = append(output, content...)
-	}
+	var fields [][]byte
 	for _, field := range fieldMarshallingOrder {
-		if mf.hasField(field) {
-			continue
-		}
 		content, err := marshalField(field, kustomization)
 		if err != nil {
-			return content, nil
-		}
-		output = append(output, content...)
-
-	}
-	return output, nil
-}
-
-func (mf *kustomizationFile) hasField(name string) bool {
-	for _, n := range mf.originalFields {
-		if n.field == name {
-			return true
-		}
-	}
-	return false
-}
-
-/*
- isCommentOrBlankLine determines if a line is a comment or blank line
- Return true for following lines
- # This line is a comment
-       # This line is also a comment with several leading white spaces
-
- (The line above is a blank line)
-*/
-func isCommentOrBlankLine(line []byte) bool {
-	s := bytes.TrimRight(bytes.TrimLeft(line, " "), "\n")
-	return len(s) == 0 || bytes.HasPrefix(s, []byte(`#`))
-}
-
-func findMatchedField(line []byte) (bool, string) {
-	for _, field := range fieldMarshallingOrder {
-		// (?i) is for case insensitive regexp matching
-		r := regexp.MustCompile("^(" + "(?i)" + field + "):")
-		if r.Match(line) {
-			return true, field
+			return nil, err
 		}
+		fields = append(fields, content)
 	}
-	return false, ""
+	return renderDocument(mf.original, fields)
 }
 
 // marshalField marshal a given field of a kustomization object into yaml format.
@@ -37,6 +37,7 @@ func TestFieldOrder(t *testing.T) {
 		"Namespace",
 		"Crds",
 		"CommonLabels",
+		"Labels",
 		"CommonAnnotations",
 		"PatchesStrategicMerge",
 		"PatchesJson6902",
@@ -45,6 +46,7 @@ func TestFieldOrder(t *testing.T) {
 		"SecretGenerator",
 		"GeneratorOptions",
 		"Vars",
+		"Replacements",
 		"Images",
 		"Replicas",
 		"Configurations",
@@ -209,41 +211,9 @@ generatorOptions:
   disableNameSuffixHash: true
 `)
 
-	expected := []byte(`
-
-    
-
-# Some comments
-# This is some comment we should preserve
-# don't delete it
-  # See which field this comment goes into
-resources:
-- ../namespaces
-- pod.yaml
-- service.yaml
-
-apiVersion: kustomize.config.k8s.io/v1beta1
-kind: kustomization
-
-# something you may want to keep
-vars:
-- fieldref:
-    fieldPath: metadata.name
-  name: MY_SERVICE_NAME
-  objref:
-    apiVersion: v1
-    kind: Service
-    name: my-service
-
-# some descriptions for the patches
-
-patchesStrategicMerge:
-- service.yaml
-- pod.yaml
-# generator options
-generatorOptions:
-  disableNameSuffixHash: true
-`)
+	// Only the case of the key differs from what's read,
+	// so the file is written back as it was.
+	expected := kustomizationContentWithComments
 	fSys := fs.MakeFakeFS()
 	fSys.WriteTestKustomizationWith(kustomizationContentWithComments)
 	mf, err := NewKustomizationFile(fSys)
@@ -348,3 +318,197 @@ kind: Kustomization
 			string(expected), string(bytes))
 	}
 }
+
+func TestWriteMinimalDiff(t *testing.T) {
+	testCases := []struct {
+		description string
+		original    string
+		edit        func(k *types.Kustomization)
+		expected    string
+	}{
+		{
+			description: "inline comments and quoting",
+			original: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namePrefix: 'dev-'   # per environment
+commonLabels:
+  "app": web  # the app
+  tier: "frontend"
+`,
+			edit: func(k *types.Kustomization) {
+				k.NamePrefix = "staging-"
+				k.CommonLabels["tier"] = "backend"
+			},
+			expected: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namePrefix: 'staging-'   # per environment
+commonLabels:
+  "app": web  # the app
+  tier: "backend"
+`,
+		},
+		{
+			description: "comments in lists",
+			original: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  # the app
+  - deployment.yaml  # scaled below
+  - service.yaml
+  # monitoring, see #42
+  - monitor.yaml
+`,
+			edit: func(k *types.Kustomization) {
+				k.Resources = []string{
+					"deployment.yaml", "monitor.yaml", "ingress.yaml"}
+			},
+			expected: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  # the app
+  - deployment.yaml  # scaled below
+  # monitoring, see #42
+  - monitor.yaml
+  - ingress.yaml
+`,
+		},
+		{
+			description: "key order within entries",
+			original: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+images:
+- name: nginx
+  newName: my-registry/nginx   # mirrored
+  newTag: "1.16"
+- name: redis
+  newTag: "5"
+`,
+			edit: func(k *types.Kustomization) {
+				k.Images[0].NewTag = "1.17"
+			},
+			expected: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+images:
+- name: nginx
+  newName: my-registry/nginx   # mirrored
+  newTag: "1.17"
+- name: redis
+  newTag: "5"
+`,
+		},
+		{
+			description: "first key of an item removed",
+			original: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+configMapGenerator:
+- behavior: merge # over the base
+  name: config
+  literals:
+  - a=b
+`,
+			edit: func(k *types.Kustomization) {
+				k.ConfigMapGenerator[0].Behavior = ""
+			},
+			expected: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+configMapGenerator:
+- name: config
+  literals:
+  - a=b
+`,
+		},
+		{
+			description: "fields removed and added",
+			original: `# An overlay
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+
+# Scaled for production
+replicas:
+- name: web
+  count: 3
+namespace: prod # fixed
+`,
+			edit: func(k *types.Kustomization) {
+				k.Replicas = nil
+				k.NameSuffix = "-v2"
+			},
+			expected: `# An overlay
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+
+namespace: prod # fixed
+nameSuffix: -v2
+`,
+		},
+		{
+			description: "flow and block styles",
+			original: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources: [a.yaml, b.yaml]  # both
+patchesJson6902:
+- target: {kind: Service, name: web}
+  patch: |-
+    - op: remove
+      path: /spec/type
+`,
+			edit: func(k *types.Kustomization) {
+				k.Resources = append(k.Resources, "c.yaml")
+				k.PatchesJson6902[0].Patch = "- op: remove\n  path: /spec/ports"
+			},
+			expected: `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources: [a.yaml, b.yaml, c.yaml]  # both
+patchesJson6902:
+- target: {kind: Service, name: web}
+  patch: |-
+    - op: remove
+      path: /spec/ports
+`,
+		},
+		{
+			description: "crlf line endings",
+			original: "apiVersion: kustomize.config.k8s.io/v1beta1\r\n" +
+				"kind: Kustomization\r\n" +
+				"# the app\r\n" +
+				"namePrefix: dev-  # per environment\r\n" +
+				"resources:\r\n" +
+				"- deployment.yaml\r\n",
+			edit: func(k *types.Kustomization) {
+				k.NamePrefix = "staging-"
+				k.Resources = append(k.Resources, "service.yaml")
+			},
+			expected: "apiVersion: kustomize.config.k8s.io/v1beta1\r\n" +
+				"kind: Kustomization\r\n" +
+				"# the app\r\n" +
+				"namePrefix: staging-  # per environment\r\n" +
+				"resources:\r\n" +
+				"- deployment.yaml\r\n" +
+				"- service.yaml\r\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			fSys := fs.MakeFakeFS()
+			fSys.WriteTestKustomizationWith([]byte(tc.original))
+			mf, err := NewKustomizationFile(fSys)
+			if err != nil {
+				t.Fatalf("Unexpected Error: %v", err)
+			}
+			k, err := mf.Read()
+			if err != nil {
+				t.Fatalf("Unexpected Error: %v", err)
+			}
+			tc.edit(k)
+			if err = mf.Write(k); err != nil {
+				t.Fatalf("Unexpected Error: %v", err)
+			}
+			bytes, _ := fSys.ReadFile(mf.path)
+			if string(bytes) != tc.expected {
+				t.Fatalf(
+					"expected =\n%s\n\nactual =\n%s\n",
+					tc.expected, string(bytes))
+			}
+		})
+	}
+}
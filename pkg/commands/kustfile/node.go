@@ -0,0 +1,681 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package kustfile
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// A kustomization file is read into a tree of the block
+// mappings and sequences it's made of, each entry of which
+// remembers the lines it was read from.  Writing the file
+// merges the new values into the tree: entries whose values
+// didn't change keep their text, comments included, entries
+// that changed are rewritten as narrowly as their shape
+// allows, and only new entries are marshalled from scratch.
+
+// block is a block mapping or block sequence.
+type block struct {
+	seq bool
+	// indent is the column of the keys, or dashes, of the block.
+	indent  int
+	entries []*entry
+	// tail holds the comment and blank lines after the last entry.
+	tail []string
+}
+
+// entry is a key and value of a block mapping,
+// or an item of a block sequence.
+type entry struct {
+	// head holds the comment and blank lines before the entry.
+	head []string
+	// key is the key of a mapping entry, as written.
+	key string
+	// lines are the lines of the entry, the first holding
+	// its key or dash.
+	lines []string
+	// value is the value of the entry, as read.
+	value interface{}
+	// start and end delimit, in lines[0], a scalar or flow
+	// value that fits on the line; end is zero if there's none.
+	start, end int
+	// flow is true if the value is a flow collection.
+	flow bool
+	// colon is where, in lines[0], the text after the key's
+	// colon, or after the dash, starts.
+	colon int
+	// comment is the comment ending lines[0], with the
+	// space before it.
+	comment string
+	// child is the block collection holding the value, if any.
+	child *block
+	// dashed is true if child starts on the line of the
+	// item's dash.
+	dashed bool
+}
+
+// parseDocument reads the lines of a YAML document
+// holding a mapping into a tree.
+func parseDocument(data []byte) (*block, error) {
+	lines := splitLines(data)
+	for _, l := range lines {
+		if !isCommentOrBlank(l) {
+			return parseBlock(lines)
+		}
+	}
+	return &block{tail: lines}, nil
+}
+
+func splitLines(data []byte) []string {
+	s := strings.TrimSuffix(string(data), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// parseBlock reads lines into a block, all content lines
+// of which are at least as indented as the first.
+func parseBlock(lines []string) (*block, error) {
+	b := &block{indent: -1}
+	var head []string
+	for i := 0; i < len(lines); {
+		l := lines[i]
+		if isCommentOrBlank(l) {
+			head = append(head, l)
+			i++
+			continue
+		}
+		n := indentOf(l)
+		if b.indent < 0 {
+			b.indent = n
+			b.seq = isDash(l[n:])
+		}
+		if n != b.indent || isDash(l[n:]) != b.seq {
+			return nil, fmt.Errorf("unexpected line %q", l)
+		}
+		e := &entry{head: head}
+		head = nil
+		if !b.seq {
+			key, colon, ok := splitKey(l[n:])
+			if !ok {
+				return nil, fmt.Errorf("expected a key in %q", l)
+			}
+			e.key, e.colon = key, n+colon
+		} else {
+			e.colon = n + 1
+		}
+		e.comment = l[e.colon+commentStart(l[e.colon:]):]
+		inline := strings.TrimSpace(
+			l[e.colon : len(l)-len(e.comment)])
+
+		// The entry goes on while lines are more indented, and
+		// a mapping entry holding a sequence on the lines below
+		// may have its dashes as indented as its key.
+		j := i + 1
+		for ; j < len(lines); j++ {
+			m := lines[j]
+			if isCommentOrBlank(m) || indentOf(m) > b.indent {
+				continue
+			}
+			if !b.seq && inline == "" &&
+				indentOf(m) == b.indent && isDash(m[b.indent:]) {
+				continue
+			}
+			break
+		}
+		// Leave the comments ending the entry to what follows,
+		// unless they're indented into the entry.
+		for j > i+1 {
+			m := lines[j-1]
+			if strings.TrimSpace(m) != "" &&
+				(!isCommentOrBlank(m) || indentOf(m) > b.indent) {
+				break
+			}
+			j--
+		}
+		e.lines = lines[i:j]
+		err := e.parse(b, inline)
+		if err != nil {
+			return nil, err
+		}
+		b.entries = append(b.entries, e)
+		i = j
+	}
+	b.tail = head
+	return b, nil
+}
+
+// parse reads the value of the entry e of block b,
+// the text after whose key or dash is inline.
+func (e *entry) parse(b *block, inline string) error {
+	err := e.decode(b)
+	if err != nil {
+		return err
+	}
+	l := e.lines[0]
+	switch {
+	case inline == "":
+		if len(e.lines) > 1 && hasContent(e.lines[1:]) {
+			e.child, _ = parseBlock(e.lines[1:])
+		}
+	case b.seq && isCollectionStart(inline):
+		// The item's value starts on the line of its dash.
+		normalized := append([]string{
+			l[:b.indent] + " " + l[b.indent+1:]}, e.lines[1:]...)
+		e.child, _ = parseBlock(normalized)
+		e.dashed = e.child != nil
+	case len(e.lines) == 1 && !strings.ContainsAny(inline[:1], "|>&*!%@`"):
+		e.start = e.colon + strings.Index(l[e.colon:], inline)
+		e.end = e.start + len(inline)
+		e.flow = strings.ContainsAny(inline[:1], "[{")
+	}
+	return nil
+}
+
+// decode decodes the value of the entry e of block b.
+func (e *entry) decode(b *block) error {
+	var text []string
+	for _, l := range e.lines {
+		if b.seq {
+			l = "  " + dedent(l, b.indent)
+		} else {
+			l = dedent(l, b.indent)
+		}
+		text = append(text, l)
+	}
+	if b.seq {
+		text = append([]string{"item:"}, text...)
+	}
+	var ms yaml.MapSlice
+	err := yaml.Unmarshal([]byte(strings.Join(text, "\n")), &ms)
+	if err != nil {
+		return err
+	}
+	if len(ms) != 1 {
+		return errors.New("expected one entry in " + e.lines[0])
+	}
+	if b.seq {
+		items, ok := ms[0].Value.([]interface{})
+		if !ok || len(items) != 1 {
+			return errors.New("expected one item in " + e.lines[0])
+		}
+		e.value = items[0]
+		return nil
+	}
+	e.value = ms[0].Value
+	return nil
+}
+
+// render returns the lines of the block holding v, and
+// false if v doesn't have the shape of the block.
+func (b *block) render(v interface{}) ([]string, bool) {
+	if b.seq {
+		items, ok := v.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		return b.renderSeq(items), true
+	}
+	ms, ok := v.(yaml.MapSlice)
+	if !ok {
+		return nil, false
+	}
+	return b.renderMap(ms, true), true
+}
+
+// renderMap keeps the entries whose keys are still there,
+// drops the rest and adds new ones.  Nested mappings are
+// marshalled with their keys sorted, and keep them so: if
+// sorted, a new key goes before the first kept entry that
+// follows it in ms.  Otherwise new keys go at the end.
+func (b *block) renderMap(ms yaml.MapSlice, sorted bool) []string {
+	used := make(map[int]bool)
+	found := make([]int, len(b.entries))
+	for k, e := range b.entries {
+		found[k] = findKey(ms, e.key, used)
+		if found[k] >= 0 {
+			used[found[k]] = true
+		}
+	}
+	var result []string
+	added := make(map[int]bool)
+	addBefore := func(n int) {
+		for i, item := range ms[:n] {
+			if used[i] || added[i] || isEmptyValue(item.Value) {
+				continue
+			}
+			added[i] = true
+			result = append(result,
+				marshalLines(yaml.MapSlice{item}, b.indent)...)
+		}
+	}
+	for k, e := range b.entries {
+		i := found[k]
+		if i < 0 {
+			if isEmptyValue(e.value) {
+				result = append(result, e.head...)
+				result = append(result, e.lines...)
+			} else {
+				result = append(result, keptHead(e.head)...)
+			}
+			continue
+		}
+		if sorted {
+			addBefore(i)
+		}
+		result = append(result, e.head...)
+		result = append(result, e.render(b, ms[i].Value)...)
+	}
+	addBefore(len(ms))
+	return append(result, b.tail...)
+}
+
+// renderSeq keeps the items that are still there, pairs
+// those that aren't, in order, with new items to render in
+// their place, drops the rest and appends new ones.
+func (b *block) renderSeq(items []interface{}) []string {
+	var result []string
+	i, j := 0, 0
+	for _, m := range b.match(items) {
+		n := 0
+		for ; i+n < m[0] && j+n < m[1]; n++ {
+			e := b.entries[i+n]
+			result = append(result, e.head...)
+			result = append(result, e.render(b, items[j+n])...)
+		}
+		for _, e := range b.entries[i+n : m[0]] {
+			result = append(result, keptHead(e.head)...)
+		}
+		for _, item := range items[j+n : m[1]] {
+			result = append(result,
+				marshalLines([]interface{}{item}, b.indent)...)
+		}
+		if m[0] < len(b.entries) {
+			e := b.entries[m[0]]
+			result = append(result, e.head...)
+			result = append(result, e.lines...)
+		}
+		i, j = m[0]+1, m[1]+1
+	}
+	return append(result, b.tail...)
+}
+
+// match returns the indices of the longest run of entries
+// of b equal to items, ending with the indices past both.
+func (b *block) match(items []interface{}) [][2]int {
+	n, m := len(b.entries), len(items)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case equal(b.entries[i].value, items[j]):
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var result [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case equal(b.entries[i].value, items[j]):
+			result = append(result, [2]int{i, j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return append(result, [2]int{n, m})
+}
+
+// render returns the lines of the entry e of block b
+// holding v instead of what it held.
+func (e *entry) render(b *block, v interface{}) []string {
+	if equal(e.value, v) {
+		return e.lines
+	}
+	l := e.lines[0]
+	if e.child != nil {
+		if lines, ok := e.child.render(v); ok {
+			if e.dashed {
+				return redash(lines, b.indent)
+			}
+			return append([]string{l}, lines...)
+		}
+	}
+	if e.end > 0 && isScalar(v) {
+		if s, ok := formatScalar(v, l[e.start:e.end]); ok {
+			return []string{l[:e.start] + s + l[e.end:]}
+		}
+	}
+	if e.flow && isCollection(v) {
+		if s, ok := formatFlow(v); ok {
+			return []string{l[:e.start] + s + l[e.end:]}
+		}
+	}
+	if b.seq {
+		return withComment(
+			marshalLines([]interface{}{v}, b.indent), e.comment)
+	}
+	if !isCollection(v) || isEmptyValue(v) {
+		// Block scalars go on with more indented lines.
+		lines := marshalLines(v, b.indent)
+		return append([]string{l[:e.colon] + " " +
+			strings.TrimLeft(lines[0], " ") + e.comment}, lines[1:]...)
+	}
+	indent := b.indent + 2
+	if _, ok := v.([]interface{}); ok {
+		indent = b.indent
+	}
+	return append([]string{l[:e.colon] + e.comment},
+		marshalLines(v, indent)...)
+}
+
+// redash puts the dash of a sequence item at the given
+// indent back on the first content line of its value.
+func redash(lines []string, indent int) []string {
+	result := append([]string(nil), lines...)
+	for i, l := range result {
+		if !isCommentOrBlank(l) {
+			result[i] = l[:indent] + "-" + l[indent+1:]
+			break
+		}
+	}
+	return result
+}
+
+// marshalLines returns the lines of v marshalled, indented.
+func marshalLines(v interface{}, indent int) []string {
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	lines := splitLines(out)
+	pad := strings.Repeat(" ", indent)
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = pad + l
+		}
+	}
+	return lines
+}
+
+func withComment(lines []string, comment string) []string {
+	if len(lines) > 0 {
+		lines[0] += comment
+	}
+	return lines
+}
+
+// formatScalar returns v written as the scalar was,
+// quoted the same way if it's a quoted string.
+func formatScalar(v interface{}, was string) (string, bool) {
+	if s, ok := v.(string); ok {
+		switch was[0] {
+		case '\'':
+			return "'" + strings.Replace(s, "'", "''", -1) + "'", true
+		case '"':
+			return strconv.Quote(s), true
+		}
+	}
+	lines := marshalLines(v, 0)
+	if len(lines) != 1 {
+		return "", false
+	}
+	return lines[0], true
+}
+
+// formatFlow returns v written as a flow collection,
+// if it fits on a line.
+func formatFlow(v interface{}) (string, bool) {
+	lines := marshalLines(struct {
+		V interface{} `yaml:"v,flow"`
+	}{v}, 0)
+	if len(lines) != 1 {
+		return "", false
+	}
+	return strings.TrimPrefix(lines[0], "v: "), true
+}
+
+// keptHead returns what of the head of a dropped entry
+// is kept: the lines up to its last blank line, leaving
+// out the comments right before the entry.
+func keptHead(head []string) []string {
+	for i := len(head) - 1; i >= 0; i-- {
+		if strings.TrimSpace(head[i]) == "" {
+			return head[:i+1]
+		}
+	}
+	return nil
+}
+
+// equal returns true if a and b are the same values,
+// reading mapping keys regardless of case, as the
+// kustomization is, and absent as empty.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case yaml.MapSlice:
+		y, ok := b.(yaml.MapSlice)
+		if !ok {
+			return isEmptyValue(a) && isEmptyValue(b)
+		}
+		return coveredBy(x, y) && coveredBy(y, x)
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok {
+			return isEmptyValue(a) && isEmptyValue(b)
+		}
+		if len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b) || isEmptyValue(a) && isEmptyValue(b)
+}
+
+// coveredBy returns true if y holds every item of x that isn't empty.
+func coveredBy(x, y yaml.MapSlice) bool {
+	for _, item := range x {
+		if isEmptyValue(item.Value) {
+			continue
+		}
+		i := findKey(y, keyString(item.Key), nil)
+		if i < 0 || !equal(item.Value, y[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// findKey returns the index of the unused item of ms with
+// the given key, preferring one of the same case, or -1.
+func findKey(ms yaml.MapSlice, key string, used map[int]bool) int {
+	for i, item := range ms {
+		if !used[i] && keyString(item.Key) == key {
+			return i
+		}
+	}
+	for i, item := range ms {
+		if !used[i] && strings.EqualFold(keyString(item.Key), key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func keyString(k interface{}) string {
+	return fmt.Sprint(k)
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case yaml.MapSlice:
+		return len(x) == 0
+	case []interface{}:
+		return len(x) == 0
+	}
+	return false
+}
+
+func isCollection(v interface{}) bool {
+	switch v.(type) {
+	case yaml.MapSlice, []interface{}:
+		return true
+	}
+	return false
+}
+
+func isScalar(v interface{}) bool {
+	return v != nil && !isCollection(v)
+}
+
+func isCommentOrBlank(l string) bool {
+	s := strings.TrimSpace(l)
+	return s == "" || strings.HasPrefix(s, "#") || s == "---"
+}
+
+func hasContent(lines []string) bool {
+	for _, l := range lines {
+		if !isCommentOrBlank(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func indentOf(l string) int {
+	return len(l) - len(strings.TrimLeft(l, " "))
+}
+
+func dedent(l string, n int) string {
+	if m := indentOf(l); m < n {
+		n = m
+	}
+	return l[n:]
+}
+
+func isDash(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+// isCollectionStart returns true if s, following a dash,
+// starts a block mapping or sequence.
+func isCollectionStart(s string) bool {
+	if isDash(s) {
+		return true
+	}
+	_, _, ok := splitKey(s)
+	return ok
+}
+
+// splitKey returns the key s starts with, and where the
+// text after the key's colon starts.
+func splitKey(s string) (string, int, bool) {
+	if s == "" || isDash(s) || strings.ContainsAny(s[:1], "[{#&*!|>%@`") {
+		return "", 0, false
+	}
+	i := 0
+	if s[0] == '"' || s[0] == '\'' {
+		i = closingQuote(s)
+		if i < 0 {
+			return "", 0, false
+		}
+	}
+	for ; i < len(s); i++ {
+		switch {
+		case s[i] == '#' && i > 0 && s[i-1] == ' ':
+			return "", 0, false
+		case s[i] == ':' && (i+1 == len(s) || s[i+1] == ' '):
+			return strings.TrimSpace(unquote(s[:i])), i + 1, true
+		}
+	}
+	return "", 0, false
+}
+
+func unquote(s string) string {
+	var v string
+	if yaml.Unmarshal([]byte(s), &v) == nil {
+		return v
+	}
+	return s
+}
+
+// closingQuote returns the index past the quoted
+// string s starts with, or -1.
+func closingQuote(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// commentStart returns the index of the comment s
+// ends with, with the spaces before it, or len(s).
+func commentStart(s string) int {
+	t := strings.TrimLeft(s, " ")
+	from := len(s) - len(t)
+	if t != "" && (t[0] == '"' || t[0] == '\'') {
+		if i := closingQuote(t); i > 0 {
+			from += i
+		}
+	}
+	for i := from; i < len(s); i++ {
+		if s[i] == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t') {
+			return len(strings.TrimRight(s[:i], " \t"))
+		}
+	}
+	return len(s)
+}
+
+// renderDocument returns the document holding the given
+// marshalled fields, merged into the original, if any.
+func renderDocument(original *block, fields [][]byte) ([]byte, error) {
+	var ms yaml.MapSlice
+	for _, content := range fields {
+		var field yaml.MapSlice
+		err := yaml.Unmarshal(content, &field)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, field...)
+	}
+	if original == nil {
+		original = &block{}
+	}
+	lines := original.renderMap(ms, false)
+	if len(lines) == 0 {
+		return []byte{}, nil
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
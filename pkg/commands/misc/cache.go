@@ -0,0 +1,137 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package misc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/irairdon/kustomize/v3/pkg/git"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdCache returns an instance of 'cache' subcommand.
+func NewCmdCache(w io.Writer) *cobra.Command {
+	var dir string
+	c := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and prune the cache of remote bases",
+		Long: `
+Inspect and prune the cache of remote bases, which
+'kustomize build --git-cache' fills and
+'kustomize build --offline' reads.
+`,
+		Example: `
+	# List the cached checkouts
+	kustomize cache list
+
+	# Remove checkouts no build has used in a week
+	kustomize cache prune --unused-for 168h
+`,
+		Args: cobra.MinimumNArgs(1),
+	}
+	c.PersistentFlags().StringVar(
+		&dir, "cache-dir", git.DefaultCacheDir(),
+		"Directory holding the cache")
+	c.AddCommand(
+		newCmdCacheList(w, &dir),
+		newCmdCachePrune(w, &dir),
+	)
+	return c
+}
+
+func newCmdCacheList(w io.Writer, dir *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the cached checkouts of remote bases",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := git.NewCache(*dir, true).Entries()
+			if err != nil {
+				return err
+			}
+			return writeCacheEntries(w, entries)
+		},
+	}
+}
+
+type pruneOptions struct {
+	unusedFor time.Duration
+	all       bool
+}
+
+func newCmdCachePrune(w io.Writer, dir *string) *cobra.Command {
+	var o pruneOptions
+	c := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached checkouts of remote bases",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.Validate()
+			if err != nil {
+				return err
+			}
+			return o.RunPrune(w, git.NewCache(*dir, true))
+		},
+	}
+	c.Flags().DurationVar(
+		&o.unusedFor, "unused-for", 0,
+		"Remove checkouts not used by a build for this long, e.g. 720h")
+	c.Flags().BoolVar(
+		&o.all, "all", false,
+		"Remove all checkouts")
+	return c
+}
+
+// Validate validates that exactly one of the prune flags is given.
+func (o *pruneOptions) Validate() error {
+	if o.all == (o.unusedFor > 0) {
+		return fmt.Errorf("specify one of --unused-for or --all")
+	}
+	return nil
+}
+
+// RunPrune prunes the cache, listing what it removed.
+func (o *pruneOptions) RunPrune(w io.Writer, c *git.Cache) error {
+	since := time.Now().Add(-o.unusedFor)
+	if o.all {
+		since = time.Now().Add(time.Hour)
+	}
+	entries, err := c.Prune(since)
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+	}
+	_, err = fmt.Fprintf(w, "removed %d checkout(s), %s\n",
+		len(entries), byteCount(size))
+	return err
+}
+
+func writeCacheEntries(w io.Writer, entries []git.CacheEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tCOMMIT\tREFS\tSIZE\tLAST USED")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			e.Repo, e.Commit, strings.Join(e.Refs, ","),
+			byteCount(e.Size), e.LastUsed.Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+func byteCount(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
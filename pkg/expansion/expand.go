@@ -37,23 +37,60 @@ func syntaxWrap(input string) string {
 // implements the expansion semantics defined in the expansion spec; it
 // returns the input string wrapped in the expansion syntax if no mapping
 // for the input is found.
+//
+// Beyond the spec, a reference may give a default, used if no
+// mapping is found, and functions to apply to the value, as in
+// $(NAME:-default|lower).  Known functions are lower, upper,
+// base64, sha256 and trimprefix:PREFIX.  A malformed reference
+// is left as it is.
 func MappingFuncFor(
 	counts map[string]int,
 	context ...map[string]interface{}) func(string) interface{} {
+	mapping := MappingFuncWithUnresolved(counts, nil, context...)
 	return func(input string) interface{} {
+		v, err := mapping(input)
+		if err != nil {
+			return syntaxWrap(input)
+		}
+		return v
+	}
+}
+
+// MappingFuncWithUnresolved is MappingFuncFor, for use with
+// ExpandWithErrors, but also counts in unresolved, if it's
+// not nil, the names of references it can't resolve, and
+// fails on malformed references, e.g. calling an unknown
+// function.
+func MappingFuncWithUnresolved(
+	counts map[string]int, unresolved map[string]int,
+	context ...map[string]interface{}) func(string) (interface{}, error) {
+	return func(input string) (interface{}, error) {
+		ref, ok, err := parseReference(input)
+		if !ok {
+			return syntaxWrap(input), nil
+		}
+		if err != nil {
+			return nil, err
+		}
 		for _, vars := range context {
-			val, ok := vars[input]
+			val, ok := vars[ref.name]
 			if ok {
-				counts[input]++
+				counts[ref.name]++
 				switch typedV := val.(type) {
 				case string, int64, float64, bool:
-					return typedV
+					return ref.apply(typedV), nil
 				default:
-					return syntaxWrap(input)
+					return syntaxWrap(input), nil
 				}
 			}
 		}
-		return syntaxWrap(input)
+		if ref.defaultVal != nil {
+			return ref.apply(*ref.defaultVal), nil
+		}
+		if unresolved != nil {
+			unresolved[ref.name]++
+		}
+		return syntaxWrap(input), nil
 	}
 }
 
@@ -61,6 +98,16 @@ func MappingFuncFor(
 // the expansion spec using the given mapping function to resolve the
 // values of variables.
 func Expand(input string, mapping func(string) interface{}) interface{} {
+	result, _ := ExpandWithErrors(input,
+		func(s string) (interface{}, error) { return mapping(s), nil })
+	return result
+}
+
+// ExpandWithErrors is Expand, but stops at, and returns,
+// the first error of the mapping function.
+func ExpandWithErrors(
+	input string,
+	mapping func(string) (interface{}, error)) (interface{}, error) {
 	var buf bytes.Buffer
 	checkpoint := 0
 	for cursor := 0; cursor < len(input); cursor++ {
@@ -77,10 +124,13 @@ func Expand(input string, mapping func(string) interface{}) interface{} {
 				// We were able to read a variable name correctly;
 				// apply the mapping to the variable name and copy the
 				// bytes into the buffer
-				mapped := mapping(read)
+				mapped, err := mapping(read)
+				if err != nil {
+					return nil, err
+				}
 				if input == syntaxWrap(read) {
 					// Preserve the type of variable
-					return mapped
+					return mapped, nil
 				}
 
 				// Variable is used in a middle of a string
@@ -101,7 +151,7 @@ func Expand(input string, mapping func(string) interface{}) interface{} {
 
 	// Return the buffer and any remaining unwritten bytes in the
 	// input string.
-	return buf.String() + input[checkpoint:]
+	return buf.String() + input[checkpoint:], nil
 }
 
 // tryReadVariableName attempts to read a variable name from the input
@@ -18,6 +18,8 @@ package expansion_test
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	"testing"
 
 	. "github.com/irairdon/kustomize/v3/pkg/expansion"
@@ -376,3 +378,65 @@ func doExpansionTest(t *testing.T, context ...map[string]interface{}) {
 		}
 	}
 }
+
+func TestMappingDefaultsAndFunctions(t *testing.T) {
+	context := map[string]interface{}{
+		"TIER":  "Web",
+		"IMAGE": "v1.2.3",
+		"PORT":  int64(8080),
+	}
+	cases := []struct {
+		input      string
+		expected   interface{}
+		unresolved []string
+		err        string
+	}{
+		{"$(TIER:-db)", "Web", nil, ""},
+		{"$(ZONE:-us-east)", "us-east", nil, ""},
+		{"$(ZONE:-)x", "x", nil, ""},
+		{"$(TIER|lower)-$(TIER|upper)", "web-WEB", nil, ""},
+		{"$(ZONE:-Abc|lower)", "abc", nil, ""},
+		{"$(TIER|base64)", "V2Vi", nil, ""},
+		{"$(TIER|sha256)",
+			"2975104784a401e3880e2215550e9490eda7e67db5fc2b35e1a244acb092ced3", nil, ""},
+		{"$(IMAGE|trimprefix:v)", "1.2.3", nil, ""},
+		{"$(IMAGE|trimprefix:v|upper)", "1.2.3", nil, ""},
+		{"$(PORT)", int64(8080), nil, ""},
+		{"$(PORT|trimprefix:80)", "80", nil, ""},
+		{"$(ZONE)/$(REGION)", "$(ZONE)/$(REGION)", []string{"REGION", "ZONE"}, ""},
+		{"$$(ZONE)", "$(ZONE)", nil, ""},
+		{"a $(TIER|reverse)", nil, nil,
+			"unknown function 'reverse' in $(TIER|reverse)"},
+		{"$(ZONE:us-east)", nil, nil,
+			"malformed default in $(ZONE:us-east); give a default as in $(NAME:-default)"},
+		{"$(hostname -f|cut -d. -f1)", "$(hostname -f|cut -d. -f1)", nil, ""},
+	}
+	for _, tc := range cases {
+		counts := make(map[string]int)
+		unresolved := make(map[string]int)
+		mapping := MappingFuncWithUnresolved(counts, unresolved, context)
+		a, err := ExpandWithErrors(tc.input, mapping)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: expected error %q, got %v", tc.input, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.input, err)
+			continue
+		}
+		if a != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.input, tc.expected, a)
+		}
+		var actual []string
+		for k := range unresolved {
+			actual = append(actual, k)
+		}
+		sort.Strings(actual)
+		if !reflect.DeepEqual(actual, tc.unresolved) {
+			t.Errorf("%s: expected unresolved %v, got %v",
+				tc.input, tc.unresolved, actual)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package expansion
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultSeparator  = ":-"
+	functionSeparator = "|"
+	argumentSeparator = ":"
+)
+
+// varName matches what may be named in a reference.  Text
+// between $( and ) that isn't a name, e.g. the shell
+// command in $(hostname -f), isn't a reference at all.
+var varName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// badDefault matches a name followed by a default
+// missing its dash, as in $(NAME:default).
+var badDefault = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*:`)
+
+// reference is the parsed text between $( and ), i.e.
+//
+//	NAME[:-default][|function[:argument]]...
+//
+// e.g. $(TIER:-web|upper) is the value of TIER, or web if
+// TIER has none, in upper case.
+type reference struct {
+	name       string
+	defaultVal *string
+	functions  []function
+}
+
+// function transforms the value of a reference.
+type function struct {
+	name string
+	arg  string
+	fn   func(s, arg string) string
+}
+
+var functions = map[string]func(s, arg string) string{
+	"lower": func(s, _ string) string {
+		return strings.ToLower(s)
+	},
+	"upper": func(s, _ string) string {
+		return strings.ToUpper(s)
+	},
+	"base64": func(s, _ string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"sha256": func(s, _ string) string {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+	},
+	"trimprefix": strings.TrimPrefix,
+}
+
+// parseReference parses the text between $( and ).
+// It returns false if the text doesn't start with a
+// name, and an error if it does, but is malformed,
+// e.g. calls an unknown function.
+func parseReference(input string) (*reference, bool, error) {
+	parts := strings.Split(input, functionSeparator)
+	r := &reference{name: parts[0]}
+	if i := strings.Index(parts[0], defaultSeparator); i >= 0 {
+		r.name = parts[0][:i]
+		d := parts[0][i+len(defaultSeparator):]
+		r.defaultVal = &d
+	}
+	if r.defaultVal == nil && badDefault.MatchString(r.name) {
+		return nil, true, fmt.Errorf(
+			"malformed default in $(%s); give a default as in $(NAME:-default)",
+			input)
+	}
+	if !varName.MatchString(r.name) {
+		return nil, false, nil
+	}
+	for _, p := range parts[1:] {
+		f := function{name: p}
+		if i := strings.Index(p, argumentSeparator); i >= 0 {
+			f.name, f.arg = p[:i], p[i+len(argumentSeparator):]
+		}
+		f.fn = functions[f.name]
+		if f.fn == nil {
+			return nil, true, fmt.Errorf(
+				"unknown function '%s' in $(%s)", f.name, input)
+		}
+		r.functions = append(r.functions, f)
+	}
+	return r, true, nil
+}
+
+// apply runs the reference's functions on a value.
+// Without functions, the value keeps its type.
+func (r *reference) apply(val interface{}) interface{} {
+	if len(r.functions) == 0 {
+		return val
+	}
+	s := fmt.Sprintf("%v", val)
+	for _, f := range r.functions {
+		s = f.fn(s, f.arg)
+	}
+	return s
+}
@@ -0,0 +1,383 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ FileSystem = &MemFS{}
+
+// MemFS is a FileSystem held entirely in memory, e.g.
+// to build kustomizations received over the network
+// without writing them to disk.
+//
+// Unlike the fake file system used in tests, it behaves
+// as the real one does: directories must exist before
+// files are written in them, reading a directory fails,
+// and so on.  Relative paths are taken relative to "/".
+// It's safe for concurrent use.
+type MemFS struct {
+	mu    sync.RWMutex
+	nodes map[string]*memNode
+}
+
+// memNode is a file or directory.
+type memNode struct {
+	dir     bool
+	content []byte
+	modTime time.Time
+}
+
+// MakeMemFS returns an empty MemFS, holding only "/".
+func MakeMemFS() *MemFS {
+	return &MemFS{nodes: map[string]*memNode{
+		memRoot: {dir: true, modTime: time.Now()},
+	}}
+}
+
+const memRoot = string(filepath.Separator)
+
+// memPath returns the cleaned, absolute form of a path.
+func memPath(name string) string {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(memRoot, name)
+	}
+	return filepath.Clean(name)
+}
+
+func memError(op, name string, err error) error {
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+// checkParent fails unless the parent of the given
+// cleaned path is an existing directory.
+func (fs *MemFS) checkParent(op, name, p string) error {
+	parent, ok := fs.nodes[filepath.Dir(p)]
+	if !ok {
+		return memError(op, name, os.ErrNotExist)
+	}
+	if !parent.dir {
+		return memError(op, name, fmt.Errorf("not a directory"))
+	}
+	return nil
+}
+
+// Create makes or truncates a file, and opens it for writing.
+func (fs *MemFS) Create(name string) (File, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	p := memPath(name)
+	if err := fs.checkParent("create", name, p); err != nil {
+		return nil, err
+	}
+	if n, ok := fs.nodes[p]; ok && n.dir {
+		return nil, memError("create", name, fmt.Errorf("is a directory"))
+	}
+	n := &memNode{modTime: time.Now()}
+	fs.nodes[p] = n
+	return &memFile{fs: fs, name: p, node: n}, nil
+}
+
+// Mkdir makes a directory in an existing directory.
+func (fs *MemFS) Mkdir(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	p := memPath(name)
+	if _, ok := fs.nodes[p]; ok {
+		return memError("mkdir", name, os.ErrExist)
+	}
+	if err := fs.checkParent("mkdir", name, p); err != nil {
+		return err
+	}
+	fs.nodes[p] = &memNode{dir: true, modTime: time.Now()}
+	return nil
+}
+
+// MkdirAll makes a directory, and any missing parents.
+func (fs *MemFS) MkdirAll(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.mkdirAll(name, memPath(name))
+}
+
+func (fs *MemFS) mkdirAll(name, p string) error {
+	if n, ok := fs.nodes[p]; ok {
+		if !n.dir {
+			return memError("mkdir", name, fmt.Errorf("not a directory"))
+		}
+		return nil
+	}
+	if err := fs.mkdirAll(name, filepath.Dir(p)); err != nil {
+		return err
+	}
+	fs.nodes[p] = &memNode{dir: true, modTime: time.Now()}
+	return nil
+}
+
+// RemoveAll removes a path and everything below it.
+// Like os.RemoveAll, removing what's not there is fine.
+func (fs *MemFS) RemoveAll(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	p := memPath(name)
+	if p == memRoot {
+		return memError("removeall", name, fmt.Errorf("cannot remove root"))
+	}
+	prefix := p + string(filepath.Separator)
+	for k := range fs.nodes {
+		if k == p || strings.HasPrefix(k, prefix) {
+			delete(fs.nodes, k)
+		}
+	}
+	return nil
+}
+
+// Open opens a file for reading.
+func (fs *MemFS) Open(name string) (File, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	p := memPath(name)
+	n, ok := fs.nodes[p]
+	if !ok {
+		return nil, memError("open", name, os.ErrNotExist)
+	}
+	return &memFile{
+		fs: fs, name: p, node: n, reader: bytes.NewReader(n.content)}, nil
+}
+
+// CleanedAbs returns a cleaned, absolute path split into
+// directory and file components.  If the entire path is a
+// directory, the file component is an empty string.
+func (fs *MemFS) CleanedAbs(path string) (ConfirmedDir, string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	p := memPath(path)
+	n, ok := fs.nodes[p]
+	if !ok {
+		return "", "", fmt.Errorf("'%s' doesn't exist", path)
+	}
+	if n.dir {
+		return ConfirmedDir(p), "", nil
+	}
+	return ConfirmedDir(filepath.Dir(p)), filepath.Base(p), nil
+}
+
+// Exists is true if the path names a file or directory.
+func (fs *MemFS) Exists(name string) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	_, ok := fs.nodes[memPath(name)]
+	return ok
+}
+
+// Glob returns the sorted paths matching the pattern,
+// with the syntax of filepath.Match.  As with
+// filepath.Glob, a relative pattern yields relative paths.
+func (fs *MemFS) Glob(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	abs := memPath(pattern)
+	var result []string
+	for p := range fs.nodes {
+		if ok, _ := filepath.Match(abs, p); !ok {
+			continue
+		}
+		if !filepath.IsAbs(pattern) {
+			p = strings.TrimPrefix(p, memRoot)
+		}
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// IsDir is true if the path names a directory.
+func (fs *MemFS) IsDir(name string) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	n, ok := fs.nodes[memPath(name)]
+	return ok && n.dir
+}
+
+// ReadFile returns the content of a file.
+func (fs *MemFS) ReadFile(name string) ([]byte, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	n, ok := fs.nodes[memPath(name)]
+	if !ok {
+		return nil, memError("read", name, os.ErrNotExist)
+	}
+	if n.dir {
+		return nil, memError("read", name, fmt.Errorf("is a directory"))
+	}
+	return append([]byte(nil), n.content...), nil
+}
+
+// WriteFile makes or replaces a file in an existing directory.
+func (fs *MemFS) WriteFile(name string, c []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.writeFile(name, memPath(name), c)
+}
+
+func (fs *MemFS) writeFile(name, p string, c []byte) error {
+	if err := fs.checkParent("write", name, p); err != nil {
+		return err
+	}
+	if n, ok := fs.nodes[p]; ok && n.dir {
+		return memError("write", name, fmt.Errorf("is a directory"))
+	}
+	fs.nodes[p] = &memNode{
+		content: append([]byte(nil), c...), modTime: time.Now()}
+	return nil
+}
+
+// Walk walks the tree rooted at path as filepath.Walk
+// does, in lexical order, honoring filepath.SkipDir.
+func (fs *MemFS) Walk(path string, walkFn filepath.WalkFunc) error {
+	info, err := fs.stat(path)
+	if err != nil {
+		err = walkFn(path, nil, err)
+	} else {
+		err = fs.walk(path, info, walkFn)
+	}
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+func (fs *MemFS) walk(
+	path string, info os.FileInfo, walkFn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return walkFn(path, info, nil)
+	}
+	err := walkFn(path, info, nil)
+	if err != nil {
+		return err
+	}
+	for _, name := range fs.readDirNames(path) {
+		filename := filepath.Join(path, name)
+		fileInfo, err := fs.stat(filename)
+		if err != nil {
+			// Removed by walkFn meanwhile.
+			if err := walkFn(filename, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+			continue
+		}
+		err = fs.walk(filename, fileInfo, walkFn)
+		if err != nil && (!fileInfo.IsDir() || err != filepath.SkipDir) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fs *MemFS) stat(name string) (os.FileInfo, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	p := memPath(name)
+	n, ok := fs.nodes[p]
+	if !ok {
+		return nil, memError("lstat", name, os.ErrNotExist)
+	}
+	return &memFileInfo{name: filepath.Base(p), node: *n}, nil
+}
+
+// readDirNames returns the sorted names in a directory.
+func (fs *MemFS) readDirNames(dir string) []string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	p := memPath(dir)
+	var names []string
+	for k := range fs.nodes {
+		if k != p && filepath.Dir(k) == p {
+			names = append(names, filepath.Base(k))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// memFile is an open file in a MemFS.
+type memFile struct {
+	fs     *MemFS
+	name   string
+	node   *memNode
+	reader *bytes.Reader
+}
+
+var _ File = &memFile{}
+
+// Read reads from a file opened with Open.
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, memError("read", f.name, fmt.Errorf("not open for reading"))
+	}
+	if f.node.dir {
+		return 0, memError("read", f.name, fmt.Errorf("is a directory"))
+	}
+	return f.reader.Read(p)
+}
+
+// Write appends to a file opened with Create.
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.reader != nil {
+		return 0, memError("write", f.name, fmt.Errorf("not open for writing"))
+	}
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	f.node.content = append(f.node.content, p...)
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+// Close does nothing.
+func (f *memFile) Close() error {
+	return nil
+}
+
+// Stat describes the file.
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.RLock()
+	defer f.fs.mu.RUnlock()
+	return &memFileInfo{name: filepath.Base(f.name), node: *f.node}, nil
+}
+
+// memFileInfo describes a file or directory in a MemFS.
+type memFileInfo struct {
+	name string
+	node memNode
+}
+
+var _ os.FileInfo = &memFileInfo{}
+
+func (fi *memFileInfo) Name() string { return fi.name }
+
+func (fi *memFileInfo) Size() int64 { return int64(len(fi.node.content)) }
+
+func (fi *memFileInfo) Mode() os.FileMode {
+	if fi.node.dir {
+		return os.ModeDir | 0777
+	}
+	return 0666
+}
+
+func (fi *memFileInfo) ModTime() time.Time { return fi.node.modTime }
+
+func (fi *memFileInfo) IsDir() bool { return fi.node.dir }
+
+func (fi *memFileInfo) Sys() interface{} { return nil }
@@ -0,0 +1,228 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package fs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func makeTestMemFS(t *testing.T) *MemFS {
+	fs, err := MakeMemFSFromFiles(map[string]string{
+		"/app/kustomization.yaml": "resources:\n- a.yaml\n",
+		"/app/a.yaml":             "kind: A\n",
+		"/app/b/b1.yaml":          "kind: B\n",
+		"app/b/b2.yaml":           "kind: B\n",
+		"/app/c/c.yaml":           "kind: C\n",
+		"/other/x.yaml":           "kind: X\n",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return fs
+}
+
+func TestMemFSReadWrite(t *testing.T) {
+	fs := makeTestMemFS(t)
+	b, err := fs.ReadFile("app/b/b2.yaml")
+	if err != nil || string(b) != "kind: B\n" {
+		t.Fatalf("unexpected %q, %v", b, err)
+	}
+	if _, err = fs.ReadFile("/app/b"); err == nil {
+		t.Fatalf("expected error reading a directory")
+	}
+	if _, err = fs.ReadFile("/app/nope"); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+	if err = fs.WriteFile("/nope/x.yaml", nil); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+	if err = fs.WriteFile("/app/a.yaml/x", nil); err == nil {
+		t.Fatalf("expected error writing below a file")
+	}
+	if err = fs.Mkdir("/app"); !os.IsExist(err) {
+		t.Fatalf("expected exist error, got %v", err)
+	}
+
+	f, err := fs.Create("/app/b/new.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Write([]byte("kind: "))
+	f.Write([]byte("New\n"))
+	f.Close()
+	f, err = fs.Open("/app/b/new.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ = ioutil.ReadAll(f)
+	info, _ := f.Stat()
+	if string(b) != "kind: New\n" || info.Size() != 10 || info.Name() != "new.yaml" {
+		t.Fatalf("unexpected %q, %v", b, info)
+	}
+
+	fs.RemoveAll("/app/b")
+	if fs.Exists("/app/b/b1.yaml") || fs.IsDir("/app/b") || !fs.Exists("/app/a.yaml") {
+		t.Fatalf("unexpected state after RemoveAll")
+	}
+}
+
+func TestMemFSCleanedAbs(t *testing.T) {
+	fs := makeTestMemFS(t)
+	var cases = []struct {
+		path, dir, file string
+	}{
+		{"/app", "/app", ""},
+		{"app/b/../a.yaml", "/app", "a.yaml"},
+		{".", "/", ""},
+		{"/app/c/", "/app/c", ""},
+	}
+	for _, c := range cases {
+		d, f, err := fs.CleanedAbs(c.path)
+		if err != nil || string(d) != c.dir || f != c.file {
+			t.Errorf("%s: expected %s %s, got %s %s %v",
+				c.path, c.dir, c.file, d, f, err)
+		}
+	}
+	if _, _, err := fs.CleanedAbs("/app/nope"); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestMemFSGlob(t *testing.T) {
+	fs := makeTestMemFS(t)
+	var cases = []struct {
+		pattern  string
+		expected []string
+	}{
+		{"/app/*.yaml", []string{"/app/a.yaml", "/app/kustomization.yaml"}},
+		{"/app/*/*.yaml", []string{"/app/b/b1.yaml", "/app/b/b2.yaml", "/app/c/c.yaml"}},
+		{"app/b/b[2-9].yaml", []string{"app/b/b2.yaml"}},
+		{"/app/nope*", nil},
+	}
+	for _, c := range cases {
+		actual, err := fs.Glob(c.pattern)
+		if err != nil || !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %v, got %v, %v", c.pattern, c.expected, actual, err)
+		}
+	}
+	if _, err := fs.Glob("/app/["); err == nil {
+		t.Fatalf("expected bad pattern error")
+	}
+}
+
+func TestMemFSWalk(t *testing.T) {
+	fs := makeTestMemFS(t)
+	var visited []string
+	err := fs.Walk("/app", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		visited = append(visited, path)
+		if info.IsDir() && info.Name() == "b" {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"/app", "/app/a.yaml", "/app/b", "/app/c", "/app/c/c.yaml",
+		"/app/kustomization.yaml",
+	}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("expected %v, got %v", expected, visited)
+	}
+	err = fs.Walk("/nope", func(path string, info os.FileInfo, err error) error {
+		return err
+	})
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error, got %v", err)
+	}
+}
+
+func makeTar(t *testing.T, zip bool, entries ...*tar.Header) []byte {
+	var b bytes.Buffer
+	var tw *tar.Writer
+	var zw *gzip.Writer
+	if zip {
+		zw = gzip.NewWriter(&b)
+		tw = tar.NewWriter(zw)
+	} else {
+		tw = tar.NewWriter(&b)
+	}
+	for _, h := range entries {
+		content := h.Name
+		if h.Typeflag == tar.TypeReg {
+			h.Size = int64(len(content))
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			tw.Write([]byte(content))
+		}
+	}
+	tw.Close()
+	if zw != nil {
+		zw.Close()
+	}
+	return b.Bytes()
+}
+
+func TestMemFSFromTar(t *testing.T) {
+	for _, zip := range []bool{false, true} {
+		fs, err := MakeMemFSFromTar(bytes.NewReader(makeTar(t, zip,
+			&tar.Header{Name: "./app/", Typeflag: tar.TypeDir, Mode: 0755},
+			&tar.Header{Name: "./app/kustomization.yaml", Typeflag: tar.TypeReg, Mode: 0644},
+			&tar.Header{Name: "app/base/deploy.yaml", Typeflag: tar.TypeReg, Mode: 0644},
+			&tar.Header{Name: "empty/", Typeflag: tar.TypeDir, Mode: 0755},
+		)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		b, err := fs.ReadFile("/app/base/deploy.yaml")
+		if err != nil || string(b) != "app/base/deploy.yaml" {
+			t.Fatalf("unexpected %q, %v", b, err)
+		}
+		if !fs.IsDir("/empty") || !fs.Exists("/app/kustomization.yaml") {
+			t.Fatalf("missing entries")
+		}
+	}
+
+	_, err := MakeMemFSFromTar(bytes.NewReader(makeTar(t, false,
+		&tar.Header{Name: "app/../../etc/passwd", Typeflag: tar.TypeReg})))
+	if err == nil || !strings.Contains(err.Error(), "reaches outside") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	_, err = MakeMemFSFromTar(bytes.NewReader(makeTar(t, false,
+		&tar.Header{Name: "link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink})))
+	if err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
+
+func TestMemFSAddFiles(t *testing.T) {
+	fs := MakeMemFS()
+	err := fs.AddFiles("/app", map[string]string{"overlay/kustomization.yaml": "x"})
+	if err != nil || !fs.Exists("/app/overlay/kustomization.yaml") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = fs.AddFiles("/app", map[string]string{"../x": "x"})
+	if err == nil || !strings.Contains(err.Error(), "reaches outside") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	err = fs.AddFiles("/app", map[string]string{"overlay/kustomization.yaml/x": "x"})
+	if err == nil {
+		t.Fatalf("expected error writing below a file")
+	}
+}
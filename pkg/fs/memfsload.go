@@ -0,0 +1,130 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package fs
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MakeMemFSFromFiles returns a MemFS holding the given
+// files, keyed by path.  See AddFiles.
+func MakeMemFSFromFiles(files map[string]string) (*MemFS, error) {
+	fs := MakeMemFS()
+	if err := fs.AddFiles(memRoot, files); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// MakeMemFSFromTar returns a MemFS holding the
+// contents of a tar stream.  See AddTar.
+func MakeMemFSFromTar(r io.Reader) (*MemFS, error) {
+	fs := MakeMemFS()
+	if err := fs.AddTar(memRoot, r); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// AddFiles writes the given files, keyed by path relative
+// to dir, making directories as needed.  A leading "/"
+// on a path is ignored; a path reaching outside dir
+// is an error.
+func (fs *MemFS) AddFiles(dir string, files map[string]string) error {
+	// Sorted, so that errors don't depend on map order.
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p, err := memJoin(dir, name)
+		if err != nil {
+			return err
+		}
+		if err = fs.addFile(name, p, []byte(files[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTar extracts a tar stream, which may be gzipped,
+// into dir, making directories as needed.  Only regular
+// files and directories are supported, and no entry may
+// reach outside dir.
+func (fs *MemFS) AddTar(dir string, r io.Reader) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		p, err := memJoin(dir, h.Name)
+		if err != nil {
+			return err
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err = fs.MkdirAll(p); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			c, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			if err = fs.addFile(h.Name, p, c); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+			// Metadata for the archive as a whole.
+		default:
+			return fmt.Errorf(
+				"tar entry '%s' has unsupported type '%c'", h.Name, h.Typeflag)
+		}
+	}
+}
+
+func (fs *MemFS) addFile(name, p string, c []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.mkdirAll(name, filepath.Dir(p)); err != nil {
+		return err
+	}
+	return fs.writeFile(name, p, c)
+}
+
+// memJoin joins a slash separated name to a directory,
+// refusing names that reach outside the directory.
+func memJoin(dir, name string) (string, error) {
+	rel := filepath.Clean(
+		filepath.FromSlash(strings.TrimLeft(name, "/")))
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("'%s' reaches outside '%s'", name, dir)
+	}
+	return filepath.Join(memPath(dir), rel), nil
+}
@@ -34,6 +34,13 @@ func MakeRealFS() FileSystem {
 	return realFS{}
 }
 
+// IsRealFS returns true if fSys is the local
+// filesystem, as made by MakeRealFS.
+func IsRealFS(fSys FileSystem) bool {
+	_, ok := fSys.(realFS)
+	return ok
+}
+
 // Create delegates to os.Create.
 func (realFS) Create(name string) (File, error) { return os.Create(name) }
 
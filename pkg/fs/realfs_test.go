@@ -175,3 +175,12 @@ func TestReadFilesRealFS(t *testing.T) {
 		t.Fatalf("incorrect files found by glob: %v", files)
 	}
 }
+
+func TestIsRealFS(t *testing.T) {
+	if !IsRealFS(MakeRealFS()) {
+		t.Fatalf("expected the real filesystem")
+	}
+	if IsRealFS(MakeMemFS()) || IsRealFS(MakeFakeFS()) {
+		t.Fatalf("expected only the real filesystem")
+	}
+}
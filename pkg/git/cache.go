@@ -0,0 +1,533 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
+	"github.com/pkg/errors"
+)
+
+const (
+	cacheCommitsDir = "commits"
+	cacheRefsDir    = "refs"
+	cacheStagingDir = "tmp"
+)
+
+var (
+	fullShaRegex   = regexp.MustCompile("^[0-9a-f]{40}$")
+	abbrevShaRegex = regexp.MustCompile("^[0-9a-f]{7,40}$")
+)
+
+// DefaultCacheDir returns where remote bases are cached
+// unless told otherwise.
+func DefaultCacheDir() string {
+	return filepath.Join(pgmconfig.CacheRoot(), "git")
+}
+
+// RefResolver returns the commit that a repoSpec's ref
+// currently names, without cloning the repo.  It returns
+// the empty string if it cannot tell.
+type RefResolver func(repoSpec *RepoSpec) (string, error)
+
+// Cache is a persistent, on-disk store of checked out
+// repositories, keyed by host, repository and commit.
+//
+// A commit's content never changes, so a checkout found
+// in the cache is used as is.  Branches and tags are
+// resolved to commits on every use, unless the cache is
+// offline, in which case the commit they named when last
+// resolved is used.
+type Cache struct {
+	root    string
+	offline bool
+}
+
+// NewCache returns a cache rooted at the given directory.
+// An offline cache never goes to the network, and fails
+// to clone repos it doesn't hold.
+func NewCache(root string, offline bool) *Cache {
+	return &Cache{root: root, offline: offline}
+}
+
+// Root returns the directory holding the cache.
+func (c *Cache) Root() string {
+	return c.root
+}
+
+// CacheEntry describes one cached checkout.
+type CacheEntry struct {
+	// Repo is the host and repository, e.g.
+	// github.com/kubernetes-sigs/kustomize
+	Repo string
+	// Commit checked out.
+	Commit string
+	// Dir holding the checkout.
+	Dir string
+	// Refs last known to name the commit.
+	Refs []string
+	// Size of the checkout in bytes.
+	Size int64
+	// LastUsed is when a build last used the checkout.
+	LastUsed time.Time
+}
+
+// Cloner returns a Cloner that takes checkouts from
+// the cache, using the delegate to fill it on a miss.
+// The resolver finds what commit a branch or tag names.
+func (c *Cache) Cloner(delegate Cloner, resolve RefResolver) Cloner {
+	return func(repoSpec *RepoSpec) error {
+		if repoSpec.Ref == "" {
+			repoSpec.Ref = defaultRef
+		}
+		repoDir, err := c.repoDir(repoSpec)
+		if err != nil {
+			return err
+		}
+		commit, err := c.resolve(repoDir, repoSpec, resolve)
+		if err != nil {
+			return err
+		}
+		if commit != "" {
+			dir := filepath.Join(repoDir, cacheCommitsDir, commit)
+			if isDir(dir) {
+				return c.useCheckout(repoSpec, repoDir, commit)
+			}
+		}
+		if c.offline {
+			return fmt.Errorf(
+				"offline, and no cached checkout of %s at %s in %s",
+				repoSpec.CloneSpec(), repoSpec.Ref, c.root)
+		}
+		err = delegate(repoSpec)
+		if err != nil {
+			return err
+		}
+		checkedOut := repoSpec.commit
+		if checkedOut == "" {
+			checkedOut, err = headCommit(repoSpec.Dir.String())
+			if err != nil {
+				return err
+			}
+		}
+		if commit != "" && checkedOut != commit {
+			// The ref moved between resolving and cloning.
+			// Build from the fresh checkout, but don't cache
+			// it, lest the ref be recorded against the wrong
+			// commit.
+			return nil
+		}
+		err = c.store(repoSpec.Dir.String(), repoDir, checkedOut)
+		if err != nil {
+			return err
+		}
+		return c.useCheckout(repoSpec, repoDir, checkedOut)
+	}
+}
+
+// resolve returns the commit the repoSpec's ref names,
+// or the empty string if it's unknown.
+func (c *Cache) resolve(
+	repoDir string, repoSpec *RepoSpec, resolve RefResolver) (string, error) {
+	if fullShaRegex.MatchString(repoSpec.Ref) {
+		return repoSpec.Ref, nil
+	}
+	if c.offline || abbrevShaRegex.MatchString(repoSpec.Ref) {
+		// Abbreviated commits are as good as
+		// full ones, once they've been resolved.
+		b, err := ioutil.ReadFile(c.refFile(repoDir, repoSpec.Ref))
+		if err == nil || c.offline {
+			return strings.TrimSpace(string(b)), nil
+		}
+		return "", nil
+	}
+	commit, err := resolve(repoSpec)
+	if err != nil {
+		return "", errors.Wrapf(
+			err, "resolving %s at %s", repoSpec.CloneSpec(), repoSpec.Ref)
+	}
+	return commit, nil
+}
+
+func (c *Cache) refFile(repoDir, ref string) string {
+	return filepath.Join(repoDir, cacheRefsDir, url.PathEscape(ref))
+}
+
+// useCheckout points the repoSpec at a cached checkout,
+// and notes the use so that the checkout isn't pruned.
+func (c *Cache) useCheckout(
+	repoSpec *RepoSpec, repoDir, commit string) error {
+	dir := filepath.Join(repoDir, cacheCommitsDir, commit)
+	now := time.Now()
+	err := os.Chtimes(dir, now, now)
+	if err != nil {
+		return err
+	}
+	if !fullShaRegex.MatchString(repoSpec.Ref) {
+		err = writeFileAtomically(
+			c.refFile(repoDir, repoSpec.Ref), []byte(commit+"\n"))
+		if err != nil {
+			return err
+		}
+	}
+	repoSpec.Dir = fs.ConfirmedDir(dir)
+	repoSpec.cached = true
+	return nil
+}
+
+// store moves a fresh checkout into the cache.
+func (c *Cache) store(src, repoDir, commit string) error {
+	err := os.RemoveAll(filepath.Join(src, ".git"))
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(repoDir, cacheCommitsDir, commit)
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+	if os.Rename(src, dst) == nil {
+		return nil
+	}
+	// The checkout may be on another device, or another
+	// build may have cached the same commit meanwhile.
+	defer os.RemoveAll(src)
+	if isDir(dst) {
+		return nil
+	}
+	staging, err := c.stagingDir()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	tmp := filepath.Join(staging, commit)
+	err = copyDir(src, tmp)
+	if err != nil {
+		return errors.Wrapf(err, "caching %s", src)
+	}
+	err = os.Rename(tmp, dst)
+	if err != nil && !isDir(dst) {
+		return err
+	}
+	return nil
+}
+
+func (c *Cache) stagingDir() (string, error) {
+	dir := filepath.Join(c.root, cacheStagingDir)
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	return ioutil.TempDir(dir, "")
+}
+
+// repoDir returns the directory holding the
+// checkouts of the repoSpec's repository.
+func (c *Cache) repoDir(repoSpec *RepoSpec) (string, error) {
+	if c.root == "" {
+		return "", fmt.Errorf("no cache directory specified")
+	}
+	return filepath.Join(c.root, repoKey(repoSpec)), nil
+}
+
+// repoKey turns the host and repository into a relative
+// path.  The scheme is dropped; a commit has the same
+// content no matter how it was fetched.
+func repoKey(repoSpec *RepoSpec) string {
+	host := repoSpec.Host
+	for _, p := range []string{
+		"git::", "gh:", "ssh://", "https://", "http://", "file://", "git@"} {
+		host = strings.TrimPrefix(host, p)
+	}
+	host = strings.Trim(host, "/:")
+	if host == "" {
+		host = "local"
+	}
+	var elems []string
+	path := host + "/" + strings.TrimSuffix(repoSpec.OrgRepo, gitSuffix)
+	for _, e := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '\\'
+	}) {
+		if e == "." || e == ".." {
+			e = "_"
+		}
+		elems = append(elems, e)
+	}
+	return filepath.Join(elems...)
+}
+
+// Entries lists the cached checkouts, ordered by repo and commit.
+func (c *Cache) Entries() ([]CacheEntry, error) {
+	var result []CacheEntry
+	if !isDir(c.root) {
+		return result, nil
+	}
+	err := filepath.Walk(c.root, func(
+		path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path == filepath.Join(c.root, cacheStagingDir) {
+			return filepath.SkipDir
+		}
+		if info.Name() != cacheCommitsDir {
+			return nil
+		}
+		entries, err := c.entriesIn(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		result = append(result, entries...)
+		return filepath.SkipDir
+	})
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Repo != result[j].Repo {
+			return result[i].Repo < result[j].Repo
+		}
+		return result[i].Commit < result[j].Commit
+	})
+	return result, err
+}
+
+func (c *Cache) entriesIn(repoDir string) ([]CacheEntry, error) {
+	repo, err := filepath.Rel(c.root, repoDir)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := readRefs(filepath.Join(repoDir, cacheRefsDir))
+	if err != nil {
+		return nil, err
+	}
+	infos, err := ioutil.ReadDir(filepath.Join(repoDir, cacheCommitsDir))
+	if err != nil {
+		return nil, err
+	}
+	var result []CacheEntry
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		dir := filepath.Join(repoDir, cacheCommitsDir, info.Name())
+		size, err := dirSize(dir)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, CacheEntry{
+			Repo:     filepath.ToSlash(repo),
+			Commit:   info.Name(),
+			Dir:      dir,
+			Refs:     refs[info.Name()],
+			Size:     size,
+			LastUsed: info.ModTime(),
+		})
+	}
+	return result, nil
+}
+
+// readRefs maps commits to the refs that name them.
+func readRefs(dir string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		b, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		ref, err := url.PathUnescape(info.Name())
+		if err != nil {
+			continue
+		}
+		commit := strings.TrimSpace(string(b))
+		result[commit] = append(result[commit], ref)
+	}
+	return result, nil
+}
+
+// Prune removes the checkouts not used since the given
+// time, along with the refs naming them, and returns
+// what it removed.
+func (c *Cache) Prune(unusedSince time.Time) ([]CacheEntry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var result []CacheEntry
+	for _, e := range entries {
+		if !e.LastUsed.Before(unusedSince) {
+			continue
+		}
+		err = os.RemoveAll(e.Dir)
+		if err != nil {
+			return result, err
+		}
+		repoDir := filepath.Dir(filepath.Dir(e.Dir))
+		for _, ref := range e.Refs {
+			err = os.Remove(c.refFile(repoDir, ref))
+			if err != nil && !os.IsNotExist(err) {
+				return result, err
+			}
+		}
+		result = append(result, e)
+	}
+	return result, os.RemoveAll(filepath.Join(c.root, cacheStagingDir))
+}
+
+// ResolveRefUsingGitExec asks the remote, using a local
+// git install, what commit the repoSpec's ref names.
+func ResolveRefUsingGitExec(repoSpec *RepoSpec) (string, error) {
+	gitProgram, err := exec.LookPath("git")
+	if err != nil {
+		return "", errors.Wrap(err, "no 'git' program on path")
+	}
+	cmd := exec.Command(
+		gitProgram, "ls-remote", repoSpec.CloneSpec(), repoSpec.Ref)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if err != nil {
+		return "", errors.Wrapf(err, "git ls-remote: %s", stderr.String())
+	}
+	return commitFromLsRemote(out.String(), repoSpec.Ref), nil
+}
+
+// commitFromLsRemote picks the commit that the ref names
+// from the output of git ls-remote, preferring the commit
+// an annotated tag points to over the tag itself.
+func commitFromLsRemote(out, ref string) string {
+	found := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			found[fields[1]] = fields[0]
+		}
+	}
+	for _, name := range []string{
+		"refs/tags/" + ref + "^{}", "refs/tags/" + ref,
+		"refs/heads/" + ref, ref} {
+		if commit, ok := found[name]; ok {
+			return commit
+		}
+	}
+	return ""
+}
+
+// headCommit reads the commit checked out in a
+// repository, without needing a git install.
+func headCommit(dir string) (string, error) {
+	r, err := openLocalRepo(dir)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading commit of %s", dir)
+	}
+	h, err := r.resolve("HEAD")
+	if err != nil {
+		return "", errors.Wrapf(err, "reading commit of %s", dir)
+	}
+	return h.String(), nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(
+		_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+func writeFileAtomically(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func copyDir(src, dst string) error {
+	return filepath.Walk(src, func(
+		path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,277 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+)
+
+const (
+	commitA = "1111111111111111111111111111111111111111"
+	commitB = "2222222222222222222222222222222222222222"
+)
+
+// fakeRemote stands in for a remote repo, whose
+// branches are moved by changing the refs map.
+// Refs in moved name other commits by the time
+// they're cloned than when they're resolved.
+type fakeRemote struct {
+	t      *testing.T
+	refs   map[string]string
+	moved  map[string]string
+	clones int
+}
+
+func (r *fakeRemote) clone(repoSpec *RepoSpec) error {
+	r.clones++
+	dir, err := ioutil.TempDir("", "kustomize-cache-test-clone")
+	if err != nil {
+		return err
+	}
+	commit := r.refs[repoSpec.Ref]
+	if moved, ok := r.moved[repoSpec.Ref]; ok {
+		commit = moved
+	}
+	if commit == "" {
+		commit = repoSpec.Ref
+	}
+	writeTestFile(r.t, filepath.Join(dir, ".git", "HEAD"), commit+"\n")
+	writeTestFile(r.t, filepath.Join(dir, "base", "kustomization.yaml"),
+		"namePrefix: "+commit[:4]+"-\n")
+	repoSpec.Dir = fs.ConfirmedDir(dir)
+	return nil
+}
+
+func (r *fakeRemote) resolve(repoSpec *RepoSpec) (string, error) {
+	return r.refs[repoSpec.Ref], nil
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func makeTestCache(t *testing.T) (*Cache, func()) {
+	root, err := ioutil.TempDir("", "kustomize-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCache(root, false), func() { os.RemoveAll(root) }
+}
+
+func cloneAndRead(t *testing.T, cloner Cloner, url string) string {
+	repoSpec, err := NewRepoSpecFromUrl(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cloner(repoSpec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadFile(
+		filepath.Join(repoSpec.AbsPath(), "kustomization.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The loader cleans up after every build;
+	// cached checkouts must survive that.
+	err = repoSpec.Cleaner(fs.MakeRealFS())()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestCacheCloner(t *testing.T) {
+	c, cleanup := makeTestCache(t)
+	defer cleanup()
+	remote := &fakeRemote{t: t, refs: map[string]string{"master": commitA}}
+	cloner := c.Cloner(remote.clone, remote.resolve)
+	url := "github.com/someOrg/someRepo/base?ref=master"
+
+	if got := cloneAndRead(t, cloner, url); got != "namePrefix: 1111-\n" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if got := cloneAndRead(t, cloner, url); got != "namePrefix: 1111-\n" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if remote.clones != 1 {
+		t.Fatalf("expected 1 clone, got %d", remote.clones)
+	}
+
+	// Moving the branch means a new clone.
+	remote.refs["master"] = commitB
+	if got := cloneAndRead(t, cloner, url); got != "namePrefix: 2222-\n" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	// Commits needn't be resolved.
+	got := cloneAndRead(
+		t, cloner, "github.com/someOrg/someRepo/base?ref="+commitA)
+	if got != "namePrefix: 1111-\n" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if remote.clones != 2 {
+		t.Fatalf("expected 2 clones, got %d", remote.clones)
+	}
+
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var summary []string
+	for _, e := range entries {
+		summary = append(summary,
+			e.Repo+" "+e.Commit[:4]+" "+strings.Join(e.Refs, ","))
+		if _, err := os.Stat(filepath.Join(e.Dir, ".git")); err == nil {
+			t.Fatalf("expected git metadata to be dropped from %s", e.Dir)
+		}
+	}
+	expected := []string{
+		"github.com/someOrg/someRepo 1111 ",
+		"github.com/someOrg/someRepo 2222 master",
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Fatalf("expected %v, got %v", expected, summary)
+	}
+}
+
+func TestCacheClonerRefMovedWhileCloning(t *testing.T) {
+	c, cleanup := makeTestCache(t)
+	defer cleanup()
+	remote := &fakeRemote{
+		t:     t,
+		refs:  map[string]string{"master": commitA},
+		moved: map[string]string{"master": commitB},
+	}
+	cloner := c.Cloner(remote.clone, remote.resolve)
+	url := "github.com/someOrg/someRepo/base?ref=master"
+
+	// The build uses what was checked out...
+	if got := cloneAndRead(t, cloner, url); got != "namePrefix: 2222-\n" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	// ...but nothing is cached under either commit.
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no cache entries, got %v", entries)
+	}
+	if got := cloneAndRead(t, cloner, url); got != "namePrefix: 2222-\n" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if remote.clones != 2 {
+		t.Fatalf("expected 2 clones, got %d", remote.clones)
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+	c, cleanup := makeTestCache(t)
+	defer cleanup()
+	remote := &fakeRemote{t: t, refs: map[string]string{"v1": commitA}}
+	url := "github.com/someOrg/someRepo/base?ref=v1"
+	cloneAndRead(t, c.Cloner(remote.clone, remote.resolve), url)
+
+	offline := NewCache(c.Root(), true).Cloner(
+		func(*RepoSpec) error {
+			t.Fatalf("offline cache must not clone")
+			return nil
+		},
+		func(*RepoSpec) (string, error) {
+			t.Fatalf("offline cache must not resolve refs")
+			return "", nil
+		})
+	if got := cloneAndRead(t, offline, url); got != "namePrefix: 1111-\n" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	repoSpec, _ := NewRepoSpecFromUrl(
+		"github.com/someOrg/someRepo/base?ref=v2")
+	err := offline(repoSpec)
+	if err == nil || !strings.Contains(err.Error(), "offline") {
+		t.Fatalf("expected offline error, got %v", err)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	c, cleanup := makeTestCache(t)
+	defer cleanup()
+	remote := &fakeRemote{t: t, refs: map[string]string{
+		"old": commitA, "new": commitB}}
+	cloner := c.Cloner(remote.clone, remote.resolve)
+	cloneAndRead(t, cloner, "github.com/someOrg/someRepo/base?ref=old")
+	cloneAndRead(t, cloner, "github.com/someOrg/someRepo/base?ref=new")
+
+	entries, _ := c.Entries()
+	old := time.Now().Add(-48 * time.Hour)
+	for _, e := range entries {
+		if e.Commit == commitA {
+			os.Chtimes(e.Dir, old, old)
+		}
+	}
+	removed, err := c.Prune(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(removed) != 1 || removed[0].Commit != commitA {
+		t.Fatalf("unexpected removal %v", removed)
+	}
+	entries, _ = c.Entries()
+	if len(entries) != 1 || entries[0].Commit != commitB {
+		t.Fatalf("unexpected entries %v", entries)
+	}
+	// The ref naming the pruned commit is gone too,
+	// so it's resolved and cloned again.
+	cloneAndRead(t, cloner, "github.com/someOrg/someRepo/base?ref=old")
+	if remote.clones != 3 {
+		t.Fatalf("expected 3 clones, got %d", remote.clones)
+	}
+}
+
+func TestRepoKey(t *testing.T) {
+	var cases = []struct {
+		url      string
+		expected string
+	}{
+		{"github.com/someOrg/someRepo/base", "github.com/someOrg/someRepo"},
+		{"git@github.com:someOrg/someRepo.git//base", "github.com/someOrg/someRepo"},
+		{"https://example.com/../../etc/repo.git", "example.com/_/_/etc/repo"},
+	}
+	for _, c := range cases {
+		repoSpec, err := NewRepoSpecFromUrl(c.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filepath.ToSlash(repoKey(repoSpec)); got != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.url, c.expected, got)
+		}
+	}
+}
+
+func TestCommitFromLsRemote(t *testing.T) {
+	out := commitA + "\trefs/tags/v1\n" +
+		commitB + "\trefs/tags/v1^{}\n" +
+		commitA + "\trefs/heads/master\n"
+	if got := commitFromLsRemote(out, "v1"); got != commitB {
+		t.Errorf("expected peeled tag commit, got %s", got)
+	}
+	if got := commitFromLsRemote(out, "master"); got != commitA {
+		t.Errorf("expected branch commit, got %s", got)
+	}
+	if got := commitFromLsRemote(out, "nope"); got != "" {
+		t.Errorf("expected nothing, got %s", got)
+	}
+}
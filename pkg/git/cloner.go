@@ -28,6 +28,9 @@ import (
 // Cloner is a function that can clone a git repo.
 type Cloner func(repoSpec *RepoSpec) error
 
+// The ref cloned if a repoSpec doesn't name one.
+const defaultRef = "master"
+
 // ClonerUsingGitExec uses a local git install, as opposed
 // to say, some remote API, to obtain a local clone of
 // a remote repo.
@@ -74,7 +77,7 @@ func ClonerUsingGitExec(repoSpec *RepoSpec) error {
 			repoSpec.CloneSpec())
 	}
 	if repoSpec.Ref == "" {
-		repoSpec.Ref = "master"
+		repoSpec.Ref = defaultRef
 	}
 	cmd = exec.Command(
 		gitProgram,
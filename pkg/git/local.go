@@ -0,0 +1,229 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// localRepo reads objects and refs straight
+// from a repository on local disk.
+type localRepo struct {
+	gitDir     string
+	objectDirs []string
+	packs      []*indexedPack
+}
+
+// openLocalRepo opens the repository at the given path,
+// which may be a bare repository or a working tree.
+func openLocalRepo(path string) (*localRepo, error) {
+	gitDir, err := findGitDir(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &localRepo{gitDir: gitDir}
+	err = r.addObjectDir(filepath.Join(gitDir, "objects"), 0)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func findGitDir(path string) (string, error) {
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	if err == nil && info.IsDir() {
+		return dotGit, nil
+	}
+	if err == nil {
+		// A worktree or submodule, pointing elsewhere.
+		b, err := ioutil.ReadFile(dotGit)
+		if err != nil {
+			return "", err
+		}
+		dir := strings.TrimSpace(strings.TrimPrefix(string(b), "gitdir:"))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(path, dir)
+		}
+		return dir, nil
+	}
+	if isDir(filepath.Join(path, "objects")) &&
+		fileExists(filepath.Join(path, "HEAD")) {
+		return path, nil
+	}
+	return "", fmt.Errorf("'%s' is not a git repository", path)
+}
+
+// addObjectDir adds a directory of loose objects and
+// packs, and those it borrows from via alternates.
+func (r *localRepo) addObjectDir(dir string, depth int) error {
+	if depth > 5 {
+		return fmt.Errorf("too many nested alternates at %s", dir)
+	}
+	r.objectDirs = append(r.objectDirs, dir)
+	idxs, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+	if err != nil {
+		return err
+	}
+	for _, idx := range idxs {
+		p, err := readPackIndex(idx, strings.TrimSuffix(idx, ".idx")+".pack")
+		if err != nil {
+			return err
+		}
+		r.packs = append(r.packs, p)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "info", "alternates"))
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, line)
+		}
+		if err := r.addObjectDir(line, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *localRepo) readObject(h hash) (*object, error) {
+	s := h.String()
+	for _, dir := range r.objectDirs {
+		b, err := ioutil.ReadFile(filepath.Join(dir, s[:2], s[2:]))
+		if err == nil {
+			return parseLooseObject(b)
+		}
+	}
+	for _, p := range r.packs {
+		if offset, ok := p.find(h); ok {
+			return p.readObjectAt(offset, r)
+		}
+	}
+	return nil, fmt.Errorf("object %s not found in %s", h, r.gitDir)
+}
+
+func parseLooseObject(b []byte) (*object, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	nul := bytes.IndexByte(data, 0)
+	sp := bytes.IndexByte(data, ' ')
+	if nul < 0 || sp < 0 || sp > nul {
+		return nil, fmt.Errorf("malformed loose object")
+	}
+	t, err := parseObjectType(string(data[:sp]))
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.Atoi(string(data[sp+1 : nul]))
+	if err != nil || size != len(data)-nul-1 {
+		return nil, fmt.Errorf("malformed loose object")
+	}
+	return &object{typ: t, data: data[nul+1:]}, nil
+}
+
+// resolve finds the object a ref names, trying the
+// same places, in the same order, as git rev-parse.
+func (r *localRepo) resolve(ref string) (hash, error) {
+	if fullShaRegex.MatchString(ref) {
+		return parseHash(ref)
+	}
+	for _, name := range []string{
+		ref, "refs/" + ref, "refs/tags/" + ref,
+		"refs/heads/" + ref, "refs/remotes/" + ref} {
+		h, ok, err := r.readRef(name, 0)
+		if err != nil {
+			return h, err
+		}
+		if ok {
+			return h, nil
+		}
+	}
+	if abbrevShaRegex.MatchString(ref) {
+		return r.resolveAbbrev(ref)
+	}
+	return hash{}, fmt.Errorf("no ref '%s' in %s", ref, r.gitDir)
+}
+
+func (r *localRepo) readRef(name string, depth int) (hash, bool, error) {
+	if depth > 5 {
+		return hash{}, false, fmt.Errorf("ref '%s' nests too deeply", name)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(r.gitDir, filepath.FromSlash(name)))
+	if err == nil {
+		s := strings.TrimSpace(string(b))
+		if strings.HasPrefix(s, "ref: ") {
+			return r.readRef(strings.TrimPrefix(s, "ref: "), depth+1)
+		}
+		h, err := parseHash(s)
+		return h, err == nil, nil
+	}
+	f, err := os.Open(filepath.Join(r.gitDir, "packed-refs"))
+	if err != nil {
+		return hash{}, false, nil
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == name {
+			h, err := parseHash(fields[0])
+			return h, err == nil, err
+		}
+	}
+	return hash{}, false, scanner.Err()
+}
+
+// resolveAbbrev finds the one object whose
+// name starts with the given prefix.
+func (r *localRepo) resolveAbbrev(prefix string) (hash, error) {
+	found := map[hash]bool{}
+	for _, dir := range r.objectDirs {
+		names, _ := filepath.Glob(filepath.Join(dir, prefix[:2], prefix[2:]+"*"))
+		for _, n := range names {
+			if h, err := parseHash(prefix[:2] + filepath.Base(n)); err == nil {
+				found[h] = true
+			}
+		}
+	}
+	for _, p := range r.packs {
+		for _, h := range p.hashes {
+			if strings.HasPrefix(h.String(), prefix) {
+				found[h] = true
+			}
+		}
+	}
+	if len(found) != 1 {
+		return hash{}, fmt.Errorf(
+			"'%s' names %d objects in %s", prefix, len(found), r.gitDir)
+	}
+	for h := range found {
+		return h, nil
+	}
+	return hash{}, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"sigs.k8s.io/yaml"
+)
+
+// LockFileName is the name of the file, next to a
+// kustomization file, that pins the kustomization's
+// remote bases to commits.
+const LockFileName = "kustomization.lock"
+
+const lockFileHeader = "# Generated by 'kustomize edit lock'; do not edit.\n"
+
+// Lock pins remote bases, identified by the url a
+// kustomization uses for them, to commits.
+type Lock struct {
+	Remotes []LockedRemote `json:"remotes,omitempty" yaml:"remotes,omitempty"`
+}
+
+// LockedRemote pins one remote base to a commit.
+type LockedRemote struct {
+	Url    string `json:"url" yaml:"url"`
+	Commit string `json:"commit" yaml:"commit"`
+}
+
+// ReadLock reads the lock file in the given directory,
+// returning nil if there isn't one.
+func ReadLock(fSys fs.FileSystem, dir string) (*Lock, error) {
+	path := filepath.Join(dir, LockFileName)
+	if !fSys.Exists(path) {
+		return nil, nil
+	}
+	b, err := fSys.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var l Lock
+	if err = yaml.Unmarshal(b, &l); err != nil {
+		return nil, fmt.Errorf("malformed %s: %v", path, err)
+	}
+	for _, r := range l.Remotes {
+		if !fullShaRegex.MatchString(r.Commit) {
+			return nil, fmt.Errorf(
+				"malformed %s: '%s' is not a full commit hash for %s",
+				path, r.Commit, r.Url)
+		}
+	}
+	return &l, nil
+}
+
+// Write writes the lock file in the given directory.
+func (l *Lock) Write(fSys fs.FileSystem, dir string) error {
+	sort.Slice(l.Remotes, func(i, j int) bool {
+		return l.Remotes[i].Url < l.Remotes[j].Url
+	})
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return fSys.WriteFile(
+		filepath.Join(dir, LockFileName), append([]byte(lockFileHeader), b...))
+}
+
+// Commit returns the commit the url is pinned to.
+func (l *Lock) Commit(url string) (string, bool) {
+	for _, r := range l.Remotes {
+		if r.Url == url {
+			return r.Commit, true
+		}
+	}
+	return "", false
+}
+
+// Set pins the url to the commit.
+func (l *Lock) Set(url, commit string) {
+	for i := range l.Remotes {
+		if l.Remotes[i].Url == url {
+			l.Remotes[i].Commit = commit
+			return
+		}
+	}
+	l.Remotes = append(l.Remotes, LockedRemote{Url: url, Commit: commit})
+}
+
+// IsCommit is true if the ref is a full commit hash,
+// i.e. a ref that can't drift.
+func IsCommit(ref string) bool {
+	return fullShaRegex.MatchString(ref)
+}
@@ -0,0 +1,242 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The git object model, as much of it as
+// is needed to check out a commit.
+
+type objectType int8
+
+const (
+	objCommit   objectType = 1
+	objTree     objectType = 2
+	objBlob     objectType = 3
+	objTag      objectType = 4
+	objOfsDelta objectType = 6
+	objRefDelta objectType = 7
+)
+
+func (t objectType) String() string {
+	switch t {
+	case objCommit:
+		return "commit"
+	case objTree:
+		return "tree"
+	case objBlob:
+		return "blob"
+	case objTag:
+		return "tag"
+	}
+	return "unknown(" + strconv.Itoa(int(t)) + ")"
+}
+
+func parseObjectType(s string) (objectType, error) {
+	for _, t := range []objectType{objCommit, objTree, objBlob, objTag} {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown object type '%s'", s)
+}
+
+type hash [20]byte
+
+func (h hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+func parseHash(s string) (hash, error) {
+	var h hash
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(h) {
+		return h, fmt.Errorf("'%s' is not a commit hash", s)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// hashObject returns the name git gives the object.
+func hashObject(t objectType, data []byte) hash {
+	s := sha1.New()
+	fmt.Fprintf(s, "%s %d\x00", t, len(data))
+	s.Write(data)
+	var h hash
+	copy(h[:], s.Sum(nil))
+	return h
+}
+
+type object struct {
+	typ  objectType
+	data []byte
+}
+
+// objectStore is somewhere objects can be read from.
+type objectStore interface {
+	readObject(h hash) (*object, error)
+}
+
+// peelToCommit follows tags until it reaches a commit.
+func peelToCommit(store objectStore, h hash) (hash, *object, error) {
+	for {
+		obj, err := store.readObject(h)
+		if err != nil {
+			return h, nil, err
+		}
+		switch obj.typ {
+		case objCommit:
+			return h, obj, nil
+		case objTag:
+			h, err = headerHash(obj.data, "object")
+			if err != nil {
+				return h, nil, err
+			}
+		default:
+			return h, nil, fmt.Errorf("%s is a %s, not a commit", h, obj.typ)
+		}
+	}
+}
+
+// headerHash returns the hash in a header line of a
+// commit or tag, e.g. the "tree" line of a commit.
+func headerHash(data []byte, key string) (hash, error) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, key+" ") {
+			return parseHash(strings.TrimPrefix(line, key+" "))
+		}
+	}
+	return hash{}, fmt.Errorf("no %s in object", key)
+}
+
+type treeEntry struct {
+	mode string
+	name string
+	hash hash
+}
+
+func parseTree(data []byte) ([]treeEntry, error) {
+	var result []treeEntry
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+21 {
+			return nil, fmt.Errorf("malformed tree")
+		}
+		e := treeEntry{mode: string(data[:sp]), name: string(data[sp+1 : nul])}
+		copy(e.hash[:], data[nul+1:nul+21])
+		result = append(result, e)
+		data = data[nul+21:]
+	}
+	return result, nil
+}
+
+// gitlink is a submodule commit recorded in a tree.
+type gitlink struct {
+	path   string
+	commit hash
+}
+
+// checkoutTree writes the tree's files into dir,
+// returning the submodule commits it holds.
+func checkoutTree(
+	store objectStore, tree hash, dir string) ([]gitlink, error) {
+	var links []gitlink
+	err := checkoutTreeAt(store, tree, dir, "", &links)
+	return links, err
+}
+
+func checkoutTreeAt(
+	store objectStore, tree hash,
+	dir, prefix string, links *[]gitlink) error {
+	obj, err := store.readObject(tree)
+	if err != nil {
+		return err
+	}
+	if obj.typ != objTree {
+		return fmt.Errorf("%s is a %s, not a tree", tree, obj.typ)
+	}
+	entries, err := parseTree(obj.data)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !isSafeTreeEntryName(e.name) {
+			return fmt.Errorf("refusing to check out '%s'", prefix+e.name)
+		}
+		path := filepath.Join(dir, e.name)
+		switch e.mode {
+		case "40000":
+			err = os.Mkdir(path, 0755)
+			if err == nil {
+				err = checkoutTreeAt(store, e.hash, path, prefix+e.name+"/", links)
+			}
+		case "160000":
+			err = os.Mkdir(path, 0755)
+			*links = append(*links, gitlink{path: prefix + e.name, commit: e.hash})
+		case "120000":
+			err = checkoutBlob(store, e.hash, func(target []byte) error {
+				return os.Symlink(string(target), path)
+			})
+		case "100755", "100644", "100664":
+			mode := os.FileMode(0644)
+			if e.mode == "100755" {
+				mode = 0755
+			}
+			err = checkoutBlob(store, e.hash, func(content []byte) error {
+				return writeNewFile(path, content, mode)
+			})
+		default:
+			err = fmt.Errorf("unknown mode %s of '%s'", e.mode, prefix+e.name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkoutBlob(
+	store objectStore, h hash, write func([]byte) error) error {
+	obj, err := store.readObject(h)
+	if err != nil {
+		return err
+	}
+	if obj.typ != objBlob {
+		return fmt.Errorf("%s is a %s, not a blob", h, obj.typ)
+	}
+	return write(obj.data)
+}
+
+// isSafeTreeEntryName rejects names that would put
+// files outside the checkout, or into its git metadata.
+func isSafeTreeEntryName(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		!strings.ContainsAny(name, "/\\\x00") &&
+		!strings.EqualFold(name, ".git")
+}
+
+func writeNewFile(path string, content []byte, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
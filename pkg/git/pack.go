@@ -0,0 +1,355 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// Packfiles, as fetched from a remote and
+// as found, with an index, in a local repo.
+// See Documentation/technical/pack-format.txt in git.
+
+var packSignature = []byte("PACK")
+
+// readEntryHeader reads the type and inflated
+// size at the start of a packed object.
+func readEntryHeader(r io.ByteReader) (objectType, int64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	t := objectType((b >> 4) & 7)
+	size := int64(b & 15)
+	for shift := uint(4); b&0x80 != 0; shift += 7 {
+		b, err = r.ReadByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		size |= int64(b&0x7f) << shift
+	}
+	return t, size, nil
+}
+
+// readOfsDeltaOffset reads how far before a delta its base is.
+func readOfsDeltaOffset(r io.ByteReader) (int64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	ofs := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		b, err = r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		ofs = ((ofs + 1) << 7) | int64(b&0x7f)
+	}
+	return ofs, nil
+}
+
+// inflate reads one zlib stream of known inflated size.
+// Given an io.ByteReader, it reads no further than the
+// end of the stream.
+func inflate(r io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data := make([]byte, size)
+	_, err = io.ReadFull(zr, data)
+	if err != nil {
+		return nil, err
+	}
+	// Reach the end of the stream, to consume its checksum.
+	n, err := io.Copy(ioutil.Discard, zr)
+	if err != nil {
+		return nil, err
+	}
+	if n != 0 {
+		return nil, fmt.Errorf("packed object larger than its header says")
+	}
+	return data, nil
+}
+
+// applyDelta makes an object from its base and a delta.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	r := bytes.NewReader(delta)
+	srcSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if srcSize != uint64(len(base)) {
+		return nil, fmt.Errorf("delta base has wrong size")
+	}
+	dstSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, 0, dstSize)
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+		switch {
+		case op&0x80 != 0:
+			var offset, size uint64
+			for i := uint(0); i < 7; i++ {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				b, err := r.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+				if i < 4 {
+					offset |= uint64(b) << (8 * i)
+				} else {
+					size |= uint64(b) << (8 * (i - 4))
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, fmt.Errorf("delta copies beyond its base")
+			}
+			result = append(result, base[offset:offset+size]...)
+		case op != 0:
+			b := make([]byte, op)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, err
+			}
+			result = append(result, b...)
+		default:
+			return nil, fmt.Errorf("bad delta instruction")
+		}
+	}
+	if uint64(len(result)) != dstSize {
+		return nil, fmt.Errorf("delta result has wrong size")
+	}
+	return result, nil
+}
+
+// memoryStore holds the objects of a fetched pack.
+type memoryStore map[hash]*object
+
+func (m memoryStore) readObject(h hash) (*object, error) {
+	obj, ok := m[h]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found in fetched pack", h)
+	}
+	return obj, nil
+}
+
+type packEntry struct {
+	object
+	baseOfs  int64
+	baseHash hash
+	resolved bool
+}
+
+// parsePack reads all the objects in a pack,
+// resolving deltas against their bases.
+func parsePack(data []byte) (memoryStore, error) {
+	if len(data) < 12 || !bytes.Equal(data[:4], packSignature) {
+		return nil, fmt.Errorf("not a git pack")
+	}
+	if v := binary.BigEndian.Uint32(data[4:8]); v != 2 && v != 3 {
+		return nil, fmt.Errorf("unsupported pack version %d", v)
+	}
+	count := binary.BigEndian.Uint32(data[8:12])
+	r := bytes.NewReader(data)
+	r.Seek(12, io.SeekStart)
+	entries := make(map[int64]*packEntry, count)
+	offsets := make([]int64, 0, count)
+	for i := uint32(0); i < count; i++ {
+		offset := int64(len(data) - r.Len())
+		e := &packEntry{}
+		t, size, err := readEntryHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		e.typ = t
+		switch t {
+		case objOfsDelta:
+			rel, err := readOfsDeltaOffset(r)
+			if err != nil {
+				return nil, err
+			}
+			e.baseOfs = offset - rel
+		case objRefDelta:
+			if _, err := io.ReadFull(r, e.baseHash[:]); err != nil {
+				return nil, err
+			}
+		}
+		e.data, err = inflate(r, size)
+		if err != nil {
+			return nil, err
+		}
+		e.resolved = t != objOfsDelta && t != objRefDelta
+		entries[offset] = e
+		offsets = append(offsets, offset)
+	}
+	result := memoryStore{}
+	for _, e := range entries {
+		if e.resolved {
+			result[hashObject(e.typ, e.data)] = &e.object
+		}
+	}
+	// Bases may themselves be deltas, so
+	// resolve until nothing changes.
+	for progress := true; progress; {
+		progress = false
+		for _, offset := range offsets {
+			e := entries[offset]
+			if e.resolved {
+				continue
+			}
+			var base *object
+			if e.typ == objOfsDelta {
+				if b, ok := entries[e.baseOfs]; ok && b.resolved {
+					base = &b.object
+				}
+			} else {
+				base = result[e.baseHash]
+			}
+			if base == nil {
+				continue
+			}
+			data, err := applyDelta(base.data, e.data)
+			if err != nil {
+				return nil, err
+			}
+			e.typ, e.data, e.resolved = base.typ, data, true
+			result[hashObject(e.typ, e.data)] = &e.object
+			progress = true
+		}
+	}
+	for _, e := range entries {
+		if !e.resolved {
+			return nil, fmt.Errorf("pack holds a delta with a missing base")
+		}
+	}
+	return result, nil
+}
+
+// indexedPack is a pack in a local repo,
+// read with the help of its version 2 index.
+type indexedPack struct {
+	path    string
+	hashes  []hash
+	offsets []int64
+}
+
+var idxSignature = []byte{0xff, 't', 'O', 'c'}
+
+func readPackIndex(idxPath, packPath string) (*indexedPack, error) {
+	data, err := ioutil.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 8+256*4 || !bytes.Equal(data[:4], idxSignature) ||
+		binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, fmt.Errorf("%s is not a version 2 pack index", idxPath)
+	}
+	n := int(binary.BigEndian.Uint32(data[8+255*4:]))
+	hashesAt := 8 + 256*4
+	offsetsAt := hashesAt + n*20 + n*4
+	largeAt := offsetsAt + n*4
+	if len(data) < largeAt {
+		return nil, fmt.Errorf("%s is truncated", idxPath)
+	}
+	p := &indexedPack{
+		path:    packPath,
+		hashes:  make([]hash, n),
+		offsets: make([]int64, n),
+	}
+	for i := 0; i < n; i++ {
+		copy(p.hashes[i][:], data[hashesAt+i*20:])
+		o := binary.BigEndian.Uint32(data[offsetsAt+i*4:])
+		if o&0x80000000 == 0 {
+			p.offsets[i] = int64(o)
+			continue
+		}
+		at := largeAt + int(o&0x7fffffff)*8
+		if len(data) < at+8 {
+			return nil, fmt.Errorf("%s is truncated", idxPath)
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(data[at:]))
+	}
+	return p, nil
+}
+
+// find returns the offset of the object, if it's in the pack.
+func (p *indexedPack) find(h hash) (int64, bool) {
+	i := sort.Search(len(p.hashes), func(i int) bool {
+		return bytes.Compare(p.hashes[i][:], h[:]) >= 0
+	})
+	if i < len(p.hashes) && p.hashes[i] == h {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// readObjectAt reads the object at the given offset,
+// using the store to find the bases of ref deltas.
+func (p *indexedPack) readObjectAt(
+	offset int64, store objectStore) (*object, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return p.readFrom(f, offset, store)
+}
+
+func (p *indexedPack) readFrom(
+	f *os.File, offset int64, store objectStore) (*object, error) {
+	r := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+	t, size, err := readEntryHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	var base *object
+	switch t {
+	case objOfsDelta:
+		rel, err := readOfsDeltaOffset(r)
+		if err != nil {
+			return nil, err
+		}
+		base, err = p.readFrom(f, offset-rel, store)
+		if err != nil {
+			return nil, err
+		}
+	case objRefDelta:
+		var h hash
+		if _, err := io.ReadFull(r, h[:]); err != nil {
+			return nil, err
+		}
+		base, err = store.readObject(h)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := inflate(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return &object{typ: t, data: data}, nil
+	}
+	data, err = applyDelta(base.data, data)
+	if err != nil {
+		return nil, err
+	}
+	return &object{typ: base.typ, data: data}, nil
+}
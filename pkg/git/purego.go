@@ -0,0 +1,275 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/pkg/errors"
+)
+
+// DefaultCloner clones using a local git install if
+// there is one, and ClonerUsingPureGo otherwise.
+func DefaultCloner(repoSpec *RepoSpec) error {
+	if haveGitProgram() {
+		return ClonerUsingGitExec(repoSpec)
+	}
+	return ClonerUsingPureGo(repoSpec)
+}
+
+// DefaultRefResolver is the RefResolver to go with DefaultCloner.
+func DefaultRefResolver(repoSpec *RepoSpec) (string, error) {
+	if haveGitProgram() {
+		return ResolveRefUsingGitExec(repoSpec)
+	}
+	return ResolveRefUsingPureGo(repoSpec)
+}
+
+func haveGitProgram() bool {
+	_, err := exec.LookPath("git")
+	return err == nil
+}
+
+// ClonerUsingPureGo obtains a checkout of a repo without
+// a local git install.  It fetches only the commit the
+// ref names, from file:// repos or over http(s), and
+// checks out submodules recursively.  Unlike a git clone,
+// the checkout has no .git directory.
+func ClonerUsingPureGo(repoSpec *RepoSpec) error {
+	if repoSpec.Ref == "" {
+		repoSpec.Ref = defaultRef
+	}
+	dir, err := fs.NewTmpConfirmedDir()
+	if err != nil {
+		return err
+	}
+	commit, err := checkoutRepo(
+		http.DefaultClient, repoSpec.CloneSpec(), repoSpec.Ref, dir.String(), 0)
+	if err != nil {
+		os.RemoveAll(dir.String())
+		return err
+	}
+	repoSpec.Dir = dir
+	repoSpec.commit = commit.String()
+	return nil
+}
+
+// ResolveRefUsingPureGo asks the remote, without using
+// a local git install, what commit the repoSpec's ref names.
+func ResolveRefUsingPureGo(repoSpec *RepoSpec) (string, error) {
+	ref := repoSpec.Ref
+	if ref == "" {
+		ref = defaultRef
+	}
+	url := repoSpec.CloneSpec()
+	if p, ok := localRepoPath(url); ok {
+		r, err := openLocalRepo(p)
+		if err != nil {
+			return "", err
+		}
+		h, err := r.resolve(ref)
+		if err != nil {
+			return "", err
+		}
+		h, _, err = peelToCommit(r, h)
+		if err != nil {
+			return "", err
+		}
+		return h.String(), nil
+	}
+	if !isHTTPURL(url) {
+		return "", unsupportedURLError(url)
+	}
+	adv, err := discoverRefs(http.DefaultClient, url)
+	if err != nil {
+		return "", err
+	}
+	h, ok := adv.resolve(ref)
+	if !ok {
+		return "", nil
+	}
+	return h.String(), nil
+}
+
+// Submodules can nest; stop well before a cycle hurts.
+const maxSubmoduleDepth = 10
+
+// checkoutRepo writes the commit a ref names into dir,
+// with its submodules, returning the commit.
+func checkoutRepo(
+	client *http.Client, url, ref, dir string, depth int) (hash, error) {
+	store, commit, err := fetchCommit(client, url, ref)
+	if err != nil {
+		return commit, err
+	}
+	commit, obj, err := peelToCommit(store, commit)
+	if err != nil {
+		return commit, err
+	}
+	tree, err := headerHash(obj.data, "tree")
+	if err != nil {
+		return commit, err
+	}
+	links, err := checkoutTree(store, tree, dir)
+	if err != nil {
+		return commit, errors.Wrapf(err, "checking out %s", url)
+	}
+	if len(links) == 0 {
+		return commit, nil
+	}
+	if depth >= maxSubmoduleDepth {
+		return commit, fmt.Errorf("submodules of %s nest too deeply", url)
+	}
+	urls, err := readGitModules(filepath.Join(dir, ".gitmodules"))
+	if err != nil {
+		return commit, err
+	}
+	for _, l := range links {
+		subURL, ok := urls[l.path]
+		if !ok {
+			return commit, fmt.Errorf(
+				"no url for submodule '%s' of %s in .gitmodules", l.path, url)
+		}
+		_, err = checkoutRepo(
+			client, resolveSubmoduleURL(url, subURL), l.commit.String(),
+			filepath.Join(dir, filepath.FromSlash(l.path)), depth+1)
+		if err != nil {
+			return commit, errors.Wrapf(err, "submodule '%s'", l.path)
+		}
+	}
+	return commit, nil
+}
+
+// fetchCommit returns a store holding what's needed to
+// check out the object the ref names, and that object.
+func fetchCommit(
+	client *http.Client, url, ref string) (objectStore, hash, error) {
+	if p, ok := localRepoPath(url); ok {
+		r, err := openLocalRepo(p)
+		if err != nil {
+			return nil, hash{}, err
+		}
+		h, err := r.resolve(ref)
+		return r, h, err
+	}
+	if !isHTTPURL(url) {
+		return nil, hash{}, unsupportedURLError(url)
+	}
+	adv, err := discoverRefs(client, url)
+	if err != nil {
+		return nil, hash{}, err
+	}
+	h, ok := adv.resolve(ref)
+	if !ok {
+		if abbrevShaRegex.MatchString(ref) {
+			return nil, h, fmt.Errorf(
+				"%s: cannot fetch abbreviated commit '%s'; use all 40 digits",
+				url, ref)
+		}
+		return nil, h, fmt.Errorf("%s: no ref '%s'", url, ref)
+	}
+	store, err := fetchShallow(client, url, adv, h)
+	return store, h, err
+}
+
+func localRepoPath(url string) (string, bool) {
+	if !strings.HasPrefix(url, localRepoHost) {
+		return "", false
+	}
+	return filepath.FromSlash(strings.TrimPrefix(url, localRepoHost)), true
+}
+
+func isHTTPURL(url string) bool {
+	return strings.HasPrefix(url, "https://") ||
+		strings.HasPrefix(url, "http://")
+}
+
+func unsupportedURLError(url string) error {
+	return fmt.Errorf(
+		"cannot clone '%s' without git; only %s, https:// and http:// "+
+			"repos are supported", url, localRepoHost)
+}
+
+// readGitModules maps submodule paths to urls, as
+// declared in a .gitmodules file.
+func readGitModules(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading submodules")
+	}
+	defer f.Close()
+	paths := map[string]string{}
+	urls := map[string]string{}
+	var section string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = ""
+			s := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			if strings.HasPrefix(s, "submodule ") {
+				section = strings.Trim(
+					strings.TrimSpace(strings.TrimPrefix(s, "submodule ")), `"`)
+			}
+			continue
+		}
+		if section == "" {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "path":
+			paths[section] = value
+		case "url":
+			urls[section] = value
+		}
+	}
+	result := map[string]string{}
+	for name, p := range paths {
+		if u, ok := urls[name]; ok {
+			result[path.Clean(p)] = u
+		}
+	}
+	return result, scanner.Err()
+}
+
+// resolveSubmoduleURL resolves a submodule url that's
+// relative to the url of its superproject.
+func resolveSubmoduleURL(base, url string) string {
+	if strings.HasPrefix(url, "/") {
+		return localRepoHost + url
+	}
+	if !strings.HasPrefix(url, "./") && !strings.HasPrefix(url, "../") {
+		return url
+	}
+	base = strings.TrimSuffix(base, "/")
+	for {
+		switch {
+		case strings.HasPrefix(url, "./"):
+			url = url[2:]
+		case strings.HasPrefix(url, "../"):
+			url = url[3:]
+			if i := strings.LastIndex(base, "/"); i >= 0 {
+				base = base[:i]
+			}
+		default:
+			return base + "/" + url
+		}
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testRepo builds a bare repo out of loose objects,
+// so that tests needn't have git installed.
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T, dir string) *testRepo {
+	for _, d := range []string{"objects", "refs/heads", "refs/tags"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, filepath.Join(dir, "HEAD"), "ref: refs/heads/master\n")
+	return &testRepo{t: t, dir: dir}
+}
+
+func (r *testRepo) put(typ objectType, data []byte) hash {
+	h := hashObject(typ, data)
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write([]byte(typ.String() + " " + strconv.Itoa(len(data)) + "\x00"))
+	w.Write(data)
+	w.Close()
+	s := h.String()
+	writeTestFile(r.t, filepath.Join(r.dir, "objects", s[:2], s[2:]), b.String())
+	return h
+}
+
+func (r *testRepo) blob(content string) hash {
+	return r.put(objBlob, []byte(content))
+}
+
+func (r *testRepo) tree(entries ...treeEntry) hash {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	var b bytes.Buffer
+	for _, e := range entries {
+		b.WriteString(e.mode + " " + e.name + "\x00")
+		b.Write(e.hash[:])
+	}
+	return r.put(objTree, b.Bytes())
+}
+
+func (r *testRepo) commit(tree hash, msg string) hash {
+	return r.put(objCommit, []byte("tree "+tree.String()+"\n"+
+		"author A <a@example.com> 1500000000 +0000\n"+
+		"committer A <a@example.com> 1500000000 +0000\n\n"+msg+"\n"))
+}
+
+func (r *testRepo) tag(name string, commit hash) hash {
+	return r.put(objTag, []byte("object "+commit.String()+"\ntype commit\n"+
+		"tag "+name+"\ntagger A <a@example.com> 1500000000 +0000\n\n"+name+"\n"))
+}
+
+func (r *testRepo) ref(name string, h hash) {
+	writeTestFile(r.t, filepath.Join(r.dir, filepath.FromSlash(name)), h.String()+"\n")
+}
+
+func readTestFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(b)
+}
+
+func TestClonerUsingPureGoLocal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kustomize-purego-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sub := newTestRepo(t, filepath.Join(dir, "sub.git"))
+	subCommit := sub.commit(sub.tree(
+		treeEntry{"100644", "configmap.yaml", sub.blob("kind: ConfigMap\n")}),
+		"sub")
+	sub.ref("refs/heads/master", subCommit)
+
+	super := newTestRepo(t, filepath.Join(dir, "super.git"))
+	v1 := super.commit(super.tree(
+		treeEntry{"100644", "kustomization.yaml", super.blob("namePrefix: v1-\n")}),
+		"v1")
+	v2 := super.commit(super.tree(
+		treeEntry{"100644", "kustomization.yaml", super.blob("namePrefix: v2-\n")},
+		treeEntry{"100644", ".gitmodules", super.blob(
+			"[submodule \"vendored\"]\n\tpath = base/sub\n\turl = ../sub.git\n")},
+		treeEntry{"40000", "base", super.tree(
+			treeEntry{"100755", "run.sh", super.blob("#!/bin/sh\n")},
+			treeEntry{"120000", "link", super.blob("run.sh")},
+			treeEntry{"160000", "sub", subCommit})}),
+		"v2")
+	super.ref("refs/tags/v1", super.tag("v1", v1))
+	super.ref("refs/heads/master", v2)
+
+	repoSpec, err := NewRepoSpecFromUrl("file://" + super.dir + "//?ref=v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ClonerUsingPureGo(repoSpec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(repoSpec.Dir.String())
+	if repoSpec.commit != v1.String() {
+		t.Fatalf("expected annotated tag to be peeled to %s, got %s",
+			v1, repoSpec.commit)
+	}
+	got := readTestFile(t, filepath.Join(repoSpec.AbsPath(), "kustomization.yaml"))
+	if got != "namePrefix: v1-\n" {
+		t.Fatalf("unexpected content %q", got)
+	}
+
+	repoSpec, _ = NewRepoSpecFromUrl("file://" + super.dir + "//base")
+	err = ClonerUsingPureGo(repoSpec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(repoSpec.Dir.String())
+	if repoSpec.Ref != "master" || repoSpec.commit != v2.String() {
+		t.Fatalf("unexpected ref %s at %s", repoSpec.Ref, repoSpec.commit)
+	}
+	got = readTestFile(t, filepath.Join(repoSpec.AbsPath(), "sub", "configmap.yaml"))
+	if got != "kind: ConfigMap\n" {
+		t.Fatalf("unexpected submodule content %q", got)
+	}
+	info, err := os.Stat(filepath.Join(repoSpec.AbsPath(), "run.sh"))
+	if err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Fatalf("expected executable run.sh, got %v, %v", info, err)
+	}
+	link, err := os.Readlink(filepath.Join(repoSpec.AbsPath(), "link"))
+	if err != nil || link != "run.sh" {
+		t.Fatalf("expected symlink to run.sh, got %s, %v", link, err)
+	}
+
+	// Abbreviated commits work locally.
+	commit, err := ResolveRefUsingPureGo(
+		NewRepoSpecFromLocalRepo(super.dir, "", v1.String()[:8]))
+	if err != nil || commit != v1.String() {
+		t.Fatalf("expected %s, got %s, %v", v1, commit, err)
+	}
+}
+
+func TestCheckoutRefusesUnsafeNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kustomize-purego-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := newTestRepo(t, filepath.Join(dir, "evil.git"))
+	r.ref("refs/heads/master", r.commit(r.tree(
+		treeEntry{"100644", "..", r.blob("x")}), "evil"))
+	repoSpec := NewRepoSpecFromLocalRepo(r.dir, "", "master")
+	err = ClonerUsingPureGo(repoSpec)
+	if err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Fatalf("expected refusal, got %v", err)
+	}
+}
+
+// packEntryHeader encodes the type and size of a packed object.
+func packEntryHeader(t objectType, size int) []byte {
+	b := []byte{byte(t)<<4 | byte(size&15)}
+	for size >>= 4; size > 0; size >>= 7 {
+		b[len(b)-1] |= 0x80
+		b = append(b, byte(size&0x7f))
+	}
+	return b
+}
+
+func deflate(data []byte) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
+
+// makeTestPack packs the objects, storing the
+// last blob as a delta against the first.
+func makeTestPack(objs []*object) []byte {
+	var b bytes.Buffer
+	b.Write(packSignature)
+	binary.Write(&b, binary.BigEndian, uint32(2))
+	binary.Write(&b, binary.BigEndian, uint32(len(objs)))
+	var firstBlob *object
+	var firstBlobAt int
+	for i, obj := range objs {
+		at := b.Len()
+		if obj.typ == objBlob && firstBlob != nil && i == len(objs)-1 &&
+			bytes.HasPrefix(obj.data, firstBlob.data) {
+			rest := obj.data[len(firstBlob.data):]
+			var delta []byte
+			delta = append(delta, byte(len(firstBlob.data)), byte(len(obj.data)))
+			delta = append(delta, 0x90, byte(len(firstBlob.data)))
+			delta = append(delta, byte(len(rest)))
+			delta = append(delta, rest...)
+			b.Write(packEntryHeader(objOfsDelta, len(delta)))
+			b.WriteByte(byte(at - firstBlobAt))
+			b.Write(deflate(delta))
+			continue
+		}
+		if obj.typ == objBlob && firstBlob == nil {
+			firstBlob, firstBlobAt = obj, at
+		}
+		b.Write(packEntryHeader(obj.typ, len(obj.data)))
+		b.Write(deflate(obj.data))
+	}
+	sum := sha1.Sum(b.Bytes())
+	b.Write(sum[:])
+	return b.Bytes()
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello world")
+	// Copy 5 bytes from offset 6, then insert "oh".
+	got, err := applyDelta(base, []byte{11, 7, 0x91, 6, 5, 2, 'o', 'h'})
+	if err != nil || string(got) != "worldoh" {
+		t.Fatalf("expected worldoh, got %q, %v", got, err)
+	}
+	_, err = applyDelta(base, []byte{11, 13, 0x91, 6, 5, 2, 'o', 'h'})
+	if err == nil {
+		t.Fatalf("expected error for wrong result size")
+	}
+	_, err = applyDelta(base, []byte{11, 7, 0x91, 9, 5})
+	if err == nil {
+		t.Fatalf("expected error for copy past end of base")
+	}
+}
+
+func TestClonerUsingPureGoHTTP(t *testing.T) {
+	a := &object{objBlob, []byte("namePrefix: a-\n")}
+	b := &object{objBlob, []byte("namePrefix: a-\nnameSuffix: -b\n")}
+	var tree bytes.Buffer
+	for _, e := range []struct {
+		name string
+		obj  *object
+	}{{"a.yaml", a}, {"kustomization.yaml", b}} {
+		h := hashObject(e.obj.typ, e.obj.data)
+		tree.WriteString("100644 " + e.name + "\x00")
+		tree.Write(h[:])
+	}
+	treeObj := &object{objTree, tree.Bytes()}
+	commitObj := &object{objCommit, []byte(
+		"tree " + hashObject(objTree, treeObj.data).String() + "\n\nc\n")}
+	commit := hashObject(objCommit, commitObj.data)
+	pack := makeTestPack([]*object{commitObj, treeObj, a, b})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repo.git/info/refs", func(
+		w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("service") != uploadPack {
+			http.Error(w, "dumb", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-"+uploadPack+"-advertisement")
+		w.Write([]byte(pktLine("# service="+uploadPack+"\n") + flushPkt +
+			pktLine(commit.String()+" HEAD\x00side-band-64k ofs-delta shallow\n") +
+			pktLine(commit.String()+" refs/heads/master\n") + flushPkt))
+	})
+	mux.HandleFunc("/repo.git/"+uploadPack, func(
+		w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if !strings.Contains(string(body), "want "+commit.String()) ||
+			!strings.Contains(string(body), "deepen 1") {
+			http.Error(w, "bad request "+string(body), http.StatusBadRequest)
+			return
+		}
+		var resp bytes.Buffer
+		resp.WriteString(pktLine("shallow "+commit.String()+"\n") + flushPkt)
+		resp.WriteString(pktLine("NAK\n"))
+		resp.WriteString(pktLine("\x02Counting objects\n"))
+		for i := 0; i < len(pack); i += 10 {
+			end := i + 10
+			if end > len(pack) {
+				end = len(pack)
+			}
+			resp.WriteString(pktLine("\x01" + string(pack[i:end])))
+		}
+		resp.WriteString(flushPkt)
+		w.Write(resp.Bytes())
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	repoSpec, err := NewRepoSpecFromUrl(server.URL + "/repo.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFound, err := ResolveRefUsingPureGo(repoSpec)
+	if err != nil || commitFound != commit.String() {
+		t.Fatalf("expected %s, got %s, %v", commit, commitFound, err)
+	}
+	err = ClonerUsingPureGo(repoSpec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(repoSpec.Dir.String())
+	got := readTestFile(t, filepath.Join(repoSpec.AbsPath(), "kustomization.yaml"))
+	if got != string(b.data) {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
+
+// TestClonerUsingPureGoMatchesGit checks that packed repos,
+// as made by git itself, are checked out as git would.
+func TestClonerUsingPureGoMatchesGit(t *testing.T) {
+	gitProgram, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("no git program on path")
+	}
+	dir, err := ioutil.TempDir("", "kustomize-purego-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	work := filepath.Join(dir, "work")
+	run := func(args ...string) {
+		cmd := exec.Command(gitProgram, args...)
+		cmd.Dir = work
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com",
+			"GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	os.MkdirAll(work, 0755)
+	run("init", "-q")
+	content := strings.Repeat("kind: ConfigMap\n", 50)
+	writeTestFile(t, filepath.Join(work, "base", "cm.yaml"), content)
+	run("add", ".")
+	run("commit", "-q", "-m", "one")
+	writeTestFile(t, filepath.Join(work, "base", "cm.yaml"), content+"# two\n")
+	run("commit", "-q", "-a", "-m", "two")
+	run("tag", "-a", "-m", "v2", "v2")
+	run("gc", "-q", "--aggressive")
+	run("clone", "-q", "--bare", ".", filepath.Join(dir, "bare.git"))
+
+	for _, root := range []string{work, filepath.Join(dir, "bare.git")} {
+		repoSpec := NewRepoSpecFromLocalRepo(root, "base", "v2")
+		err = ClonerUsingPureGo(repoSpec)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := readTestFile(t, filepath.Join(repoSpec.AbsPath(), "cm.yaml"))
+		os.RemoveAll(repoSpec.Dir.String())
+		if got != content+"# two\n" {
+			t.Fatalf("unexpected content from %s: %q", root, got)
+		}
+	}
+}
+
+func TestResolveSubmoduleURL(t *testing.T) {
+	var cases = []struct {
+		base, url, expected string
+	}{
+		{"https://example.com/org/repo.git", "../other.git",
+			"https://example.com/org/other.git"},
+		{"https://example.com/org/repo.git/", "./sub",
+			"https://example.com/org/repo.git/sub"},
+		{"file:///repos/a", "../../b", "file:///b"},
+		{"file:///repos/a", "/repos/c", "file:///repos/c"},
+		{"file:///repos/a", "https://example.com/x", "https://example.com/x"},
+	}
+	for _, c := range cases {
+		if got := resolveSubmoduleURL(c.base, c.url); got != c.expected {
+			t.Errorf("%s + %s: expected %s, got %s", c.base, c.url, c.expected, got)
+		}
+	}
+}
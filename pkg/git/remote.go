@@ -0,0 +1,231 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A client for the smart HTTP protocol, version 0, just
+// capable enough to make a shallow fetch of one commit.
+// See Documentation/technical/http-protocol.txt in git.
+
+const (
+	uploadPack = "git-upload-pack"
+	agent      = "agent=kustomize"
+)
+
+// advertisement is what a remote says it has.
+type advertisement struct {
+	refs map[string]hash
+	caps map[string]bool
+}
+
+// readPktLine reads one pkt-line, returning
+// nil data for a flush-pkt.
+func readPktLine(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n, err := strconv.ParseUint(string(size[:]), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("malformed pkt-line length %q", size)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	if n < 4 {
+		return nil, fmt.Errorf("malformed pkt-line length %q", size)
+	}
+	data := make([]byte, n-4)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
+
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
+
+const flushPkt = "0000"
+
+// discoverRefs asks the remote what refs it has.
+func discoverRefs(
+	client *http.Client, repoURL string) (*advertisement, error) {
+	resp, err := client.Get(
+		strings.TrimSuffix(repoURL, "/") + "/info/refs?service=" + uploadPack)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(
+			"%s: listing refs: %s", repoURL, resp.Status)
+	}
+	if resp.Header.Get("Content-Type") !=
+		"application/x-"+uploadPack+"-advertisement" {
+		return nil, fmt.Errorf(
+			"%s doesn't speak the smart HTTP protocol", repoURL)
+	}
+	r := bufio.NewReader(resp.Body)
+	line, err := readPktLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if string(bytes.TrimSpace(line)) != "# service="+uploadPack {
+		return nil, fmt.Errorf("%s: unexpected reply %q", repoURL, line)
+	}
+	if line, err = readPktLine(r); err != nil || line != nil {
+		return nil, fmt.Errorf("%s: expected flush-pkt", repoURL)
+	}
+	adv := &advertisement{refs: map[string]hash{}, caps: map[string]bool{}}
+	for first := true; ; first = false {
+		line, err = readPktLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if line == nil {
+			return adv, nil
+		}
+		s := strings.TrimSuffix(string(line), "\n")
+		if first {
+			if i := strings.IndexByte(s, 0); i >= 0 {
+				for _, c := range strings.Fields(s[i+1:]) {
+					adv.caps[c] = true
+				}
+				s = s[:i]
+			}
+		}
+		fields := strings.Fields(s)
+		if len(fields) != 2 {
+			continue
+		}
+		h, err := parseHash(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		adv.refs[fields[1]] = h
+	}
+}
+
+// resolve finds the commit a branch, tag or commit
+// hash names, peeling annotated tags where the remote
+// says what they point to.
+func (adv *advertisement) resolve(ref string) (hash, bool) {
+	if fullShaRegex.MatchString(ref) {
+		h, err := parseHash(ref)
+		return h, err == nil
+	}
+	for _, name := range []string{
+		ref, "refs/tags/" + ref + "^{}", "refs/tags/" + ref,
+		"refs/heads/" + ref} {
+		if h, ok := adv.refs[name]; ok {
+			return h, true
+		}
+	}
+	return hash{}, false
+}
+
+// fetchShallow fetches the given object, and only as
+// much history as needed to check out the commit.
+func fetchShallow(
+	client *http.Client, repoURL string,
+	adv *advertisement, want hash) (memoryStore, error) {
+	caps := []string{agent}
+	for _, c := range []string{"side-band-64k", "ofs-delta", "no-progress"} {
+		if adv.caps[c] {
+			caps = append(caps, c)
+		}
+	}
+	sideBand := adv.caps["side-band-64k"]
+	shallow := adv.caps["shallow"]
+	var req bytes.Buffer
+	req.WriteString(pktLine(
+		"want " + want.String() + " " + strings.Join(caps, " ") + "\n"))
+	if shallow {
+		req.WriteString(pktLine("deepen 1\n"))
+	}
+	req.WriteString(flushPkt)
+	req.WriteString(pktLine("done\n"))
+	resp, err := client.Post(
+		strings.TrimSuffix(repoURL, "/")+"/"+uploadPack,
+		"application/x-"+uploadPack+"-request", &req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: fetching %s: %s", repoURL, want, resp.Status)
+	}
+	r := bufio.NewReader(resp.Body)
+	if shallow {
+		// Skip the shallow-info section.
+		for {
+			line, err := readPktLine(r)
+			if err != nil {
+				return nil, err
+			}
+			if line == nil {
+				break
+			}
+		}
+	}
+	line, err := readPktLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if s := string(line); !strings.HasPrefix(s, "NAK") &&
+		!strings.HasPrefix(s, "ACK") {
+		return nil, fmt.Errorf("%s: fetching %s: %s", repoURL, want,
+			strings.TrimSpace(strings.TrimPrefix(s, "ERR ")))
+	}
+	var pack []byte
+	if sideBand {
+		pack, err = readSideBand(r)
+	} else {
+		pack, err = ioutil.ReadAll(r)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: fetching %s", repoURL, want)
+	}
+	return parsePack(pack)
+}
+
+// readSideBand demultiplexes the pack from the
+// progress and error messages sent with it.
+func readSideBand(r io.Reader) ([]byte, error) {
+	var pack bytes.Buffer
+	for {
+		line, err := readPktLine(r)
+		if err == io.EOF {
+			return pack.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 {
+			return pack.Bytes(), nil
+		}
+		switch line[0] {
+		case 1:
+			pack.Write(line[1:])
+		case 2:
+			// Progress.
+		case 3:
+			return nil, fmt.Errorf("remote error: %s",
+				strings.TrimSpace(string(line[1:])))
+		default:
+			return nil, fmt.Errorf("unknown side band %d", line[0])
+		}
+	}
+}
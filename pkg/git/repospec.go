@@ -57,6 +57,12 @@ type RepoSpec struct {
 
 	// e.g. .git or empty in case of _git is present
 	GitSuffix string
+
+	// True if Dir is in a Cache, and must outlive the build.
+	cached bool
+
+	// The commit checked out in Dir, if the cloner knows it.
+	commit string
 }
 
 // CloneSpec returns a string suitable for "git clone {spec}".
@@ -80,6 +86,9 @@ func (x *RepoSpec) AbsPath() string {
 }
 
 func (x *RepoSpec) Cleaner(fSys fs.FileSystem) func() error {
+	if x.cached {
+		return func() error { return nil }
+	}
 	return func() error { return fSys.RemoveAll(x.Dir.String()) }
 }
 
@@ -102,7 +111,40 @@ func NewRepoSpecFromUrl(n string) (*RepoSpec, error) {
 		Dir: notCloned, Path: path, Ref: gitRef, GitSuffix: gitSuffix}, nil
 }
 
+// NewRepoSpecFromLocalRepo makes a RepoSpec for the
+// given ref of a git repository already on local disk,
+// e.g. to build the working copy's kustomization as it
+// was at some earlier commit.  The path is relative to
+// the repository's top level directory.
+func NewRepoSpecFromLocalRepo(repoDir, path, ref string) *RepoSpec {
+	return &RepoSpec{
+		raw:     localRepoHost + repoDir + "//" + path + refQuery + ref,
+		Host:    localRepoHost,
+		OrgRepo: repoDir,
+		Dir:     notCloned,
+		Path:    path,
+		Ref:     ref,
+	}
+}
+
+// WithRef returns a copy of the RepoSpec
+// that refers to a different branch, tag or commit.
+func (x *RepoSpec) WithRef(ref string) *RepoSpec {
+	result := *x
+	result.Dir = notCloned
+	result.cached = false
+	result.commit = ""
+	result.Ref = ref
+	r, _ := regexp.Compile(refQueryRegex)
+	if j := r.FindStringIndex(result.raw); len(j) > 0 {
+		result.raw = result.raw[:j[0]]
+	}
+	result.raw += refQuery + ref
+	return &result
+}
+
 const (
+	localRepoHost = "file://"
 	refQuery      = "?ref="
 	refQueryRegex = "\\?(version|ref)="
 	gitSuffix     = ".git"
@@ -115,6 +157,18 @@ const (
 func parseGitUrl(n string) (
 	host string, orgRepo string, path string, gitRef string, gitSuff string) {
 
+	if strings.HasPrefix(n, localRepoHost) {
+		// A repo on local disk, e.g. file:///repos/foo//someDir?ref=v1
+		host = localRepoHost
+		n = n[len(localRepoHost):]
+		if i := strings.Index(n, "//"); i > 0 {
+			orgRepo = n[:i]
+			path, gitRef = peelQuery(n[i+2:])
+			return
+		}
+		orgRepo, gitRef = peelQuery(n)
+		return
+	}
 	if strings.Contains(n, gitDelimiter) {
 		index := strings.Index(n, gitDelimiter)
 		// Adding _git/ to host
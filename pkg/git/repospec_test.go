@@ -193,6 +193,18 @@ func TestNewRepoSpecFromUrl_CloneSpecs(t *testing.T) {
 			absPath:   notCloned.String(),
 			ref:       "",
 		},
+		{
+			input:     "file:///repos/somerepo.git//somedir?ref=v1",
+			cloneSpec: "file:///repos/somerepo.git",
+			absPath:   notCloned.Join("somedir"),
+			ref:       "v1",
+		},
+		{
+			input:     "file:///repos/somerepo?ref=v1",
+			cloneSpec: "file:///repos/somerepo",
+			absPath:   notCloned.String(),
+			ref:       "v1",
+		},
 	}
 	for _, testcase := range testcases {
 		rs, err := NewRepoSpecFromUrl(testcase.input)
@@ -299,3 +311,30 @@ func TestIsAWSHost(t *testing.T) {
 		}
 	}
 }
+
+func TestWithRef(t *testing.T) {
+	rs, err := NewRepoSpecFromUrl("github.com/someOrg/someRepo/someDir?ref=v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other := rs.WithRef("v2")
+	if other.Ref != "v2" || rs.Ref != "v1" {
+		t.Errorf("unexpected refs: %s, %s", other.Ref, rs.Ref)
+	}
+	if other.Raw() != "github.com/someOrg/someRepo/someDir?ref=v2" {
+		t.Errorf("unexpected raw: %s", other.Raw())
+	}
+	if other.CloneSpec() != rs.CloneSpec() || other.Path != rs.Path {
+		t.Errorf("expected same repo and path")
+	}
+}
+
+func TestNewRepoSpecFromLocalRepo(t *testing.T) {
+	rs := NewRepoSpecFromLocalRepo("/home/me/repo", "overlays/prod", "v1")
+	if rs.CloneSpec() != "file:///home/me/repo" {
+		t.Errorf("unexpected clone spec: %s", rs.CloneSpec())
+	}
+	if rs.Path != "overlays/prod" || rs.Ref != "v1" {
+		t.Errorf("unexpected path or ref: %s, %s", rs.Path, rs.Ref)
+	}
+}
@@ -33,4 +33,10 @@ type Image struct {
 	// Digest is the value used to replace the original image tag.
 	// If digest is present NewTag value is ignored.
 	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
+
+	// DigestFile is the relative path to a JSON or YAML file
+	// mapping image names to digests, as written by a release
+	// pipeline.  The digest of Name is read from it; without a
+	// Name, every image in the file gets its digest.
+	DigestFile string `json:"digestFile,omitempty" yaml:"digestFile,omitempty"`
 }
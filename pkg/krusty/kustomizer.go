@@ -0,0 +1,168 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package krusty builds kustomizations in-process, for
+// programs that embed kustomize rather than run it.
+//
+//   k := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+//   m, err := k.Run("path/to/overlay")
+//
+// The result is the same as 'kustomize build' prints.
+// To build kustomizations that never touch disk, use
+// an fs.MemFS as the options' FileSystem; remote bases
+// then aren't supported.
+package krusty
+
+import (
+	"fmt"
+
+	"github.com/irairdon/kustomize/v3/k8sdeps/kunstruct"
+	"github.com/irairdon/kustomize/v3/k8sdeps/transformer"
+	"github.com/irairdon/kustomize/v3/k8sdeps/validator"
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/git"
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/loader"
+	"github.com/irairdon/kustomize/v3/pkg/plugins"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/target"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+	"github.com/irairdon/kustomize/v3/plugin/builtin"
+)
+
+// ReorderMode says how a Kustomizer orders the
+// resources it returns.
+type ReorderMode int
+
+const (
+	// ReorderLegacy sorts resources as 'kustomize build'
+	// does by default, e.g. Namespaces first and
+	// webhooks last.
+	ReorderLegacy ReorderMode = iota
+	// ReorderNone leaves resources in the order the
+	// kustomizations list them.
+	ReorderNone
+)
+
+// Options control a Kustomizer.
+type Options struct {
+	// FileSystem holds the kustomizations to build.
+	// Remote targets and bases are cloned to local
+	// disk, so only load with the real file system;
+	// with any other, e.g. an fs.MemFS, Run fails on
+	// them.
+	FileSystem fs.FileSystem
+
+	// LoadRestrictor says what files a local
+	// kustomization may read, e.g.
+	// loader.RestrictionRootOnly, the default, or
+	// loader.RestrictionNone.
+	LoadRestrictor loader.LoadRestrictorFunc
+
+	// PluginConfig says whether and where to find
+	// plugins beyond the builtin ones.
+	PluginConfig *types.PluginConfig
+
+	// Reorder says how to order the output.
+	Reorder ReorderMode
+
+	// Strict fails the build on configuration that has
+	// no effect, as 'kustomize build --strict' does.
+	Strict bool
+}
+
+// MakeDefaultOptions returns options matching the
+// defaults of 'kustomize build': the real file system,
+// root only load restrictions, no plugins beyond the
+// builtin ones, and legacy ordering.
+func MakeDefaultOptions() *Options {
+	return &Options{
+		FileSystem:     fs.MakeRealFS(),
+		LoadRestrictor: loader.RestrictionRootOnly,
+		PluginConfig:   plugins.DefaultPluginConfig(),
+		Reorder:        ReorderLegacy,
+	}
+}
+
+// Kustomizer builds kustomizations.  One Kustomizer
+// may be used for any number of builds.
+type Kustomizer struct {
+	options Options
+	rf      *resmap.Factory
+	pf      resmap.PatchFactory
+	v       ifc.Validator
+}
+
+// MakeKustomizer returns a Kustomizer using the given
+// options.  Fields left unset in the options take
+// their values from MakeDefaultOptions.
+func MakeKustomizer(o *Options) *Kustomizer {
+	options := *MakeDefaultOptions()
+	if o != nil {
+		if o.FileSystem != nil {
+			options.FileSystem = o.FileSystem
+		}
+		if o.LoadRestrictor != nil {
+			options.LoadRestrictor = o.LoadRestrictor
+		}
+		if o.PluginConfig != nil {
+			options.PluginConfig = o.PluginConfig
+		}
+		options.Reorder = o.Reorder
+		options.Strict = o.Strict
+	}
+	pf := transformer.NewFactoryImpl()
+	return &Kustomizer{
+		options: options,
+		rf: resmap.NewFactory(
+			resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl()), pf),
+		pf: pf,
+		v:  validator.NewKustValidator(),
+	}
+}
+
+// Run builds the kustomization at the given path,
+// which is either a directory in the options' file
+// system, or a git URL of the kind 'kustomize build'
+// accepts.  Remote bases are cloned, and removed
+// again when the build is done.
+func (k *Kustomizer) Run(path string) (resmap.ResMap, error) {
+	cloner := git.DefaultCloner
+	if !fs.IsRealFS(k.options.FileSystem) {
+		cloner = noRemoteCloner
+	}
+	ldr, err := loader.NewLoaderWithCloner(
+		k.options.LoadRestrictor, k.v, path,
+		k.options.FileSystem, cloner)
+	if err != nil {
+		return nil, err
+	}
+	defer ldr.Cleanup()
+	kt, err := target.NewKustTarget(
+		ldr, k.rf, k.pf, plugins.NewLoader(k.options.PluginConfig, k.rf))
+	if err != nil {
+		return nil, err
+	}
+	if k.options.Strict {
+		kt.EnableStrict()
+	}
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		return nil, err
+	}
+	if k.options.Reorder == ReorderLegacy {
+		err = builtin.NewLegacyOrderTransformerPlugin().Transform(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// noRemoteCloner refuses to clone, as a clone on local
+// disk can't be read through any other file system.
+func noRemoteCloner(repoSpec *git.RepoSpec) error {
+	return fmt.Errorf(
+		"remote base %s needs the real file system", repoSpec.Raw())
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package krusty_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/krusty"
+	"github.com/irairdon/kustomize/v3/pkg/loader"
+)
+
+func writeOverlay(t *testing.T) fs.FileSystem {
+	fSys := fs.MakeFakeFS()
+	for path, content := range map[string]string{
+		"/app/base/kustomization.yaml": `
+resources:
+- service.yaml
+- namespace.yaml
+`,
+		"/app/base/service.yaml": `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+		"/app/base/namespace.yaml": `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: shop
+`,
+		"/app/prod/kustomization.yaml": `
+namePrefix: prod-
+resources:
+- ../base
+configMapGenerator:
+- name: settings
+  files:
+  - ../shared/settings.env
+`,
+		"/app/shared/settings.env": "color=blue\n",
+	} {
+		if err := fSys.WriteFile(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fSys
+}
+
+func run(t *testing.T, o *krusty.Options) string {
+	m, err := krusty.MakeKustomizer(o).Run("/app/prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := m.AsYaml()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(b)
+}
+
+func TestKustomizerLoadRestrictions(t *testing.T) {
+	o := &krusty.Options{FileSystem: writeOverlay(t)}
+	_, err := krusty.MakeKustomizer(o).Run("/app/prod")
+	if err == nil || !strings.Contains(err.Error(), "is not in or below") {
+		t.Fatalf("expected load restriction error, got %v", err)
+	}
+	o.LoadRestrictor = loader.RestrictionNone
+	if out := run(t, o); !strings.Contains(out, "color=blue") {
+		t.Fatalf("expected generated configmap, got\n%s", out)
+	}
+}
+
+func TestKustomizerReorder(t *testing.T) {
+	o := &krusty.Options{
+		FileSystem:     writeOverlay(t),
+		LoadRestrictor: loader.RestrictionNone,
+	}
+	out := run(t, o)
+	if !strings.HasPrefix(out, "apiVersion: v1\nkind: Namespace\n") {
+		t.Fatalf("expected the namespace first, got\n%s", out)
+	}
+	o.Reorder = krusty.ReorderNone
+	out = run(t, o)
+	if !strings.HasPrefix(out, "apiVersion: v1\nkind: Service\n") {
+		t.Fatalf("expected the service first, got\n%s", out)
+	}
+	if !strings.Contains(out, "name: prod-web") {
+		t.Fatalf("expected prefixed names, got\n%s", out)
+	}
+}
+
+func TestKustomizerPluginConfig(t *testing.T) {
+	fSys := fs.MakeFakeFS()
+	fSys.WriteFile("/app/kustomization.yaml", []byte(`
+generators:
+- gen.yaml
+`))
+	fSys.WriteFile("/app/gen.yaml", []byte(`
+apiVersion: someteam.example.com/v1
+kind: SomeGenerator
+metadata:
+  name: gen
+`))
+	_, err := krusty.MakeKustomizer(
+		&krusty.Options{FileSystem: fSys}).Run("/app")
+	if err == nil || !strings.Contains(err.Error(), "plugins disabled") {
+		t.Fatalf("expected plugins disabled error, got %v", err)
+	}
+}
+
+func TestKustomizerInMemory(t *testing.T) {
+	fSys, err := fs.MakeMemFSFromFiles(map[string]string{
+		"base/kustomization.yaml": "resources:\n- service.yaml\n",
+		"base/service.yaml": `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+		"prod/kustomization.yaml": `
+namespace: prod
+resources:
+- ../base
+configMapGenerator:
+- name: settings
+  files:
+  - settings.env
+`,
+		"prod/settings.env": "color=blue\n",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err := krusty.MakeKustomizer(
+		&krusty.Options{FileSystem: fSys}).Run("prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := m.AsYaml()
+	if !strings.Contains(string(b), "namespace: prod") ||
+		!strings.Contains(string(b), "color=blue") {
+		t.Fatalf("unexpected output\n%s", b)
+	}
+}
+
+func TestKustomizerInMemoryRemoteBase(t *testing.T) {
+	const remote = "github.com/kubernetes-sigs/kustomize//examples/multibases?ref=v1.0.6"
+	fSys, err := fs.MakeMemFSFromFiles(map[string]string{
+		"prod/kustomization.yaml": "resources:\n- " + remote + "\n",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	k := krusty.MakeKustomizer(&krusty.Options{FileSystem: fSys})
+	for _, path := range []string{"prod", remote} {
+		_, err = k.Run(path)
+		if err == nil || !strings.Contains(err.Error(), "needs the real file system") {
+			t.Fatalf("expected remote base error for %s, got %v", path, err)
+		}
+	}
+}
@@ -92,6 +92,10 @@ type fileLoader struct {
 	// Used to clone repositories.
 	cloner git.Cloner
 
+	// If this is non-nil, remote bases are pinned to
+	// the commits in the root's kustomization.lock.
+	lock *remoteLock
+
 	// Used to clean up, as needed.
 	cleaner func() error
 }
@@ -126,7 +130,7 @@ func newLoaderOrDie(
 		log.Fatalf("unable to make loader at '%s'; %v", path, err)
 	}
 	return newLoaderAtConfirmedDir(
-		lr, v, root, fSys, nil, git.ClonerUsingGitExec)
+		lr, v, root, fSys, nil, git.DefaultCloner)
 }
 
 // newLoaderAtConfirmedDir returns a new fileLoader with given root.
@@ -142,10 +146,20 @@ func newLoaderAtConfirmedDir(
 		referrer:       referrer,
 		fSys:           fSys,
 		cloner:         cloner,
+		lock:           referrer.remoteLock(),
 		cleaner:        func() error { return nil },
 	}
 }
 
+// remoteLock returns the lock shared by all loaders
+// spawned from the same root, if any.
+func (fl *fileLoader) remoteLock() *remoteLock {
+	if fl == nil {
+		return nil
+	}
+	return fl.lock
+}
+
 // Assure that the given path is in fact a directory.
 func demandDirectoryRoot(
 	fSys fs.FileSystem, path string) (fs.ConfirmedDir, error) {
@@ -178,6 +192,12 @@ func (fl *fileLoader) New(path string) (ifc.Loader, error) {
 		if err := fl.errIfRepoCycle(repoSpec); err != nil {
 			return nil, err
 		}
+		if fl.lock != nil {
+			repoSpec, err = fl.lock.pin(repoSpec)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return newLoaderAtGitClone(
 			repoSpec, fl.validator, fl.fSys, fl, fl.cloner)
 	}
@@ -230,6 +250,7 @@ func newLoaderAtGitClone(
 		repoSpec:       repoSpec,
 		fSys:           fSys,
 		cloner:         cloner,
+		lock:           referrer.remoteLock(),
 		cleaner:        repoSpec.Cleaner(fSys),
 	}, nil
 }
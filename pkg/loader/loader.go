@@ -33,16 +33,56 @@ func NewLoader(
 	lr LoadRestrictorFunc,
 	v ifc.Validator,
 	target string, fSys fs.FileSystem) (ifc.Loader, error) {
+	return NewLoaderWithCloner(
+		lr, v, target, fSys, git.DefaultCloner)
+}
+
+// NewLoaderWithCloner is like NewLoader, but obtains
+// remote targets and bases using the given cloner,
+// e.g. one backed by a git.Cache.
+func NewLoaderWithCloner(
+	lr LoadRestrictorFunc,
+	v ifc.Validator,
+	target string, fSys fs.FileSystem,
+	cloner git.Cloner) (ifc.Loader, error) {
+	return NewLoaderWithLock(
+		lr, v, target, fSys, cloner, DefaultLockPolicy)
+}
+
+// NewLoaderWithLock is like NewLoaderWithCloner, but
+// treats a kustomization.lock in a local target's root
+// as the given policy says.  Regardless of policy, a
+// target without a lock has its remote bases unpinned,
+// unless the policy allows updates.
+func NewLoaderWithLock(
+	lr LoadRestrictorFunc,
+	v ifc.Validator,
+	target string, fSys fs.FileSystem,
+	cloner git.Cloner, policy LockPolicy) (ifc.Loader, error) {
 	repoSpec, err := git.NewRepoSpecFromUrl(target)
 	if err == nil {
 		// The target qualifies as a remote git target.
 		return newLoaderAtGitClone(
-			repoSpec, v, fSys, nil, git.ClonerUsingGitExec)
+			repoSpec, v, fSys, nil, cloner)
 	}
 	root, err := demandDirectoryRoot(fSys, target)
 	if err != nil {
 		return nil, err
 	}
-	return newLoaderAtConfirmedDir(
-		lr, v, root, fSys, nil, git.ClonerUsingGitExec), nil
+	fl := newLoaderAtConfirmedDir(lr, v, root, fSys, nil, cloner)
+	fl.lock, err = newRemoteLock(fSys, root, policy)
+	if err != nil {
+		return nil, err
+	}
+	return fl, nil
+}
+
+// NewLoaderAtRepoSpec returns a Loader rooted in a fresh
+// clone of the given repoSpec, regardless of whether
+// the spec was parsed from a URL or made some other way.
+func NewLoaderAtRepoSpec(
+	v ifc.Validator,
+	repoSpec *git.RepoSpec, fSys fs.FileSystem) (ifc.Loader, error) {
+	return newLoaderAtGitClone(
+		repoSpec, v, fSys, nil, git.DefaultCloner)
 }
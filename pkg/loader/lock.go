@@ -0,0 +1,153 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/git"
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/pkg/errors"
+)
+
+// LockPolicy says how loaders treat the commits that
+// a kustomization.lock pins remote bases to.
+type LockPolicy struct {
+	// Resolve finds the commit that a remote base's ref
+	// names now, to detect drift from the lock.
+	// If nil, drift isn't checked.
+	Resolve git.RefResolver
+
+	// Update, if true, records drift, and remote bases
+	// missing from the lock, rather than failing.
+	// See SaveLock.
+	Update bool
+}
+
+// DefaultLockPolicy honors a lock, failing on drift.
+var DefaultLockPolicy = LockPolicy{Resolve: git.DefaultRefResolver}
+
+// remoteLock is shared by a root loader and every
+// loader it spawns, so that remote bases of remote
+// bases are pinned by the root's lock too.
+type remoteLock struct {
+	// Guards lock and used, as bases may load concurrently.
+	mu     sync.Mutex
+	policy LockPolicy
+	fSys   fs.FileSystem
+	dir    fs.ConfirmedDir
+	// True if the lock file existed when loading began.
+	existed bool
+	lock    *git.Lock
+	// The urls of the remote bases loaded so far.
+	used map[string]bool
+}
+
+// newRemoteLock reads the lock in the given root, returning
+// nil if there's none, and no need to make one.
+func newRemoteLock(
+	fSys fs.FileSystem, root fs.ConfirmedDir,
+	policy LockPolicy) (*remoteLock, error) {
+	l, err := git.ReadLock(fSys, root.String())
+	if err != nil {
+		return nil, err
+	}
+	existed := l != nil
+	if !existed {
+		if !policy.Update {
+			return nil, nil
+		}
+		l = &git.Lock{}
+	}
+	return &remoteLock{
+		policy:  policy,
+		fSys:    fSys,
+		dir:     root,
+		existed: existed,
+		lock:    l,
+		used:    make(map[string]bool),
+	}, nil
+}
+
+func (rl *remoteLock) path() string {
+	return rl.dir.Join(git.LockFileName)
+}
+
+// pin returns a copy of the repoSpec that refers to
+// the commit the lock pins it to.
+func (rl *remoteLock) pin(repoSpec *git.RepoSpec) (*git.RepoSpec, error) {
+	url := repoSpec.Raw()
+	rl.mu.Lock()
+	rl.used[url] = true
+	locked, ok := rl.lock.Commit(url)
+	rl.mu.Unlock()
+	var current string
+	if git.IsCommit(repoSpec.Ref) {
+		current = repoSpec.Ref
+	} else if rl.policy.Resolve != nil && (ok || rl.policy.Update) {
+		c, err := rl.policy.Resolve(repoSpec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "checking '%s' against %s", url, rl.path())
+		}
+		if c == "" {
+			return nil, fmt.Errorf("%s has no ref '%s'", repoSpec.CloneSpec(), repoSpec.Ref)
+		}
+		current = c
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	switch {
+	case !ok && !rl.policy.Update:
+		return nil, fmt.Errorf(
+			"remote base '%s' is not pinned in %s; run 'kustomize edit lock'",
+			url, rl.path())
+	case !ok:
+		if current == "" {
+			return nil, fmt.Errorf(
+				"cannot pin remote base '%s' without resolving its ref", url)
+		}
+		rl.lock.Set(url, current)
+		locked = current
+	case current != "" && current != locked:
+		if !rl.policy.Update {
+			return nil, fmt.Errorf(
+				"remote base '%s' has drifted to commit %s, but %s pins it to %s; "+
+					"run 'kustomize edit lock' to update the lock",
+				url, current, rl.path(), locked)
+		}
+		rl.lock.Set(url, current)
+		locked = current
+	}
+	return repoSpec.WithRef(locked), nil
+}
+
+// save writes a lock holding just the remote bases loaded.
+func (rl *remoteLock) save() error {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	l := &git.Lock{}
+	for _, r := range rl.lock.Remotes {
+		if rl.used[r.Url] {
+			l.Set(r.Url, r.Commit)
+		}
+	}
+	if len(l.Remotes) == 0 && !rl.existed {
+		return nil
+	}
+	return l.Write(rl.fSys, rl.dir.String())
+}
+
+// SaveLock writes the kustomization.lock in the root
+// of a loader made with a LockPolicy allowing updates.
+// Call it once everything has been loaded; remote bases
+// that weren't loaded are dropped from the lock.
+func SaveLock(ldr ifc.Loader) error {
+	fl, ok := ldr.(*fileLoader)
+	if !ok || fl.lock == nil || !fl.lock.policy.Update {
+		return nil
+	}
+	return fl.lock.save()
+}
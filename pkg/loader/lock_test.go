@@ -0,0 +1,187 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package loader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/fs"
+	"github.com/irairdon/kustomize/v3/pkg/git"
+	"github.com/irairdon/kustomize/v3/pkg/validators"
+)
+
+const (
+	lockTopDir    = "/app"
+	lockCloneRoot = "/app/someClone"
+	remoteBase    = "github.com/someOrg/someRepo/base?ref=v1"
+	nestedBase    = "github.com/someOrg/otherRepo?ref=master"
+	oldCommit     = "a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1a1"
+	newCommit     = "b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2b2"
+	otherCommit   = "c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3c3"
+)
+
+// lockTestCloner records the refs cloned.
+type lockTestCloner struct {
+	refs []string
+}
+
+func (c *lockTestCloner) clone(rs *git.RepoSpec) error {
+	c.refs = append(c.refs, rs.Ref)
+	rs.Dir = fs.ConfirmedDir(lockCloneRoot)
+	return nil
+}
+
+func fixedResolver(commits map[string]string) git.RefResolver {
+	return func(rs *git.RepoSpec) (string, error) {
+		return commits[rs.OrgRepo], nil
+	}
+}
+
+func makeLockTestFs(t *testing.T, lock string) fs.FileSystem {
+	fSys := fs.MakeFakeFS()
+	fSys.MkdirAll(lockCloneRoot + "/base")
+	if lock != "" {
+		err := fSys.WriteFile(lockTopDir+"/"+git.LockFileName, []byte(lock))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fSys
+}
+
+func loadWithLock(
+	t *testing.T, fSys fs.FileSystem, policy LockPolicy,
+	urls ...string) (*lockTestCloner, error) {
+	c := &lockTestCloner{}
+	ldr, err := NewLoaderWithLock(
+		RestrictionRootOnly, validators.MakeFakeValidator(),
+		lockTopDir, fSys, c.clone, policy)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	for _, url := range urls {
+		if ldr, err = ldr.New(url); err != nil {
+			return c, err
+		}
+	}
+	return c, SaveLock(ldr)
+}
+
+func TestNoLockLeavesRefsAlone(t *testing.T) {
+	fSys := makeLockTestFs(t, "")
+	c, err := loadWithLock(t, fSys, DefaultLockPolicy, remoteBase)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(c.refs) != 1 || c.refs[0] != "v1" {
+		t.Fatalf("unexpected refs %v", c.refs)
+	}
+	if fSys.Exists(lockTopDir + "/" + git.LockFileName) {
+		t.Fatalf("unexpected lock file")
+	}
+}
+
+func TestLockPinsRemoteBases(t *testing.T) {
+	fSys := makeLockTestFs(t, `
+remotes:
+- url: `+remoteBase+`
+  commit: `+oldCommit+`
+- url: `+nestedBase+`
+  commit: `+otherCommit+`
+`)
+	c, err := loadWithLock(t, fSys, LockPolicy{
+		Resolve: fixedResolver(map[string]string{
+			"someOrg/someRepo":  oldCommit,
+			"someOrg/otherRepo": otherCommit,
+		})}, remoteBase, nestedBase)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(c.refs) != 2 || c.refs[0] != oldCommit || c.refs[1] != otherCommit {
+		t.Fatalf("unexpected refs %v", c.refs)
+	}
+
+	// Without a resolver, the lock is honored unchecked.
+	c, err = loadWithLock(t, fSys, LockPolicy{}, remoteBase)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(c.refs) != 1 || c.refs[0] != oldCommit {
+		t.Fatalf("unexpected refs %v", c.refs)
+	}
+}
+
+func TestLockFailsOnDrift(t *testing.T) {
+	fSys := makeLockTestFs(t, `
+remotes:
+- url: `+remoteBase+`
+  commit: `+oldCommit+`
+`)
+	policy := LockPolicy{
+		Resolve: fixedResolver(map[string]string{
+			"someOrg/someRepo":  newCommit,
+			"someOrg/otherRepo": otherCommit,
+		})}
+	_, err := loadWithLock(t, fSys, policy, remoteBase)
+	if err == nil || !strings.Contains(err.Error(), "has drifted to commit "+newCommit) {
+		t.Fatalf("expected drift error, got %v", err)
+	}
+	_, err = loadWithLock(t, fSys, policy, nestedBase)
+	if err == nil || !strings.Contains(err.Error(), "is not pinned") {
+		t.Fatalf("expected missing pin error, got %v", err)
+	}
+}
+
+func TestLockUpdate(t *testing.T) {
+	fSys := makeLockTestFs(t, `
+remotes:
+- url: github.com/someOrg/gone
+  commit: `+oldCommit+`
+- url: `+remoteBase+`
+  commit: `+oldCommit+`
+`)
+	c, err := loadWithLock(t, fSys, LockPolicy{
+		Resolve: fixedResolver(map[string]string{
+			"someOrg/someRepo":  newCommit,
+			"someOrg/otherRepo": otherCommit,
+		}),
+		Update: true}, remoteBase, nestedBase)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(c.refs) != 2 || c.refs[0] != newCommit || c.refs[1] != otherCommit {
+		t.Fatalf("unexpected refs %v", c.refs)
+	}
+	l, err := git.ReadLock(fSys, lockTopDir)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	expected := []git.LockedRemote{
+		{Url: nestedBase, Commit: otherCommit},
+		{Url: remoteBase, Commit: newCommit},
+	}
+	if len(l.Remotes) != len(expected) {
+		t.Fatalf("unexpected lock %v", l.Remotes)
+	}
+	for i := range expected {
+		if l.Remotes[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected[i], l.Remotes[i])
+		}
+	}
+}
+
+func TestMalformedLock(t *testing.T) {
+	fSys := makeLockTestFs(t, `
+remotes:
+- url: `+remoteBase+`
+  commit: v1
+`)
+	_, err := NewLoaderWithLock(
+		RestrictionRootOnly, validators.MakeFakeValidator(),
+		lockTopDir, fSys, git.DoNothingCloner(lockCloneRoot), DefaultLockPolicy)
+	if err == nil || !strings.Contains(err.Error(), "not a full commit hash") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package openapi validates resources against OpenAPI schemas.
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/spec"
+	"github.com/irairdon/kustomize/v3/pkg/gvk"
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/pkg/errors"
+	"k8s.io/kube-openapi/pkg/common"
+	"sigs.k8s.io/yaml"
+)
+
+// Extension naming the kinds an OpenAPI definition describes.
+// The value is a list of objects with group, version and kind fields.
+const xGroupVersionKind = "x-kubernetes-group-version-kind"
+
+// Schemas holds OpenAPI definitions, keyed by definition
+// name (e.g. "k8s.io/api/apps/v1.Deployment"), and
+// knows which definition describes which kind.
+type Schemas struct {
+	// Guards additions, as kustomizations loaded
+	// concurrently may add their crds at once.
+	mu    sync.Mutex
+	defs  map[string]spec.Schema
+	kinds map[gvk.Gvk]string
+}
+
+// NewSchemas returns an empty set of schemas.
+func NewSchemas() *Schemas {
+	return &Schemas{
+		defs:  make(map[string]spec.Schema),
+		kinds: make(map[gvk.Gvk]string),
+	}
+}
+
+// Copy returns a copy of the schemas that may be
+// added to without changing the original.
+func (s *Schemas) Copy() *Schemas {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := NewSchemas()
+	for k, v := range s.defs {
+		c.defs[k] = v
+	}
+	for k, v := range s.kinds {
+		c.kinds[k] = v
+	}
+	return c
+}
+
+// AddDefinition adds a named definition, and records it as
+// the schema for the given kinds.  A kind with an empty group
+// and version matches resources of that kind in any group.
+// Definitions may refer to each other by name with $ref.
+func (s *Schemas) AddDefinition(
+	name string, schema spec.Schema, kinds ...gvk.Gvk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defs[name] = schema
+	for _, k := range kinds {
+		s.kinds[k] = name
+	}
+}
+
+// LoadCrds adds the definitions found in the given files,
+// which have the same format as the files in the 'crds'
+// field of a kustomization.
+func (s *Schemas) LoadCrds(ldr ifc.Loader, paths []string) error {
+	for _, path := range paths {
+		content, err := ldr.Load(path)
+		if err != nil {
+			return err
+		}
+		var m map[string]common.OpenAPIDefinition
+		if len(content) > 0 && content[0] == '{' {
+			err = json.Unmarshal(content, &m)
+		} else {
+			err = yaml.Unmarshal(content, &m)
+		}
+		if err != nil {
+			return errors.Wrapf(
+				err, "unable to parse open API definition from '%s'", path)
+		}
+		for name, api := range m {
+			s.AddDefinition(name, api.Schema, kindsOf(name, api.Schema)...)
+		}
+	}
+	return nil
+}
+
+// kindsOf returns the kinds a definition describes.
+// Definitions that don't look like a k8s type describe
+// no kind; they're only reachable through a $ref.
+func kindsOf(name string, schema spec.Schema) []gvk.Gvk {
+	for _, p := range []string{"kind", "apiVersion", "metadata"} {
+		if _, ok := schema.Properties[p]; !ok {
+			return nil
+		}
+	}
+	var result []gvk.Gvk
+	if l, ok := schema.Extensions[xGroupVersionKind].([]interface{}); ok {
+		for _, x := range l {
+			m, ok := x.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			g, _ := m["group"].(string)
+			v, _ := m["version"].(string)
+			k, _ := m["kind"].(string)
+			if k != "" {
+				result = append(result, gvk.Gvk{Group: g, Version: v, Kind: k})
+			}
+		}
+	}
+	if len(result) == 0 {
+		names := strings.Split(name, ".")
+		result = append(result, gvk.FromKind(names[len(names)-1]))
+	}
+	return result
+}
+
+// schemaFor returns the schema describing the given kind,
+// preferring an exact match over a match on kind alone.
+func (s *Schemas) schemaFor(x gvk.Gvk) (*spec.Schema, bool) {
+	name, ok := s.kinds[x]
+	if !ok {
+		name, ok = s.kinds[gvk.FromKind(x.Kind)]
+	}
+	if !ok {
+		return nil, false
+	}
+	schema, ok := s.defs[name]
+	return &schema, ok
+}
@@ -0,0 +1,271 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"github.com/irairdon/kustomize/v3/pkg/resid"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+)
+
+const (
+	// Format of a string field that also accepts integers.
+	FormatIntOrString = "int-or-string"
+	// Format of a string field holding a resource
+	// quantity, which may also be written as a number.
+	FormatQuantity = "quantity"
+	// Extension marking a field that accepts
+	// either an integer or a string.
+	xIntOrString = "x-kubernetes-int-or-string"
+)
+
+// Problem is one way in which a resource
+// fails to match its schema.
+type Problem struct {
+	Id resid.ResId
+	// Path to the field, e.g. spec.template.spec.containers[0].name
+	Path string
+	Msg  string
+}
+
+func (p Problem) String() string {
+	return p.Id.String() + ": " + p.Path + ": " + p.Msg
+}
+
+// ValidationError lists every problem found
+// when validating a set of resources.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = "  " + p.String()
+	}
+	return fmt.Sprintf(
+		"%d resource schema violation(s):\n%s",
+		len(e.Problems), strings.Join(lines, "\n"))
+}
+
+// Validate checks each resource against the schema for its
+// kind, reporting unknown fields, values of the wrong type
+// and missing required fields.  Resources of kinds without
+// a schema aren't checked.  If any problem is found, the
+// returned error is a *ValidationError.
+func (s *Schemas) Validate(m resmap.ResMap) error {
+	v := validation{schemas: s}
+	for _, r := range m.Resources() {
+		schema, ok := s.schemaFor(r.GetGvk())
+		if !ok {
+			continue
+		}
+		v.id = r.CurId()
+		v.validate("", r.Map(), schema)
+	}
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+type validation struct {
+	schemas  *Schemas
+	id       resid.ResId
+	problems []Problem
+}
+
+func (v *validation) report(path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Id:   v.id,
+		Path: path,
+		Msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+// resolve follows $refs; it returns false if
+// the schema refers to an unknown definition.
+func (v *validation) resolve(schema *spec.Schema) (*spec.Schema, bool) {
+	for seen := 0; schema.Ref.String() != ""; seen++ {
+		def, ok := v.schemas.defs[schema.Ref.String()]
+		if !ok || seen > len(v.schemas.defs) {
+			return nil, false
+		}
+		schema = &def
+	}
+	return schema, true
+}
+
+func (v *validation) validate(
+	path string, value interface{}, schema *spec.Schema) {
+	if value == nil {
+		return
+	}
+	schema, ok := v.resolve(schema)
+	if !ok {
+		return
+	}
+	if isIntOrString(schema) {
+		if !isString(value) && !isInteger(value) {
+			v.report(path, "expected integer or string, got %s", typeName(value))
+		}
+		return
+	}
+	switch schemaType(schema) {
+	case "object":
+		v.validateObject(path, value, schema)
+	case "array":
+		v.validateArray(path, value, schema)
+	case "string":
+		if isString(value) ||
+			(schema.Format == FormatQuantity && isNumber(value)) {
+			return
+		}
+		v.report(path, "expected string, got %s", typeName(value))
+	case "integer":
+		if !isInteger(value) {
+			v.report(path, "expected integer, got %s", typeName(value))
+		}
+	case "number":
+		if !isNumber(value) {
+			v.report(path, "expected number, got %s", typeName(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.report(path, "expected boolean, got %s", typeName(value))
+		}
+	}
+}
+
+func (v *validation) validateObject(
+	path string, value interface{}, schema *spec.Schema) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		v.report(path, "expected object, got %s", typeName(value))
+		return
+	}
+	for _, f := range schema.Required {
+		if m[f] == nil {
+			v.report(join(path, f), "required field is missing")
+		}
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if p, ok := schema.Properties[k]; ok {
+			v.validate(join(path, k), m[k], &p)
+			continue
+		}
+		if ap := schema.AdditionalProperties; ap != nil {
+			if ap.Schema != nil {
+				v.validate(join(path, k), m[k], ap.Schema)
+			} else if !ap.Allows {
+				v.report(join(path, k), "unknown field")
+			}
+			continue
+		}
+		if len(schema.Properties) > 0 {
+			v.report(join(path, k), "unknown field")
+		}
+	}
+}
+
+func (v *validation) validateArray(
+	path string, value interface{}, schema *spec.Schema) {
+	l, ok := value.([]interface{})
+	if !ok {
+		v.report(path, "expected array, got %s", typeName(value))
+		return
+	}
+	if schema.Items == nil || schema.Items.Schema == nil {
+		return
+	}
+	for i, x := range l {
+		v.validate(path+"["+strconv.Itoa(i)+"]", x, schema.Items.Schema)
+	}
+}
+
+// schemaType returns the type of value the schema
+// describes, or the empty string if it allows any value.
+func schemaType(schema *spec.Schema) string {
+	if len(schema.Type) > 0 {
+		return schema.Type[0]
+	}
+	if len(schema.Properties) > 0 || schema.AdditionalProperties != nil {
+		return "object"
+	}
+	if schema.Items != nil {
+		return "array"
+	}
+	return ""
+}
+
+func isIntOrString(schema *spec.Schema) bool {
+	if schema.Format == FormatIntOrString {
+		return true
+	}
+	b, _ := schema.Extensions.GetBool(xIntOrString)
+	return b
+}
+
+func join(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func isString(value interface{}) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int, int32, int64, float32, float64:
+		return true
+	}
+	return false
+}
+
+func isInteger(value interface{}) bool {
+	switch x := value.(type) {
+	case int, int32, int64:
+		return true
+	case float32:
+		return float64(x) == math.Trunc(float64(x))
+	case float64:
+		return x == math.Trunc(x)
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if isInteger(value) {
+		return "integer"
+	}
+	if isNumber(value) {
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
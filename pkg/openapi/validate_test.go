@@ -0,0 +1,164 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package openapi
+
+import (
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/irairdon/kustomize/v3/internal/loadertest"
+	"github.com/irairdon/kustomize/v3/k8sdeps/kunstruct"
+	"github.com/irairdon/kustomize/v3/pkg/resmaptest"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+)
+
+const crds = `
+{
+  "example.com/v1.Bee": {
+    "Schema": {
+      "required": ["spec"],
+      "properties": {
+        "apiVersion": {"type": "string"},
+        "kind": {"type": "string"},
+        "metadata": {"type": "object"},
+        "spec": {"$ref": "example.com/v1.BeeSpec"}
+      },
+      "x-kubernetes-group-version-kind": [
+        {"group": "example.com", "version": "v1", "kind": "Bee"}
+      ]
+    }
+  },
+  "example.com/v1.BeeSpec": {
+    "Schema": {
+      "required": ["action"],
+      "properties": {
+        "action": {"type": "string"},
+        "wings": {"type": "integer"},
+        "port": {"type": "string", "format": "int-or-string"},
+        "flowers": {
+          "type": "array",
+          "items": {"$ref": "example.com/v1.Flower"}
+        },
+        "labels": {
+          "type": "object",
+          "additionalProperties": {"type": "string"}
+        },
+        "anything": {"$ref": "example.com/v1.Unknown"}
+      }
+    }
+  },
+  "example.com/v1.Flower": {
+    "Schema": {
+      "properties": {
+        "color": {"type": "string"},
+        "open": {"type": "boolean"}
+      }
+    }
+  },
+  "example.com/v1.Hive": {
+    "Schema": {
+      "properties": {
+        "apiVersion": {"type": "string"},
+        "kind": {"type": "string"},
+        "metadata": {"type": "object"},
+        "size": {"type": "number"}
+      }
+    }
+  }
+}
+`
+
+func makeSchemas(t *testing.T) *Schemas {
+	ldr := loadertest.NewFakeLoader("/app")
+	ldr.AddFile("/app/crds.json", []byte(crds))
+	s := NewSchemas()
+	err := s.LoadCrds(ldr, []string{"crds.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func TestValidateValid(t *testing.T) {
+	rf := resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl())
+	m := resmaptest_test.NewRmBuilder(t, rf).
+		Add(map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Bee",
+			"metadata":   map[string]interface{}{"name": "bee"},
+			"spec": map[string]interface{}{
+				"action": "fly",
+				"wings":  4,
+				"port":   8080,
+				"flowers": []interface{}{
+					map[string]interface{}{"color": "red", "open": true},
+				},
+				"labels":   map[string]interface{}{"a": "b"},
+				"anything": []interface{}{1, "x"},
+			},
+		}).
+		Add(map[string]interface{}{
+			// No schema for this kind, so anything goes.
+			"apiVersion": "example.com/v1",
+			"kind":       "Wasp",
+			"metadata":   map[string]interface{}{"name": "wasp"},
+			"whatever":   1,
+		}).ResMap()
+	err := makeSchemas(t).Validate(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateProblems(t *testing.T) {
+	rf := resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl())
+	m := resmaptest_test.NewRmBuilder(t, rf).
+		Add(map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Bee",
+			"metadata":   map[string]interface{}{"name": "bee"},
+			"spec": map[string]interface{}{
+				"wings": "four",
+				"port":  true,
+				"flowers": []interface{}{
+					map[string]interface{}{"color": "red", "petals": 5},
+				},
+				"labels": map[string]interface{}{"a": 1},
+			},
+		}).
+		Add(map[string]interface{}{
+			// Matched on kind alone, as the definition
+			// has no group-version-kind extension.
+			"apiVersion": "other.com/v2",
+			"kind":       "Hive",
+			"metadata":   map[string]interface{}{"name": "hive"},
+			"size":       "big",
+		}).ResMap()
+	err := makeSchemas(t).Validate(m)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	expected := `6 resource schema violation(s):
+  example.com_v1_Bee|~X|bee: spec.action: required field is missing
+  example.com_v1_Bee|~X|bee: spec.flowers[0].petals: unknown field
+  example.com_v1_Bee|~X|bee: spec.labels.a: expected string, got integer
+  example.com_v1_Bee|~X|bee: spec.port: expected integer or string, got boolean
+  example.com_v1_Bee|~X|bee: spec.wings: expected integer, got string
+  other.com_v2_Hive|~X|hive: size: expected number, got string`
+	if err.Error() != expected {
+		t.Fatalf("expected\n%s\nbut got\n%s", expected, err.Error())
+	}
+	if len(err.(*ValidationError).Problems) != 6 {
+		t.Fatalf("unexpected problems: %v", err.(*ValidationError).Problems)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	s := makeSchemas(t)
+	c := s.Copy()
+	c.AddDefinition("example.com/v1.Other", spec.Schema{})
+	if _, ok := s.defs["example.com/v1.Other"]; ok {
+		t.Fatalf("copy changed the original")
+	}
+}
@@ -27,6 +27,8 @@ import (
 const (
 	XDG_CONFIG_HOME     = "XDG_CONFIG_HOME"
 	defaultConfigSubdir = ".config"
+	XDG_CACHE_HOME      = "XDG_CACHE_HOME"
+	defaultCacheSubdir  = ".cache"
 	PluginRoot          = "plugin"
 )
 
@@ -40,6 +42,17 @@ func ConfigRoot() string {
 	return filepath.Join(dir, ProgramName)
 }
 
+// CacheRoot returns the directory holding data kustomize
+// keeps between runs, but can recreate if it's deleted.
+func CacheRoot() string {
+	dir := os.Getenv(XDG_CACHE_HOME)
+	if len(dir) == 0 {
+		dir = filepath.Join(
+			HomeDir(), defaultCacheSubdir)
+	}
+	return filepath.Join(dir, ProgramName)
+}
+
 func HomeDir() string {
 	home := os.Getenv(homeEnv())
 	if len(home) > 0 {
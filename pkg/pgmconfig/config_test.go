@@ -54,3 +54,17 @@ func TestConfigDirWithXdg(t *testing.T) {
 		t.Fatalf("unexpected config dir: %s", s)
 	}
 }
+
+func TestCacheDirWithXdg(t *testing.T) {
+	xdg, isSet := os.LookupEnv(XDG_CACHE_HOME)
+	os.Setenv(XDG_CACHE_HOME, rootedPath("blah"))
+	s := CacheRoot()
+	if isSet {
+		os.Setenv(XDG_CACHE_HOME, xdg)
+	} else {
+		os.Unsetenv(XDG_CACHE_HOME)
+	}
+	if s != rootedPath("blah", ProgramName) {
+		t.Fatalf("unexpected cache dir: %s", s)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"plugin"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/kr/pretty"
 
@@ -143,9 +144,15 @@ func (l *Loader) loadPlugin(resId resid.ResId) (Configurable, error) {
 // but the loaded .so files are in shared memory, so one will get
 // "this plugin already loaded" errors if the registry is maintained
 // as a Loader instance variable.  So make it a package variable.
-var registry = make(map[string]Configurable)
+// It's guarded, as kustomizations may load concurrently.
+var (
+	registry   = make(map[string]Configurable)
+	registryMu sync.Mutex
+)
 
 func (l *Loader) loadGoPlugin(id resid.ResId) (Configurable, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
 	regId := relativePluginPath(id)
 	if c, ok := registry[regId]; ok {
 		return copyPlugin(c), nil
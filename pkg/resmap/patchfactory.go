@@ -12,4 +12,10 @@ import (
 type PatchFactory interface {
 	MergePatches(patches []*resource.Resource,
 		rf *resource.Factory) (ResMap, error)
+
+	// DerivePatch returns the strategic merge patch that
+	// turns original into modified, or false if the kind
+	// has no known patch strategy.
+	DerivePatch(original, modified *resource.Resource) (
+		map[string]interface{}, bool, error)
 }
@@ -0,0 +1,62 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package resource
+
+import (
+	"strings"
+)
+
+const (
+	// Annotation that, set to "true", asks for a hash suffix
+	// on the name of a resource, as generated ConfigMaps and
+	// Secrets get.  Build removes it from the output.
+	NeedsHashAnnotation = "kustomize.config.k8s.io/needs-hash"
+
+	// Annotation listing, comma separated, the builtin
+	// transformers that must leave a resource alone, e.g.
+	//
+	//   kustomize.config.k8s.io/skip: namespace,prefix,labels
+	//
+	// Build removes it from the output.
+	SkipAnnotation = "kustomize.config.k8s.io/skip"
+
+	// Values recognized in the SkipAnnotation.
+	SkipNamespace = "namespace"
+	SkipPrefix    = "prefix"
+	SkipSuffix    = "suffix"
+	SkipLabels    = "labels"
+	SkipHash      = "hash"
+)
+
+// Skips is true if the resource's SkipAnnotation
+// names the given transformer.
+func (r *Resource) Skips(transformer string) bool {
+	v, ok := r.GetAnnotations()[SkipAnnotation]
+	if !ok {
+		return false
+	}
+	for _, s := range strings.Split(v, ",") {
+		if strings.TrimSpace(s) == transformer {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveBuildAnnotations removes the annotations that
+// only direct the build, and the annotations field if
+// nothing else is left.
+func (r *Resource) RemoveBuildAnnotations() {
+	a := r.GetAnnotations()
+	n := len(a)
+	delete(a, SkipAnnotation)
+	delete(a, NeedsHashAnnotation)
+	if len(a) == n {
+		return
+	}
+	if len(a) == 0 {
+		a = nil
+	}
+	r.SetAnnotations(a)
+}
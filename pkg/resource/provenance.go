@@ -0,0 +1,133 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package resource
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Annotation naming the file a resource was read from.
+	ProvenanceFileAnnotation = "kustomize.config.k8s.io/ProvenanceFile"
+
+	// Annotation naming the generator that made a resource.
+	ProvenanceGeneratorAnnotation = "kustomize.config.k8s.io/ProvenanceGenerator"
+
+	// Annotation listing the kustomization roots a resource
+	// passed through, innermost first.
+	ProvenanceRootsAnnotation = "kustomize.config.k8s.io/ProvenanceRoots"
+
+	// Annotation listing the transformers, including patches,
+	// that changed a resource, in the order they ran.
+	ProvenanceTransformersAnnotation = "kustomize.config.k8s.io/ProvenanceTransformers"
+)
+
+// ProvenanceStep names a generator or transformer, and
+// the root of the kustomization that ran it.
+type ProvenanceStep struct {
+	Name string
+	Root string
+}
+
+// Provenance records where a Resource came from,
+// and what changed it on its way to the output.
+type Provenance struct {
+	// File the resource was read from; empty if generated.
+	File string
+	// Generator that made the resource, if any.
+	Generator *ProvenanceStep
+	// Roots of the kustomizations the resource passed
+	// through, innermost first.
+	Roots []string
+	// Transformers that changed the resource, in order.
+	Transformers []ProvenanceStep
+}
+
+func (p Provenance) copy() Provenance {
+	result := p
+	if p.Generator != nil {
+		g := *p.Generator
+		result.Generator = &g
+	}
+	result.Roots = copyStringSlice(p.Roots)
+	if p.Transformers != nil {
+		result.Transformers = make([]ProvenanceStep, len(p.Transformers))
+		copy(result.Transformers, p.Transformers)
+	}
+	return result
+}
+
+// GetProvenance returns the provenance record of the resource.
+func (r *Resource) GetProvenance() Provenance {
+	return r.provenance.copy()
+}
+
+// SetProvenanceFile records the file the resource was read from.
+func (r *Resource) SetProvenanceFile(path string) {
+	r.provenance.File = path
+}
+
+// SetProvenanceGenerator records the generator that made the resource.
+func (r *Resource) SetProvenanceGenerator(name, root string) {
+	r.provenance.Generator = &ProvenanceStep{Name: name, Root: root}
+}
+
+// AppendProvenanceRoot records a kustomization root
+// the resource passed through.
+func (r *Resource) AppendProvenanceRoot(root string) {
+	r.provenance.Roots = append(r.provenance.Roots, root)
+}
+
+// AppendProvenanceTransformer records a transformer
+// that changed the resource.
+func (r *Resource) AppendProvenanceTransformer(name, root string) {
+	r.provenance.Transformers = append(
+		r.provenance.Transformers, ProvenanceStep{Name: name, Root: root})
+}
+
+// AddProvenanceAnnotations writes the provenance record
+// into the resource's annotations.  Paths are written
+// relative to the given directory, so that output doesn't
+// depend on where the kustomization was found.
+func (r *Resource) AddProvenanceAnnotations(dir string) {
+	p := r.provenance
+	add := make(map[string]string)
+	if p.File != "" {
+		add[ProvenanceFileAnnotation] = relativePath(dir, p.File)
+	}
+	if p.Generator != nil {
+		add[ProvenanceGeneratorAnnotation] = p.Generator.relativeTo(dir)
+	}
+	if len(p.Roots) > 0 {
+		roots := make([]string, len(p.Roots))
+		for i, root := range p.Roots {
+			roots[i] = relativePath(dir, root)
+		}
+		add[ProvenanceRootsAnnotation] = strings.Join(roots, ",")
+	}
+	if len(p.Transformers) > 0 {
+		steps := make([]string, len(p.Transformers))
+		for i, s := range p.Transformers {
+			steps[i] = s.relativeTo(dir)
+		}
+		add[ProvenanceTransformersAnnotation] = strings.Join(steps, ",")
+	}
+	if len(add) == 0 {
+		return
+	}
+	r.SetAnnotations(mergeStringMaps(r.GetAnnotations(), add))
+}
+
+func (s ProvenanceStep) relativeTo(dir string) string {
+	return s.Name + "@" + relativePath(dir, s.Root)
+}
+
+func relativePath(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
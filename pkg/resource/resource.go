@@ -25,6 +25,7 @@ type Resource struct {
 	refVarNames  []string
 	namePrefixes []string
 	nameSuffixes []string
+	provenance   Provenance
 }
 
 // ResCtx is an interface describing the contextual added
@@ -70,6 +71,7 @@ func (r *Resource) copyOtherFields(other *Resource) {
 	r.refVarNames = copyStringSlice(other.refVarNames)
 	r.namePrefixes = copyStringSlice(other.namePrefixes)
 	r.nameSuffixes = copyStringSlice(other.nameSuffixes)
+	r.provenance = other.provenance.copy()
 }
 
 func (r *Resource) Equals(o *Resource) bool {
@@ -249,7 +251,8 @@ func (r *Resource) Behavior() types.GenerationBehavior {
 
 // NeedHashSuffix checks if the resource need a hash suffix
 func (r *Resource) NeedHashSuffix() bool {
-	return r.options != nil && r.options.NeedsHashSuffix()
+	return (r.options != nil && r.options.NeedsHashSuffix()) ||
+		r.GetAnnotations()[NeedsHashAnnotation] == "true"
 }
 
 // GetNamespace returns the namespace the resource thinks it's in.
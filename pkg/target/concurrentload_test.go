@@ -0,0 +1,132 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	kusttest_test "github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+// writeWideTree writes a kustomization with many sibling
+// bases, each with files and a base of its own, so
+// that loading them concurrently could reorder things.
+func writeWideTree(th *kusttest_test.KustTestHarness) {
+	var resources []string
+	for i := 0; i < 12; i++ {
+		dir := fmt.Sprintf("/app/team%02d", i)
+		resources = append(resources, fmt.Sprintf("- team%02d", i))
+		th.WriteK(dir, fmt.Sprintf(`
+namePrefix: t%02d-
+resources:
+- ../common/base%d
+- deployment.yaml
+- service.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - team=%02d
+`, i, i%3, i))
+		th.WriteF(dir+"/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+`)
+		th.WriteF(dir+"/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+`)
+	}
+	for i := 0; i < 3; i++ {
+		dir := fmt.Sprintf("/app/common/base%d", i)
+		th.WriteK(dir, `
+resources:
+- role.yaml
+`)
+		th.WriteF(dir+"/role.yaml", fmt.Sprintf(`
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: reader%d
+`, i))
+	}
+	th.WriteK("/app/all", `
+resources:
+`+strings.Join(resources, "\n")+`
+- extra.yaml
+`)
+	th.WriteF("/app/all/extra.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: extra
+`)
+	// Bases must be below the kustomization using them.
+	for i := 0; i < 12; i++ {
+		th.WriteF(fmt.Sprintf("/app/all/team%02d/kustomization.yaml", i),
+			fmt.Sprintf("resources:\n- ../../team%02d\n", i))
+	}
+}
+
+func buildWideTree(
+	t *testing.T, th *kusttest_test.KustTestHarness, loads int) string {
+	kt := th.MakeKustTarget()
+	kt.SetMaxConcurrentLoads(loads)
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := m.AsYaml()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(b)
+}
+
+func TestConcurrentLoadsMatchSerialLoads(t *testing.T) {
+	th := kusttest_test.NewKustTestNoLoadRestrictorHarness(t, "/app/all")
+	writeWideTree(th)
+	expected := buildWideTree(t, th, 1)
+	if !strings.Contains(expected, "t11-settings-") ||
+		strings.Index(expected, "t00-app") > strings.Index(expected, "t01-app") {
+		t.Fatalf("unexpected serial output:\n%s", expected)
+	}
+	for i := 0; i < 20; i++ {
+		if actual := buildWideTree(t, th, 8); actual != expected {
+			t.Fatalf("concurrent build differs from serial build; got\n%s\nexpected\n%s",
+				actual, expected)
+		}
+	}
+}
+
+func TestConcurrentLoadsReportFirstError(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- a
+- b.yaml
+- c.yaml
+`)
+	th.WriteK("/app/a", `
+resources:
+- missing-a.yaml
+`)
+	th.WriteF("/app/b.yaml", "kind: Broken\n\tindented: badly\n")
+	for i := 0; i < 10; i++ {
+		kt := th.MakeKustTarget()
+		kt.SetMaxConcurrentLoads(4)
+		_, err := kt.MakeCustomizedResMap()
+		if err == nil {
+			t.Fatalf("expected error")
+		}
+		if !strings.Contains(err.Error(), "missing-a.yaml") {
+			t.Fatalf("expected error for the first path, got %v", err)
+		}
+	}
+}
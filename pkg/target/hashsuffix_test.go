@@ -0,0 +1,99 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+)
+
+func writeHashSuffixApp(th *kusttest_test.KustTestHarness, owner string) {
+	th.WriteK("/app", `
+namePrefix: p-
+resources:
+- resources.yaml
+configurations:
+- config.yaml
+`)
+	th.WriteF("/app/config.yaml", `
+nameReference:
+- kind: AppConfig
+  group: example.com
+  fieldSpecs:
+  - path: spec/template/spec/volumes/appConfig/name
+    kind: Deployment
+hashFields:
+- path: spec/settings
+  kind: AppConfig
+`)
+	th.WriteF("/app/resources.yaml", `
+apiVersion: example.com/v1
+kind: AppConfig
+metadata:
+  name: settings
+  annotations:
+    kustomize.config.k8s.io/needs-hash: "true"
+spec:
+  owner: `+owner+`
+  settings:
+    color: blue
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      volumes:
+      - name: settings
+        appConfig:
+          name: settings
+`)
+}
+
+// Any kind can ask for a hash suffix, referrers
+// follow it, and the hash covers only the fields
+// configured for the kind.
+func TestHashSuffixOnAnyKind(t *testing.T) {
+	build := func(owner string) resmap.ResMap {
+		th := kusttest_test.NewKustTestHarness(t, "/app")
+		writeHashSuffixApp(th, owner)
+		m, err := th.MakeKustTarget().MakeCustomizedResMap()
+		if err != nil {
+			t.Fatalf("Err: %v", err)
+		}
+		return m
+	}
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	m := build("alice")
+	th.AssertActualEqualsExpected(m, `
+apiVersion: example.com/v1
+kind: AppConfig
+metadata:
+  name: p-settings-h8bc664k67
+spec:
+  owner: alice
+  settings:
+    color: blue
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: p-web
+spec:
+  template:
+    spec:
+      volumes:
+      - appConfig:
+          name: p-settings-h8bc664k67
+        name: settings
+`)
+	other := build("bob")
+	if m.Resources()[0].GetName() != other.Resources()[0].GetName() {
+		t.Fatalf("a field the hash doesn't cover changed the name")
+	}
+}
@@ -10,11 +10,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/irairdon/kustomize/v3/pkg/accumulator"
+	"github.com/irairdon/kustomize/v3/pkg/git"
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/openapi"
 	"github.com/irairdon/kustomize/v3/pkg/pgmconfig"
 	"github.com/irairdon/kustomize/v3/pkg/plugins"
 	"github.com/irairdon/kustomize/v3/pkg/resmap"
@@ -32,6 +36,19 @@ type KustTarget struct {
 	rFactory      *resmap.Factory
 	tFactory      resmap.PatchFactory
 	pLdr          *plugins.Loader
+	// If true, record and annotate where each resource
+	// came from and what changed it.
+	recordProvenance bool
+	// If not nil, the output is validated against these
+	// schemas, plus those named in the crds field of
+	// every kustomization in the build.
+	schemas *openapi.Schemas
+	// Bounds the resources and bases loaded concurrently,
+	// across all the kustomizations in the build.
+	slots loadSlots
+	// If not nil, collects configuration that has no
+	// effect, failing the build if there's any.
+	strict *strictReport
 }
 
 // NewKustTarget returns a new instance of KustTarget primed with a Loader.
@@ -63,9 +80,25 @@ func NewKustTarget(
 		rFactory:      rFactory,
 		tFactory:      tFactory,
 		pLdr:          pLdr,
+		slots:         newLoadSlots(DefaultMaxConcurrentLoads),
 	}, nil
 }
 
+// EnableSchemaValidation makes the target check its output
+// against the given schemas, failing the build if any
+// resource has unknown fields, values of the wrong type
+// or missing required fields.
+func (kt *KustTarget) EnableSchemaValidation(s *openapi.Schemas) {
+	kt.schemas = s.Copy()
+}
+
+// SetMaxConcurrentLoads bounds how many resource files
+// and bases the build loads at once.  One means load
+// them one after another.
+func (kt *KustTarget) SetMaxConcurrentLoads(n int) {
+	kt.slots = newLoadSlots(n)
+}
+
 func quoted(l []string) []string {
 	r := make([]string, len(l))
 	for i, v := range l {
@@ -123,6 +156,9 @@ func (kt *KustTarget) MakePruneConfigMap() (resmap.ResMap, error) {
 
 func (kt *KustTarget) makeCustomizedResMap(
 	garbagePolicy types.GarbagePolicy) (resmap.ResMap, error) {
+	if kt.strict != nil {
+		kt.strict.reset()
+	}
 	ra, err := kt.AccumulateTarget()
 	if err != nil {
 		return nil, err
@@ -149,22 +185,54 @@ func (kt *KustTarget) makeCustomizedResMap(
 		return nil, err
 	}
 
+	if kt.strict != nil {
+		for _, v := range ra.UnusedVars() {
+			kt.strict.add("var " + v + " is never referenced")
+		}
+		err = kt.strict.err()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Opt-ins and opt-outs matter only while transforming.
+	for _, r := range ra.ResMap().Resources() {
+		r.RemoveBuildAnnotations()
+	}
+
 	err = kt.computeInventory(ra, garbagePolicy)
 	if err != nil {
 		return nil, err
 	}
 
+	if kt.schemas != nil {
+		err = kt.schemas.Validate(ra.ResMap())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if kt.recordProvenance {
+		for _, r := range ra.ResMap().Resources() {
+			r.AddProvenanceAnnotations(kt.ldr.Root())
+		}
+	}
+
 	return ra.ResMap(), nil
 }
 
 func (kt *KustTarget) addHashesToNames(
 	ra *accumulator.ResAccumulator) error {
+	var c struct {
+		FieldSpecs []config.FieldSpec
+	}
+	c.FieldSpecs = ra.GetTransformerConfig().HashFields
 	p := builtin.NewHashTransformerPlugin()
-	err := kt.configureBuiltinPlugin(p, nil, "hash")
+	err := kt.configureBuiltinPlugin(p, c, "hash")
 	if err != nil {
 		return err
 	}
-	return ra.Transform(p)
+	return ra.Transform(kt.maybeRecordTransformer(p, pluginName(p)))
 }
 
 func (kt *KustTarget) computeInventory(
@@ -233,6 +301,13 @@ func (kt *KustTarget) AccumulateTarget() (
 		return nil, errors.Wrapf(
 			err, "merging CRDs %v", crdTc)
 	}
+	if kt.schemas != nil {
+		err = kt.schemas.LoadCrds(kt.ldr, kt.kustomization.Crds)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "loading CRD schemas %v", kt.kustomization.Crds)
+		}
+	}
 	err = kt.runGenerators(ra)
 	if err != nil {
 		return nil, err
@@ -246,6 +321,11 @@ func (kt *KustTarget) AccumulateTarget() (
 		return nil, errors.Wrapf(
 			err, "merging vars %v", kt.kustomization.Vars)
 	}
+	if kt.recordProvenance {
+		for _, r := range ra.ResMap().Resources() {
+			r.AppendProvenanceRoot(kt.ldr.Root())
+		}
+	}
 	return ra, nil
 }
 
@@ -256,7 +336,7 @@ func (kt *KustTarget) runGenerators(
 		return err
 	}
 	for _, g := range generators {
-		resMap, err := g.Generate()
+		resMap, err := kt.maybeRecordGenerator(g, pluginName(g)).Generate()
 		if err != nil {
 			return err
 		}
@@ -289,7 +369,14 @@ func (kt *KustTarget) configureExternalGenerators() ([]transformers.Generator, e
 	if err != nil {
 		return nil, err
 	}
-	return kt.pLdr.LoadGenerators(kt.ldr, ra.ResMap())
+	result, err := kt.pLdr.LoadGenerators(kt.ldr, ra.ResMap())
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range ra.ResMap().Resources() {
+		result[i] = kt.maybeRecordGenerator(result[i], configName(c))
+	}
+	return result, nil
 }
 
 func (kt *KustTarget) runTransformers(ra *accumulator.ResAccumulator) error {
@@ -299,7 +386,10 @@ func (kt *KustTarget) runTransformers(ra *accumulator.ResAccumulator) error {
 	if err != nil {
 		return err
 	}
-	r = append(r, lts...)
+	for _, t := range lts {
+		r = append(r, kt.maybeRecordTransformer(
+			kt.maybeCheckTransformer(t, pluginName(t)), pluginName(t)))
+	}
 	lts, err = kt.configureExternalTransformers()
 	if err != nil {
 		return err
@@ -315,62 +405,122 @@ func (kt *KustTarget) configureExternalTransformers() ([]transformers.Transforme
 	if err != nil {
 		return nil, err
 	}
-	return kt.pLdr.LoadTransformers(kt.ldr, ra.ResMap())
+	result, err := kt.pLdr.LoadTransformers(kt.ldr, ra.ResMap())
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range ra.ResMap().Resources() {
+		result[i] = kt.maybeRecordTransformer(
+			kt.maybeCheckTransformer(result[i], configName(c)), configName(c))
+	}
+	return result, nil
 }
 
 // accumulateResources fills the given resourceAccumulator
 // with resources read from the given list of paths.
+// The paths are loaded concurrently, as free load slots
+// allow, but merged in order, so that the result is the
+// same as if they'd been loaded one after another.
 func (kt *KustTarget) accumulateResources(
 	ra *accumulator.ResAccumulator, paths []string) error {
-	for _, path := range paths {
-		ldr, err := kt.ldr.New(path)
-		if err == nil {
-			err = kt.accumulateDirectory(ra, ldr, path)
+	loaded := make([]loadedPath, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		if !kt.slots.tryAcquire() {
+			loaded[i] = kt.loadPath(path)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			defer kt.slots.release()
+			loaded[i] = kt.loadPath(path)
+		}(i, path)
+	}
+	wg.Wait()
+	for i, path := range paths {
+		l := loaded[i]
+		if l.err != nil {
+			return l.err
+		}
+		if l.subRa != nil {
+			err := ra.MergeAccumulator(l.subRa)
 			if err != nil {
-				return err
-			}
-		} else {
-			err2 := kt.accumulateFile(ra, path)
-			if err2 != nil {
-				// Log ldr.New() error to highlight git failures.
-				log.Print(err.Error())
-				return err2
+				return errors.Wrapf(
+					err, "recursed merging from path '%s'", path)
 			}
+			continue
+		}
+		err := ra.AppendAll(l.resources)
+		if err != nil {
+			return errors.Wrapf(err, "merging resources from '%s'", path)
 		}
 	}
 	return nil
 }
 
+// loadedPath holds what was loaded from an entry
+// in a kustomization's list of resources.
+type loadedPath struct {
+	// Set if the path is a kustomization directory.
+	subRa *accumulator.ResAccumulator
+	// Set if the path is a file of resources.
+	resources resmap.ResMap
+	err       error
+}
+
+func (kt *KustTarget) loadPath(path string) loadedPath {
+	ldr, err := kt.ldr.New(path)
+	if err == nil {
+		subRa, err := kt.accumulateDirectory(ldr, path)
+		return loadedPath{subRa: subRa, err: err}
+	}
+	if _, errNotRemote := git.NewRepoSpecFromUrl(path); errNotRemote == nil {
+		// A remote base, e.g. unreachable or drifted from its lock.
+		return loadedPath{
+			err: errors.Wrapf(err, "loading remote base '%s'", path)}
+	}
+	resources, err2 := kt.loadFile(path)
+	if err2 != nil {
+		// Log ldr.New() error to highlight git failures.
+		log.Print(err.Error())
+	}
+	return loadedPath{resources: resources, err: err2}
+}
+
 func (kt *KustTarget) accumulateDirectory(
-	ra *accumulator.ResAccumulator, ldr ifc.Loader, path string) error {
+	ldr ifc.Loader, path string) (*accumulator.ResAccumulator, error) {
 	defer ldr.Cleanup()
 	subKt, err := NewKustTarget(
 		ldr, kt.rFactory, kt.tFactory, kt.pLdr)
 	if err != nil {
-		return errors.Wrapf(err, "couldn't make target for path '%s'", path)
+		return nil, errors.Wrapf(err, "couldn't make target for path '%s'", path)
 	}
+	subKt.recordProvenance = kt.recordProvenance
+	subKt.schemas = kt.schemas
+	subKt.slots = kt.slots
+	subKt.strict = kt.strict
 	subRa, err := subKt.AccumulateTarget()
 	if err != nil {
-		return errors.Wrapf(
+		return nil, errors.Wrapf(
 			err, "recursed accumulation of path '%s'", path)
 	}
-	err = ra.MergeAccumulator(subRa)
-	if err != nil {
-		return errors.Wrapf(
-			err, "recursed merging from path '%s'", path)
-	}
-	return nil
+	return subRa, nil
 }
 
-func (kt *KustTarget) accumulateFile(
-	ra *accumulator.ResAccumulator, path string) error {
+func (kt *KustTarget) loadFile(path string) (resmap.ResMap, error) {
 	resources, err := kt.rFactory.FromFile(kt.ldr, path)
 	if err != nil {
-		return errors.Wrapf(err, "accumulating resources from '%s'", path)
+		return nil, errors.Wrapf(err, "accumulating resources from '%s'", path)
 	}
-	err = ra.AppendAll(resources)
-	if err != nil {
-		return errors.Wrapf(err, "merging resources from '%s'", path)
+	if kt.recordProvenance {
+		file := path
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(kt.ldr.Root(), file)
+		}
+		for _, r := range resources.Resources() {
+			r.SetProvenanceFile(file)
+		}
 	}
-	return nil
+	return resources, nil
 }
@@ -72,6 +72,7 @@ func (kt *KustTarget) configureBuiltinTransformers(
 		kt.configureBuiltinPatchJson6902Transformer,
 		kt.configureBuiltinReplicaCountTransformer,
 		kt.configureBuiltinImageTagTransformer,
+		kt.configureBuiltinReplacementTransformer,
 	}
 	var result []transformers.Transformer
 	for _, f := range configurators {
@@ -133,9 +134,11 @@ func (kt *KustTarget) configureBuiltinNamespaceTransformer(
 	var c struct {
 		types.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
 		FieldSpecs       []config.FieldSpec
+		NameReference    []config.NameBackReferences
 	}
 	c.Namespace = kt.kustomization.Namespace
 	c.FieldSpecs = tConfig.NameSpace
+	c.NameReference = tConfig.NameReference
 	p := builtin.NewNamespaceTransformerPlugin()
 	err = kt.configureBuiltinPlugin(p, c, "namespace")
 	if err != nil {
@@ -227,9 +230,49 @@ func (kt *KustTarget) configureBuiltinLabelTransformer(
 		return nil, err
 	}
 	result = append(result, p)
+	for _, label := range kt.kustomization.Labels {
+		c.Labels = label.Pairs
+		c.FieldSpecs = labelFieldSpecs(label, tConfig.CommonLabels)
+		p := builtin.NewLabelTransformerPlugin()
+		err = kt.configureBuiltinPlugin(p, c, "label")
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
 	return
 }
 
+const metaLabels = "metadata/labels"
+
+// podTemplateLabels are the paths to the labels of the pods
+// that a resource makes, which includeTemplates applies to.
+var podTemplateLabels = map[string]bool{
+	"spec/template/metadata/labels":                  true,
+	"spec/jobTemplate/spec/template/metadata/labels": true,
+}
+
+// labelFieldSpecs picks the fields an entry in labels
+// applies to from those commonLabels applies to.
+func labelFieldSpecs(
+	label types.Label, common []config.FieldSpec) []config.FieldSpec {
+	result := []config.FieldSpec{{Path: metaLabels, CreateIfNotPresent: true}}
+	for _, fs := range common {
+		if fs.Path == metaLabels {
+			continue
+		}
+		// Pod templates hold the labels of the pods a resource
+		// makes; all else that commonLabels touches, e.g. a
+		// selector or a StatefulSet's volumeClaimTemplates,
+		// is left to includeSelectors.
+		isTemplate := podTemplateLabels[fs.Path]
+		if label.IncludeSelectors || (label.IncludeTemplates && isTemplate) {
+			result = append(result, fs)
+		}
+	}
+	return append(result, label.FieldSpecs...)
+}
+
 func (kt *KustTarget) configureBuiltinAnnotationsTransformer(
 	tConfig *config.TransformerConfig) (
 	result []transformers.Transformer, err error) {
@@ -292,12 +335,16 @@ func (kt *KustTarget) configureBuiltinReplicaCountTransformer(
 	tConfig *config.TransformerConfig) (
 	result []transformers.Transformer, err error) {
 	var c struct {
-		Replica    types.Replica
-		FieldSpecs []config.FieldSpec
+		Replica               types.Replica
+		FieldSpecs            []config.FieldSpec
+		MinReplicasFieldSpecs []config.FieldSpec
+		MaxReplicasFieldSpecs []config.FieldSpec
 	}
 	for _, args := range kt.kustomization.Replicas {
 		c.Replica = args
 		c.FieldSpecs = tConfig.Replicas
+		c.MinReplicasFieldSpecs = tConfig.MinReplicas
+		c.MaxReplicasFieldSpecs = tConfig.MaxReplicas
 		p := builtin.NewReplicaCountTransformerPlugin()
 		err = kt.configureBuiltinPlugin(p, c, "replica")
 		if err != nil {
@@ -308,6 +355,28 @@ func (kt *KustTarget) configureBuiltinReplicaCountTransformer(
 	return
 }
 
+// Replacements run after the other builtin transformers,
+// so they copy values as this kustomization leaves them,
+// e.g. names with their prefix.
+func (kt *KustTarget) configureBuiltinReplacementTransformer(
+	tConfig *config.TransformerConfig) (
+	result []transformers.Transformer, err error) {
+	if len(kt.kustomization.Replacements) == 0 {
+		return
+	}
+	var c struct {
+		Replacements []types.Replacement
+	}
+	c.Replacements = kt.kustomization.Replacements
+	p := builtin.NewReplacementTransformerPlugin()
+	err = kt.configureBuiltinPlugin(p, c, "replacement")
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, p)
+	return
+}
+
 func (kt *KustTarget) configureBuiltinPlugin(
 	p plugins.Configurable, c interface{}, id string) (err error) {
 	var y []byte
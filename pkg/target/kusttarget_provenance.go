@@ -0,0 +1,100 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/transformers"
+)
+
+// EnableProvenance makes the target record where each
+// resource came from - the file, the kustomization roots
+// it passed through, and the generators and transformers
+// that made or changed it - and write that record into
+// the annotations of the output resources.
+// See the resource.Provenance*Annotation constants.
+func (kt *KustTarget) EnableProvenance() {
+	kt.recordProvenance = true
+}
+
+// maybeRecordGenerator wraps the generator so that its
+// output is marked with its name, if recording provenance.
+func (kt *KustTarget) maybeRecordGenerator(
+	g transformers.Generator, name string) transformers.Generator {
+	if !kt.recordProvenance {
+		return g
+	}
+	return &recordingGenerator{delegate: g, name: name, root: kt.ldr.Root()}
+}
+
+// maybeRecordTransformer wraps the transformer so that the
+// resources it changes are marked with its name, if
+// recording provenance.
+func (kt *KustTarget) maybeRecordTransformer(
+	t transformers.Transformer, name string) transformers.Transformer {
+	if !kt.recordProvenance {
+		return t
+	}
+	return &recordingTransformer{delegate: t, name: name, root: kt.ldr.Root()}
+}
+
+// pluginName returns the name of a builtin plugin
+// as used in the kind field of its configuration.
+func pluginName(p interface{}) string {
+	return strings.TrimSuffix(
+		reflect.Indirect(reflect.ValueOf(p)).Type().Name(), "Plugin")
+}
+
+// configName names an external plugin by its config.
+func configName(c *resource.Resource) string {
+	return c.OrgId().Kind + "/" + c.OrgId().Name
+}
+
+type recordingGenerator struct {
+	delegate transformers.Generator
+	name     string
+	root     string
+}
+
+func (g *recordingGenerator) Generate() (resmap.ResMap, error) {
+	m, err := g.delegate.Generate()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range m.Resources() {
+		r.SetProvenanceGenerator(g.name, g.root)
+	}
+	return m, nil
+}
+
+type recordingTransformer struct {
+	delegate transformers.Transformer
+	name     string
+	root     string
+}
+
+// Transform snapshots every resource before delegating,
+// and marks those that were added or changed.
+func (t *recordingTransformer) Transform(m resmap.ResMap) error {
+	before := make(map[*resource.Resource]ifc.Kunstructured)
+	for _, r := range m.Resources() {
+		before[r] = r.Kunstructured.Copy()
+	}
+	err := t.delegate.Transform(m)
+	if err != nil {
+		return err
+	}
+	for _, r := range m.Resources() {
+		old, ok := before[r]
+		if !ok || !reflect.DeepEqual(old.Map(), r.Map()) {
+			r.AppendProvenanceTransformer(t.name, t.root)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/transformers"
+	"github.com/irairdon/kustomize/v3/plugin/builtin"
+)
+
+// EnableStrict makes the build fail on configuration
+// that has no effect - vars never referenced, patches
+// and images matching nothing, transformers changing
+// nothing - reporting every such case at once, in every
+// kustomization in the build.
+func (kt *KustTarget) EnableStrict() {
+	kt.strict = &strictReport{}
+}
+
+// strictReport collects what a strict build fails on.
+type strictReport struct {
+	mu       sync.Mutex
+	problems []string
+}
+
+func (r *strictReport) add(problem string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.problems = append(r.problems, problem)
+}
+
+func (r *strictReport) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.problems = nil
+}
+
+// err returns the problems found, sorted and without
+// duplicates, e.g. from a base used twice, as one error.
+func (r *strictReport) err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.problems) == 0 {
+		return nil
+	}
+	sort.Strings(r.problems)
+	var lines []string
+	for i, p := range r.problems {
+		if i == 0 || p != r.problems[i-1] {
+			lines = append(lines, "  "+p)
+		}
+	}
+	return fmt.Errorf(
+		"strict build found configuration with no effect:\n%s",
+		strings.Join(lines, "\n"))
+}
+
+// maybeCheckTransformer wraps the transformer so that a
+// strict build reports it if it matches or changes nothing.
+func (kt *KustTarget) maybeCheckTransformer(
+	t transformers.Transformer, name string) transformers.Transformer {
+	if kt.strict == nil || unconfigured(t) {
+		return t
+	}
+	return &checkingTransformer{
+		delegate: t, name: kt.ldr.Root() + ": " + name, report: kt.strict}
+}
+
+// unconfigured is true of the builtin transformers that
+// run in every kustomization, when it gives them nothing
+// to do.
+func unconfigured(t transformers.Transformer) bool {
+	switch p := t.(type) {
+	case *builtin.NamespaceTransformerPlugin:
+		return p.Namespace == ""
+	case *builtin.PrefixSuffixTransformerPlugin:
+		return p.Prefix == "" && p.Suffix == ""
+	case *builtin.LabelTransformerPlugin:
+		return len(p.Labels) == 0
+	case *builtin.AnnotationsTransformerPlugin:
+		return len(p.Annotations) == 0
+	}
+	return false
+}
+
+type checkingTransformer struct {
+	delegate transformers.Transformer
+	name     string
+	report   *strictReport
+}
+
+// Transform snapshots every resource before delegating,
+// so as to tell if the delegate changed any.
+func (t *checkingTransformer) Transform(m resmap.ResMap) error {
+	before := make(map[*resource.Resource]map[string]interface{})
+	for _, r := range m.Resources() {
+		before[r] = r.Kunstructured.Copy().Map()
+	}
+	err := t.delegate.Transform(m)
+	if err != nil {
+		return err
+	}
+	if u, ok := t.delegate.(transformers.UnmatchedReporter); ok {
+		unmatched := u.Unmatched()
+		for _, p := range unmatched {
+			t.report.add(t.name + ": " + p)
+		}
+		if len(unmatched) > 0 {
+			return nil
+		}
+	}
+	if m.Size() != len(before) {
+		return nil
+	}
+	for _, r := range m.Resources() {
+		old, ok := before[r]
+		if !ok || !reflect.DeepEqual(old, r.Map()) {
+			return nil
+		}
+	}
+	t.report.add(t.name + " changed nothing")
+	return nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+func writeLabelsBase(th *kusttest_test.KustTestHarness) {
+	th.WriteF("/app/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+`)
+}
+
+// Labels stay out of selectors unless asked.
+func TestLabels(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	writeLabelsBase(th)
+	th.WriteK("/app", `
+resources:
+- deployment.yaml
+labels:
+- pairs:
+    owner: shop
+- pairs:
+    tier: frontend
+  includeTemplates: true
+- pairs:
+    version: v2
+  fieldSpecs:
+  - path: spec/template/metadata/annotations
+    kind: Deployment
+    create: true
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    owner: shop
+    tier: frontend
+    version: v2
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      annotations:
+        version: v2
+      labels:
+        app: web
+        tier: frontend
+`)
+}
+
+func TestLabelsIncludeSelectors(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	writeLabelsBase(th)
+	th.WriteK("/app", `
+resources:
+- deployment.yaml
+labels:
+- pairs:
+    tier: frontend
+  includeSelectors: true
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    tier: frontend
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+      tier: frontend
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: frontend
+`)
+}
+
+// A StatefulSet's volumeClaimTemplates aren't pod templates.
+func TestLabelsIncludeTemplatesStatefulSet(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteF("/app/statefulset.yaml", `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+      labels:
+        app: db
+`)
+	th.WriteK("/app", `
+resources:
+- statefulset.yaml
+labels:
+- pairs:
+    tier: backend
+  includeTemplates: true
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  labels:
+    tier: backend
+  name: db
+spec:
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+        tier: backend
+  volumeClaimTemplates:
+  - metadata:
+      labels:
+        app: db
+      name: data
+`)
+}
@@ -0,0 +1,39 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target
+
+// DefaultMaxConcurrentLoads bounds how many resource files
+// and bases a build loads at once.  Loading is mostly
+// waiting on disks and remote repos, so it's more than
+// the number of CPUs one might expect.
+const DefaultMaxConcurrentLoads = 16
+
+// loadSlots bounds the goroutines loading resources
+// and bases, across all the kustomizations in a build.
+// A kustomization that finds no free slot loads the
+// path itself, so that loads waiting on their own bases
+// to load can't take every slot and deadlock.
+type loadSlots chan struct{}
+
+// newLoadSlots makes slots for n loads at once, counting
+// the one done by the goroutine that asks for a slot.
+func newLoadSlots(n int) loadSlots {
+	if n < 1 {
+		n = 1
+	}
+	return make(loadSlots, n-1)
+}
+
+func (s loadSlots) tryAcquire() bool {
+	select {
+	case s <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s loadSlots) release() {
+	<-s
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+// A Service mentioned by its DNS name in an env
+// value, and in ConfigMap data, gets its new name
+// and namespace there.
+func TestNameReferencePattern(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+namePrefix: p-
+namespace: shop
+resources:
+- resources.yaml
+configurations:
+- dns.yaml
+`)
+	th.WriteF("/app/dns.yaml", `
+nameReference:
+- kind: Service
+  version: v1
+  fieldSpecs:
+  - path: spec/template/spec/containers/env/value
+    kind: Deployment
+    pattern: "{name}.{namespace}.svc.cluster.local"
+  - path: data
+    kind: ConfigMap
+    pattern: "{name}.{namespace}.svc.cluster.local"
+`)
+	th.WriteF("/app/resources.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: web
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        env:
+        - name: DB
+          value: postgres://db.web.svc.cluster.local:5432/app
+        - name: OTHER
+          value: mydb.web.svc.cluster.local
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: web
+data:
+  upstream: db.web.svc.cluster.local,cache.web.svc.cluster.local
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: p-db
+  namespace: shop
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: p-app
+  namespace: shop
+spec:
+  template:
+    spec:
+      containers:
+      - env:
+        - name: DB
+          value: postgres://p-db.shop.svc.cluster.local:5432/app
+        - name: OTHER
+          value: mydb.web.svc.cluster.local
+        name: app
+---
+apiVersion: v1
+data:
+  upstream: p-db.shop.svc.cluster.local,cache.web.svc.cluster.local
+kind: ConfigMap
+metadata:
+  name: p-config
+  namespace: shop
+`)
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+// References to resources in the build follow them
+// into a new namespace, through overlays that also
+// rename them.
+func TestNamespaceReferences(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	th.WriteK("/app/base", `
+namespace: web
+resources:
+- resources.yaml
+`)
+	th.WriteF("/app/base/resources.yaml", `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: sa
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: crb
+subjects:
+- kind: ServiceAccount
+  name: sa
+  namespace: default
+- kind: ServiceAccount
+  name: other
+  namespace: kube-system
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: v1.example.com
+spec:
+  service:
+    name: svc
+    namespace: default
+`)
+	th.WriteK("/app/overlay", `
+namespace: shop
+namePrefix: p-
+resources:
+- ../base
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: p-sa
+  namespace: shop
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: p-svc
+  namespace: shop
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: p-crb
+subjects:
+- kind: ServiceAccount
+  name: p-sa
+  namespace: shop
+- kind: ServiceAccount
+  name: other
+  namespace: kube-system
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: p-v1.example.com
+spec:
+  service:
+    name: p-svc
+    namespace: shop
+`)
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+func TestProvenanceAnnotations(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	th.WriteK("/app/base", `
+resources:
+- deployment.yaml
+configMapGenerator:
+- name: cfg
+  literals:
+  - a=b
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+- service.yaml
+images:
+- name: nginx
+  newTag: "1.17"
+`)
+	th.WriteF("/app/overlay/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	kt := th.MakeKustTarget()
+	kt.EnableProvenance()
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    kustomize.config.k8s.io/ProvenanceFile: ../base/deployment.yaml
+    kustomize.config.k8s.io/ProvenanceRoots: ../base,.
+    kustomize.config.k8s.io/ProvenanceTransformers: ImageTagTransformer@.
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx:1.17
+        name: web
+---
+apiVersion: v1
+data:
+  a: b
+kind: ConfigMap
+metadata:
+  annotations:
+    kustomize.config.k8s.io/ProvenanceGenerator: ConfigMapGenerator@../base
+    kustomize.config.k8s.io/ProvenanceRoots: ../base,.
+    kustomize.config.k8s.io/ProvenanceTransformers: HashTransformer@.
+  name: cfg-6tk58t77gc
+---
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    kustomize.config.k8s.io/ProvenanceFile: service.yaml
+    kustomize.config.k8s.io/ProvenanceRoots: .
+  name: web
+`)
+}
+
+func TestProvenanceOffByDefault(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- service.yaml
+`)
+	th.WriteF("/app/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+// Replacements reach fields of custom resources
+// with no varReference configuration, and copy
+// values as the overlay leaves them.
+func TestReplacementsInOverlay(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	th.WriteK("/app/base", `
+resources:
+- service.yaml
+- probe.yaml
+`)
+	th.WriteF("/app/base/service.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`)
+	th.WriteF("/app/base/probe.yaml", `
+apiVersion: monitoring.example.com/v1
+kind: Probe
+metadata:
+  name: web-probe
+spec:
+  prober:
+    address: web.default.svc:8080
+`)
+	th.WriteK("/app/overlay", `
+namePrefix: prod-
+namespace: shop
+resources:
+- ../base
+replacements:
+- source:
+    kind: Service
+    name: web
+  targets:
+  - select:
+      kind: Probe
+    fieldPaths:
+    - spec.prober.address
+    options:
+      delimiter: .
+      index: 0
+- source:
+    kind: Service
+    name: web
+    fieldPath: metadata.namespace
+  targets:
+  - select:
+      kind: Probe
+    fieldPaths:
+    - spec.prober.address
+    options:
+      delimiter: .
+      index: 1
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: prod-web
+  namespace: shop
+---
+apiVersion: monitoring.example.com/v1
+kind: Probe
+metadata:
+  name: prod-web-probe
+  namespace: shop
+spec:
+  prober:
+    address: prod-web.shop.svc:8080
+`)
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+// Replicas reach custom kinds registered in configurations,
+// picked by label, and the autoscalers next to them.
+func TestReplicasBySelectorWithCustomKind(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- resources.yaml
+configurations:
+- replicas.yaml
+replicas:
+- selector:
+    labelSelector: tier=frontend
+  count: 3
+  minReplicas: 3
+  maxReplicas: 6
+`)
+	th.WriteF("/app/replicas.yaml", `
+replicas:
+- path: spec/size
+  create: true
+  kind: Rollout
+`)
+	th.WriteF("/app/resources.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    tier: frontend
+---
+apiVersion: example.com/v1
+kind: Rollout
+metadata:
+  name: shop
+  labels:
+    tier: frontend
+spec:
+  size: 1
+---
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: shop
+  labels:
+    tier: frontend
+spec:
+  minReplicas: 1
+  maxReplicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    tier: frontend
+  name: web
+spec:
+  replicas: 3
+---
+apiVersion: example.com/v1
+kind: Rollout
+metadata:
+  labels:
+    tier: frontend
+  name: shop
+spec:
+  size: 3
+---
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  labels:
+    tier: frontend
+  name: shop
+spec:
+  maxReplicas: 6
+  minReplicas: 3
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+`)
+}
+
+// The fields minReplicas and maxReplicas set can be
+// extended in configurations too.
+func TestReplicasBoundsWithCustomKind(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- resources.yaml
+configurations:
+- replicas.yaml
+replicas:
+- name: web
+  minReplicas: 2
+  maxReplicas: 8
+`)
+	th.WriteF("/app/replicas.yaml", `
+minReplicas:
+- path: spec/minReplicaCount
+  create: true
+  kind: ScaledObject
+maxReplicas:
+- path: spec/maxReplicaCount
+  create: true
+  kind: ScaledObject
+`)
+	th.WriteF("/app/resources.yaml", `
+apiVersion: keda.example.com/v1
+kind: ScaledObject
+metadata:
+  name: web
+spec:
+  minReplicaCount: 1
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: keda.example.com/v1
+kind: ScaledObject
+metadata:
+  name: web
+spec:
+  maxReplicaCount: 8
+  minReplicaCount: 2
+`)
+}
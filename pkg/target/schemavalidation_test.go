@@ -0,0 +1,140 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/k8sdeps/schema"
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+func TestSchemaValidationValid(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeBaseWithCrd(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+- deployment.yaml
+`)
+	th.WriteF("/app/overlay/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        ports:
+        - containerPort: 80
+        resources:
+          limits:
+            cpu: 1
+            memory: 1Gi
+`)
+	kt := th.MakeKustTarget()
+	kt.EnableSchemaValidation(schema.NewBuiltinSchemas())
+	_, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+}
+
+func TestSchemaValidationProblems(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	// The base's CRD schemas apply to the whole build.
+	writeBaseWithCrd(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+- deployment.yaml
+patchesStrategicMerge:
+- mykind.yaml
+`)
+	th.WriteF("/app/overlay/mykind.yaml", `
+apiVersion: jingfang.example.com/v1beta1
+kind: MyKind
+metadata:
+  name: mykind
+spec:
+  secretRef:
+    key: PATH
+`)
+	th.WriteF("/app/overlay/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: three
+  selector:
+    matchLabels:
+      app: web
+  template:
+    spec:
+      containers:
+      - image: nginx
+`)
+	kt := th.MakeKustTarget()
+	kt.EnableSchemaValidation(schema.NewBuiltinSchemas())
+	_, err := kt.MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	expected := []string{
+		"jingfang.example.com_v1beta1_MyKind|~X|x-mykind: " +
+			"spec.secretRef.key: unknown field",
+		"apps_v1_Deployment|~X|web: spec.replicas: expected integer, got string",
+		"apps_v1_Deployment|~X|web: " +
+			"spec.template.spec.containers[0].name: required field is missing",
+	}
+	for _, e := range expected {
+		if !strings.Contains(err.Error(), e) {
+			t.Fatalf("expected error containing %q, got %v", e, err)
+		}
+	}
+
+	// Without validation, the build succeeds.
+	_, err = th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+}
+
+// Fields marked +optional, like a ClusterRole's rules,
+// aren't required even without omitempty.
+func TestSchemaValidationAggregatedClusterRole(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- clusterrole.yaml
+`)
+	th.WriteF("/app/clusterrole.yaml", `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: monitoring
+aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      rbac.example.com/aggregate-to-monitoring: "true"
+`)
+	kt := th.MakeKustTarget()
+	kt.EnableSchemaValidation(schema.NewBuiltinSchemas())
+	_, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+// Resources can opt out of builtin transformers
+// one by one, and the opt-out isn't in the output.
+func TestSkipAnnotation(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+namespace: shop
+namePrefix: prod-
+commonLabels:
+  app: web
+resources:
+- resources.yaml
+configMapGenerator:
+- name: settings
+  literals:
+  - color=blue
+generatorOptions:
+  annotations:
+    kustomize.config.k8s.io/skip: hash
+`)
+	th.WriteF("/app/resources.yaml", `
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared
+  namespace: kube-public
+  annotations:
+    kustomize.config.k8s.io/skip: namespace, prefix, labels
+    owner: platform
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app: web
+  name: prod-web
+  namespace: shop
+spec:
+  selector:
+    app: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  annotations:
+    owner: platform
+  name: shared
+  namespace: kube-public
+---
+apiVersion: v1
+data:
+  color: blue
+kind: ConfigMap
+metadata:
+  labels:
+    app: web
+  name: prod-settings
+  namespace: shop
+`)
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package target_test
+
+import (
+	"strings"
+	"testing"
+
+	kusttest_test "github.com/irairdon/kustomize/v3/pkg/kusttest"
+)
+
+func writeStrictBase(th *kusttest_test.KustTestHarness) {
+	th.WriteK("/app/base", `
+resources:
+- deployment.yaml
+vars:
+- name: WEB_NAME
+  objref:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: web
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.7
+`)
+}
+
+func TestStrictBuildReportsEverythingWithNoEffect(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeStrictBase(th)
+	th.WriteK("/app/overlay", `
+resources:
+- ../base
+namespace: shop
+images:
+- name: nginx
+  newTag: "1.8"
+- name: ngnix
+  newTag: "1.8"
+patches:
+- target:
+    kind: Deployment
+    name: wbe
+  patch: |-
+    - op: replace
+      path: /spec/replicas
+      value: 3
+`)
+	kt := th.MakeKustTarget()
+	_, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error without strict: %v", err)
+	}
+	kt.EnableStrict()
+	_, err = kt.MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	expected := `strict build found configuration with no effect:
+  /app/overlay: ImageTagTransformer: image ngnix matches no container
+  /app/overlay: NamespaceTransformer changed nothing
+  /app/overlay: PatchTransformer: patch target {kind=Deployment, name=wbe} selects no resources
+  var WEB_NAME is never referenced`
+	if err.Error() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, err)
+	}
+}
+
+func TestStrictBuildPasses(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/overlay")
+	writeStrictBase(th)
+	th.WriteK("/app/overlay", `
+namePrefix: prod-
+resources:
+- ../base
+images:
+- name: nginx
+  newTag: "1.8"
+`)
+	th.WriteF("/app/base/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.7
+        args: ["--name", "$(WEB_NAME)"]
+`)
+	kt := th.MakeKustTarget()
+	kt.EnableStrict()
+	m, err := kt.MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := m.AsYaml()
+	if !strings.Contains(string(b), "- prod-web") ||
+		!strings.Contains(string(b), "image: nginx:1.8") {
+		t.Fatalf("unexpected output\n%s", b)
+	}
+}
@@ -422,3 +422,58 @@ spec:
             image: solsa-echo:foo
 `)
 }
+
+func TestTransfomersImageInTextNeedsPattern(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app/base")
+	th.WriteK("/app/base", `
+resources:
+- pod.yaml
+configurations:
+- config/args.yaml
+images:
+- name: envoy
+  newTag: v2
+`)
+	th.WriteF("/app/base/pod.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+spec:
+  containers:
+  - name: injector
+    image: injector:v1
+    args:
+    - envoy:v1
+    - envoy:v1 --flag
+    - --mirror=https://mirror.example.com/envoy:v1
+    - --sidecar-image=envoy:v1
+`)
+	th.WriteF("/app/base/config/args.yaml", `
+images:
+- path: spec/containers/args
+  kind: Pod
+- path: spec/containers/args
+  kind: Pod
+  pattern: --sidecar-image=(\S+)
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+spec:
+  containers:
+  - args:
+    - envoy:v2
+    - envoy:v1 --flag
+    - --mirror=https://mirror.example.com/envoy:v1
+    - --sidecar-image=envoy:v2
+    image: injector:v1
+    name: injector
+`)
+}
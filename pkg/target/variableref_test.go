@@ -1365,3 +1365,243 @@ spec:
     protocol: TCP
 `)
 }
+
+func TestVarDefaultsAndFunctions(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+namePrefix: Base-
+resources:
+- pod.yaml
+vars:
+- name: POD_NAME
+  objref:
+    apiVersion: v1
+    kind: Pod
+    name: clown
+`)
+	th.WriteF("/app/pod.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: clown
+spec:
+  containers:
+  - name: frown
+    image: frown
+    command:
+    - echo
+    - "$(POD_NAME|lower|trimprefix:base-)"
+    - "$(TIER:-web)"
+    - "$(POD_IP)"
+    - "$$(POD_HOST)"
+    env:
+    - name: POD_IP
+      valueFrom:
+        fieldRef:
+          fieldPath: status.podIP
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: Base-clown
+spec:
+  containers:
+  - command:
+    - echo
+    - clown
+    - web
+    - $(POD_IP)
+    - $(POD_HOST)
+    env:
+    - name: POD_IP
+      valueFrom:
+        fieldRef:
+          fieldPath: status.podIP
+    image: frown
+    name: frown
+`)
+}
+
+func TestUnresolvedVarsFailTheBuild(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- pod.yaml
+`)
+	th.WriteF("/app/pod.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: clown
+spec:
+  containers:
+  - name: frown
+    image: frown
+    command:
+    - echo
+    - "$(POD_NAME) $(hostname -f)"
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "unresolved vars: POD_NAME;") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMalformedVarReferencesFailTheBuild(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- pod.yaml
+`)
+	th.WriteF("/app/pod.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: clown
+spec:
+  containers:
+  - name: frown
+    image: frown
+    command:
+    - echo
+    - "$(TIER|lowr)"
+    envFrom:
+    - configMapRef:
+        name: tiers
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "unknown function 'lowr' in $(TIER|lowr)") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestEscapedVarsLeftInPlace(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- pod.yaml
+`)
+	th.WriteF("/app/pod.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: clown
+spec:
+  containers:
+  - name: frown
+    image: frown
+    command: ["sh", "-c", "echo $$(date) on $$(hostname)"]
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: clown
+spec:
+  containers:
+  - command:
+    - sh
+    - -c
+    - echo $(date) on $(hostname)
+    image: frown
+    name: frown
+`)
+}
+
+func TestUnresolvedVarsFromEnvFrom(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- deployment.yaml
+`)
+	th.WriteF("/app/deployment.yaml", `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app
+        command:
+        - start
+        - "--db=$(DB_HOST)"
+        envFrom:
+        - configMapRef:
+            name: db
+`)
+	m, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	th.AssertActualEqualsExpected(m, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - command:
+        - start
+        - --db=$(DB_HOST)
+        envFrom:
+        - configMapRef:
+            name: db
+        image: app
+        name: app
+`)
+}
+
+func TestUnresolvedVarsPerContainer(t *testing.T) {
+	th := kusttest_test.NewKustTestHarness(t, "/app")
+	th.WriteK("/app", `
+resources:
+- pod.yaml
+`)
+	th.WriteF("/app/pod.yaml", `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: clown
+spec:
+  containers:
+  - name: frown
+    image: frown
+    env:
+    - name: POD_IP
+      valueFrom:
+        fieldRef:
+          fieldPath: status.podIP
+  - name: smile
+    image: smile
+    command:
+    - echo
+    - "$(POD_IP)"
+`)
+	_, err := th.MakeKustTarget().MakeCustomizedResMap()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if !strings.Contains(err.Error(), "unresolved vars: POD_IP") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
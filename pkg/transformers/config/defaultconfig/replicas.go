@@ -17,4 +17,14 @@ replicas:
 - path: spec/replicas
   create: true
   kind: StatefulSet
+
+minReplicas:
+- path: spec/minReplicas
+  create: true
+  kind: HorizontalPodAutoscaler
+
+maxReplicas:
+- path: spec/maxReplicas
+  create: true
+  kind: HorizontalPodAutoscaler
 `
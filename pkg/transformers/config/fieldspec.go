@@ -18,76 +18,18 @@ package config
 
 import (
 	"fmt"
-	"strings"
 
-	"github.com/irairdon/kustomize/v3/pkg/gvk"
+	"github.com/irairdon/kustomize/v3/pkg/types"
 )
 
-// FieldSpec completely specifies a kustomizable field in
-// an unstructured representation of a k8s API object.
-// It helps define the operands of transformations.
-//
-// For example, a directive to add a common label to objects
-// will need to know that a 'Deployment' object (in API group
-// 'apps', any version) can have labels at field path
-// 'spec/template/metadata/labels', and further that it is OK
-// (or not OK) to add that field path to the object if the
-// field path doesn't exist already.
-//
-// This would look like
-// {
-//   group: apps
-//   kind: Deployment
-//   path: spec/template/metadata/labels
-//   create: true
-// }
-type FieldSpec struct {
-	gvk.Gvk            `json:",inline,omitempty" yaml:",inline,omitempty"`
-	Path               string `json:"path,omitempty" yaml:"path,omitempty"`
-	CreateIfNotPresent bool   `json:"create,omitempty" yaml:"create,omitempty"`
-}
-
-const (
-	escapedForwardSlash  = "\\/"
-	tempSlashReplacement = "???"
-)
-
-func (fs FieldSpec) String() string {
-	return fmt.Sprintf(
-		"%s:%v:%s", fs.Gvk.String(), fs.CreateIfNotPresent, fs.Path)
-}
+// FieldSpec completely specifies a kustomizable field.
+// It lives in types so that kustomization fields can hold it.
+type FieldSpec = types.FieldSpec
 
 // If true, the primary key is the same, but other fields might not be.
-func (fs FieldSpec) effectivelyEquals(other FieldSpec) bool {
-	return fs.IsSelected(&other.Gvk) && fs.Path == other.Path
-}
-
-// PathSlice converts the path string to a slice of strings,
-// separated by a '/'. Forward slash can be contained in a
-// fieldname. such as ingress.kubernetes.io/auth-secret in
-// Ingress annotations. To deal with this special case, the
-// path to this field should be formatted as
-//
-//   metadata/annotations/ingress.kubernetes.io\/auth-secret
-//
-// Then PathSlice will return
-//
-//   []string{
-//      "metadata",
-//      "annotations",
-//      "ingress.auth-secretkubernetes.io/auth-secret"
-//   }
-func (fs FieldSpec) PathSlice() []string {
-	if !strings.Contains(fs.Path, escapedForwardSlash) {
-		return strings.Split(fs.Path, "/")
-	}
-	s := strings.Replace(fs.Path, escapedForwardSlash, tempSlashReplacement, -1)
-	paths := strings.Split(s, "/")
-	var result []string
-	for _, path := range paths {
-		result = append(result, strings.Replace(path, tempSlashReplacement, "/", -1))
-	}
-	return result
+func effectivelyEquals(fs, other FieldSpec) bool {
+	return fs.IsSelected(&other.Gvk) && fs.Path == other.Path &&
+		fs.Pattern == other.Pattern
 }
 
 type fsSlice []FieldSpec
@@ -131,7 +73,7 @@ func (s fsSlice) mergeOne(x FieldSpec) (fsSlice, error) {
 
 func (s fsSlice) index(fs FieldSpec) int {
 	for i, x := range s {
-		if x.effectivelyEquals(fs) {
+		if effectivelyEquals(x, fs) {
 			return i
 		}
 	}
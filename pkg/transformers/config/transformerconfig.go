@@ -36,6 +36,9 @@ type TransformerConfig struct {
 	VarReference      fsSlice  `json:"varReference,omitempty" yaml:"varReference,omitempty"`
 	Images            fsSlice  `json:"images,omitempty" yaml:"images,omitempty"`
 	Replicas          fsSlice  `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	MinReplicas       fsSlice  `json:"minReplicas,omitempty" yaml:"minReplicas,omitempty"`
+	MaxReplicas       fsSlice  `json:"maxReplicas,omitempty" yaml:"maxReplicas,omitempty"`
+	HashFields        fsSlice  `json:"hashFields,omitempty" yaml:"hashFields,omitempty"`
 }
 
 // MakeEmptyConfig returns an empty TransformerConfig object
@@ -63,6 +66,9 @@ func (t *TransformerConfig) sortFields() {
 	sort.Sort(t.VarReference)
 	sort.Sort(t.Images)
 	sort.Sort(t.Replicas)
+	sort.Sort(t.MinReplicas)
+	sort.Sort(t.MaxReplicas)
+	sort.Sort(t.HashFields)
 }
 
 // AddPrefixFieldSpec adds a FieldSpec to NamePrefix
@@ -141,6 +147,18 @@ func (t *TransformerConfig) Merge(input *TransformerConfig) (
 	if err != nil {
 		return nil, err
 	}
+	merged.MinReplicas, err = t.MinReplicas.mergeAll(input.MinReplicas)
+	if err != nil {
+		return nil, err
+	}
+	merged.MaxReplicas, err = t.MaxReplicas.mergeAll(input.MaxReplicas)
+	if err != nil {
+		return nil, err
+	}
+	merged.HashFields, err = t.HashFields.mergeAll(input.HashFields)
+	if err != nil {
+		return nil, err
+	}
 	merged.sortFields()
 	return merged, nil
 }
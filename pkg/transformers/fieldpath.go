@@ -0,0 +1,212 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package transformers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A field path names fields in a resource, as dot
+// separated map keys, where a bracketed segment is
+//
+//   [name=app]  the list elements whose name is app,
+//   [2]         the list element at index 2,
+//   [a.b/c]     the map key a.b/c, dots and all.
+//
+// A map key reaching a list applies to every element,
+// so spec.containers.image names every container image.
+// A list segment may follow a key directly, as in
+// spec.containers[name=app].image.
+
+type segmentKind int
+
+const (
+	segmentKey segmentKind = iota
+	segmentMatch
+	segmentIndex
+)
+
+type pathSegment struct {
+	kind  segmentKind
+	key   string
+	value string
+	index int
+}
+
+func parseFieldPath(path string) ([]pathSegment, error) {
+	var result []pathSegment
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			continue
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ']' in field path %s", path)
+			}
+			result = append(result, parseBracket(path[1:end]))
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			result = append(result, pathSegment{kind: segmentKey, key: path[:end]})
+			path = path[end:]
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("empty field path")
+	}
+	return result, nil
+}
+
+func parseBracket(s string) pathSegment {
+	if i, err := strconv.Atoi(s); err == nil && i >= 0 {
+		return pathSegment{kind: segmentIndex, index: i}
+	}
+	if i := strings.IndexByte(s, '='); i > 0 {
+		return pathSegment{kind: segmentMatch, key: s[:i], value: s[i+1:]}
+	}
+	return pathSegment{kind: segmentKey, key: s}
+}
+
+// LookupField returns the values of the fields at the
+// given path, in document order.
+func LookupField(
+	m map[string]interface{}, path string) ([]interface{}, error) {
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return nil, err
+	}
+	var result []interface{}
+	_, err = visitField(m, func(interface{}) {}, segments, false,
+		func(v interface{}) (interface{}, error) {
+			result = append(result, v)
+			return v, nil
+		})
+	return result, err
+}
+
+// MutateFieldPath applies the mutator to the fields at
+// the given path, and returns how many there were.
+// If create is true, missing map keys and matched list
+// elements along the way are made, and the mutator of a
+// made field gets nil.
+func MutateFieldPath(
+	m map[string]interface{}, path string,
+	create bool, fn mutateFunc) (int, error) {
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := visitField(m, func(interface{}) {}, segments, create, fn)
+	if err != nil {
+		return 0, fmt.Errorf("field path %s: %v", path, err)
+	}
+	return n, nil
+}
+
+// visitField walks the segments down from v, which set
+// replaces in its parent.
+func visitField(
+	v interface{}, set func(interface{}), segments []pathSegment,
+	create bool, fn mutateFunc) (int, error) {
+	if len(segments) == 0 {
+		nv, err := fn(v)
+		if err != nil {
+			return 0, err
+		}
+		set(nv)
+		return 1, nil
+	}
+	seg := segments[0]
+	if v == nil {
+		if !create || seg.kind == segmentIndex {
+			return 0, nil
+		}
+		if seg.kind == segmentKey {
+			v = map[string]interface{}{}
+		} else {
+			v = []interface{}{}
+		}
+		set(v)
+	}
+	if seg.kind == segmentKey {
+		switch typed := v.(type) {
+		case map[string]interface{}:
+			child, ok := typed[seg.key]
+			if !ok && !create {
+				return 0, nil
+			}
+			return visitField(child, func(nv interface{}) {
+				typed[seg.key] = nv
+			}, segments[1:], create, fn)
+		case []interface{}:
+			return visitList(typed, segments, create, fn)
+		default:
+			return 0, fmt.Errorf("%s is not a map", seg.key)
+		}
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return 0, fmt.Errorf("%#v is not a list", v)
+	}
+	if seg.kind == segmentIndex {
+		if seg.index >= len(list) {
+			return 0, nil
+		}
+		return visitField(
+			list[seg.index], listSetter(list, seg.index), segments[1:], create, fn)
+	}
+	count, found := 0, false
+	for i := range list {
+		if !matches(list[i], seg) {
+			continue
+		}
+		found = true
+		n, err := visitField(list[i], listSetter(list, i), segments[1:], create, fn)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	if found || !create {
+		return count, nil
+	}
+	list = append(list, map[string]interface{}{seg.key: seg.value})
+	set(list)
+	return visitField(
+		list[len(list)-1], listSetter(list, len(list)-1), segments[1:], create, fn)
+}
+
+func matches(item interface{}, seg pathSegment) bool {
+	m, ok := item.(map[string]interface{})
+	return ok && m[seg.key] != nil && fmt.Sprint(m[seg.key]) == seg.value
+}
+
+func listSetter(list []interface{}, i int) func(interface{}) {
+	return func(nv interface{}) {
+		list[i] = nv
+	}
+}
+
+// visitList walks the segments down from each element
+// of the list.
+func visitList(
+	list []interface{}, segments []pathSegment,
+	create bool, fn mutateFunc) (int, error) {
+	count := 0
+	for i := range list {
+		n, err := visitField(list[i], listSetter(list, i), segments, create, fn)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
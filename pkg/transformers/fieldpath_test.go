@@ -0,0 +1,113 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package transformers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func makeFieldPathTestMap() map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "web",
+			"annotations": map[string]interface{}{
+				"example.com/url": "http://old:80/x",
+			},
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":  "app",
+					"image": "app:1",
+					"ports": []interface{}{
+						map[string]interface{}{"containerPort": int64(80)},
+					},
+				},
+				map[string]interface{}{
+					"name":  "sidecar",
+					"image": "sidecar:2",
+				},
+			},
+		},
+	}
+}
+
+func TestLookupField(t *testing.T) {
+	var cases = []struct {
+		path     string
+		expected []interface{}
+	}{
+		{"metadata.name", []interface{}{"web"}},
+		{"metadata.annotations.[example.com/url]", []interface{}{"http://old:80/x"}},
+		{"spec.containers.image", []interface{}{"app:1", "sidecar:2"}},
+		{"spec.containers[name=sidecar].image", []interface{}{"sidecar:2"}},
+		{"spec.containers.[name=app].ports[0].containerPort", []interface{}{int64(80)}},
+		{"spec.containers[1].name", []interface{}{"sidecar"}},
+		{"spec.containers[name=app].ports.[containerPort=80]",
+			[]interface{}{map[string]interface{}{"containerPort": int64(80)}}},
+		{"spec.containers[7].name", nil},
+		{"spec.containers[name=nope].name", nil},
+		{"spec.volumes", nil},
+	}
+	for _, c := range cases {
+		actual, err := LookupField(makeFieldPathTestMap(), c.path)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.path, err)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.path, c.expected, actual)
+		}
+	}
+	for _, path := range []string{"", "spec.containers[name=app", "metadata.name.x"} {
+		if _, err := LookupField(makeFieldPathTestMap(), path); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+}
+
+func TestMutateFieldPath(t *testing.T) {
+	set := func(in interface{}) (interface{}, error) {
+		return "new", nil
+	}
+	var cases = []struct {
+		path   string
+		create bool
+		count  int
+		check  string
+	}{
+		{"spec.containers.image", false, 2, "spec.containers[name=sidecar].image"},
+		{"spec.containers[name=app].image", false, 1, "spec.containers[0].image"},
+		{"spec.containers[name=app].env[name=URL].value", false, 0, ""},
+		{"spec.containers[name=app].env[name=URL].value", true, 1,
+			"spec.containers[name=app].env[name=URL].value"},
+		{"spec.containers[name=db].image", true, 1, "spec.containers[2].image"},
+		{"spec.template.metadata.labels.[app.kubernetes.io/name]", true, 1,
+			"spec.template.metadata.labels.[app.kubernetes.io/name]"},
+		{"spec.containers[5].image", true, 0, ""},
+	}
+	for _, c := range cases {
+		m := makeFieldPathTestMap()
+		n, err := MutateFieldPath(m, c.path, c.create, set)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.path, err)
+		}
+		if n != c.count {
+			t.Fatalf("%s: expected %d fields, got %d", c.path, c.count, n)
+		}
+		if c.check == "" {
+			continue
+		}
+		v, _ := LookupField(m, c.check)
+		if !reflect.DeepEqual(v, []interface{}{"new"}) {
+			t.Errorf("%s: expected new value at %s, got %v", c.path, c.check, v)
+		}
+	}
+	_, err := MutateFieldPath(
+		makeFieldPathTestMap(), "spec.containers[0].name.x", true, set)
+	if err == nil || !strings.Contains(err.Error(), "not a map") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
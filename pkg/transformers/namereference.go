@@ -6,12 +6,14 @@ package transformers
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/irairdon/kustomize/v3/pkg/resource"
 
 	"github.com/irairdon/kustomize/v3/pkg/gvk"
 	"github.com/irairdon/kustomize/v3/pkg/resmap"
 	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
+	"github.com/irairdon/kustomize/v3/pkg/types"
 )
 
 type nameReferenceTransformer struct {
@@ -86,16 +88,21 @@ func (o *nameReferenceTransformer) Transform(m resmap.ResMap) error {
 					if candidates == nil {
 						candidates = m.SubsetThatCouldBeReferencedByResource(referrer)
 					}
+					fn := o.getNewNameFunc(
+						// referrer could be an HPA instance,
+						// target could be Gvk for Deployment,
+						// candidate a list of resources "reachable"
+						// from the HPA.
+						referrer, target.Gvk, candidates)
+					if fSpec.Pattern != "" {
+						fn = o.getPatternFunc(
+							referrer, target.Gvk, candidates, fSpec.Pattern)
+					}
 					err := MutateField(
 						referrer.Map(),
 						fSpec.PathSlice(),
 						fSpec.CreateIfNotPresent,
-						o.getNewNameFunc(
-							// referrer could be an HPA instance,
-							// target could be Gvk for Deployment,
-							// candidate a list of resources "reachable"
-							// from the HPA.
-							referrer, target.Gvk, candidates))
+						fn)
 					if err != nil {
 						return err
 					}
@@ -178,11 +185,15 @@ func (o *nameReferenceTransformer) getNameAndNsStruct(
 	subset := referralCandidates.Resources()
 	if namespacevalue, ok := inMap["namespace"]; ok {
 		namespace := namespacevalue.(string)
-		bynamespace := referralCandidates.GroupedByOriginalNamespace()
-		if _, ok := bynamespace[namespace]; !ok {
+		// The namespace transformer may already have
+		// moved the reference along with its referral,
+		// so look in both the original and current one.
+		subset = inNamespace(namespace,
+			referralCandidates.GroupedByOriginalNamespace(),
+			referralCandidates.GroupedByCurrentNamespace())
+		if len(subset) == 0 {
 			return inMap, nil
 		}
-		subset = bynamespace[namespace]
 	}
 
 	newname, newnamespace, err := o.selectReferral(oldName, referrer, target,
@@ -265,6 +276,113 @@ func (o *nameReferenceTransformer) getNewNameFunc(
 	}
 }
 
+// getPatternFunc returns a function rewriting the mentions,
+// as the pattern makes them, of the referral candidates in
+// text, e.g. a Service mentioned as
+// {name}.{namespace}.svc.cluster.local in an env value.
+// The text can be a string, or a list or map of strings.
+func (o *nameReferenceTransformer) getPatternFunc(
+	referrer *resource.Resource,
+	target gvk.Gvk,
+	referralCandidates resmap.ResMap,
+	pattern string) func(in interface{}) (interface{}, error) {
+	rewrite := func(text string) string {
+		for _, res := range referralCandidates.Resources() {
+			id := res.OrgId()
+			if !id.IsSelected(&target) {
+				continue
+			}
+			old := strings.NewReplacer(
+				types.PatternName, res.GetOriginalName(),
+				types.PatternNamespace, id.EffectiveNamespace()).Replace(pattern)
+			current := strings.NewReplacer(
+				types.PatternName, res.GetName(),
+				types.PatternNamespace, res.CurId().EffectiveNamespace()).Replace(pattern)
+			if old == current {
+				continue
+			}
+			replaced := replaceMentions(text, old, current)
+			if replaced != text {
+				res.AppendRefBy(referrer.CurId())
+				text = replaced
+			}
+		}
+		return text
+	}
+	return func(in interface{}) (interface{}, error) {
+		switch v := in.(type) {
+		case string:
+			return rewrite(v), nil
+		case []interface{}:
+			for i, item := range v {
+				if s, ok := item.(string); ok {
+					v[i] = rewrite(s)
+				}
+			}
+			return v, nil
+		case map[string]interface{}:
+			for k, item := range v {
+				if s, ok := item.(string); ok {
+					v[k] = rewrite(s)
+				}
+			}
+			return v, nil
+		default:
+			return nil, fmt.Errorf(
+				"%#v is expected to be a string, or a list or map of them", in)
+		}
+	}
+}
+
+// replaceMentions replaces old with current where it
+// stands alone in text, and isn't just part of a longer
+// name, e.g. svc in my-svc or svc.example.com.
+func replaceMentions(text, old, current string) string {
+	var b strings.Builder
+	rest := text
+	for {
+		i := strings.Index(rest, old)
+		if i < 0 {
+			b.WriteString(rest)
+			return b.String()
+		}
+		end := i + len(old)
+		done := len(text) - len(rest)
+		if (done+i > 0 && isNameChar(text[done+i-1])) ||
+			(end < len(rest) && isNameChar(rest[end])) {
+			b.WriteString(rest[:end])
+		} else {
+			b.WriteString(rest[:i])
+			b.WriteString(current)
+		}
+		rest = rest[end:]
+	}
+}
+
+func isNameChar(c byte) bool {
+	return c == '-' || c == '.' || c == '_' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+		('0' <= c && c <= '9')
+}
+
+// inNamespace merges the resources of the namespace
+// from each of the groupings, without repeats.
+func inNamespace(
+	namespace string,
+	groupings ...map[string][]*resource.Resource) []*resource.Resource {
+	var result []*resource.Resource
+	seen := make(map[*resource.Resource]bool)
+	for _, g := range groupings {
+		for _, r := range g[namespace] {
+			if !seen[r] {
+				seen[r] = true
+				result = append(result, r)
+			}
+		}
+	}
+	return result
+}
+
 func indexOf(s string, slice []string) []int {
 	var index []int
 	for i, item := range slice {
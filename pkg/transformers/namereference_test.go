@@ -1059,3 +1059,20 @@ func TestNameReferenceCandidateSelection(t *testing.T) {
 		t.Fatalf("actual doesn't match expected: %v", err)
 	}
 }
+
+func TestReplaceMentions(t *testing.T) {
+	for _, tc := range []struct{ text, expected string }{
+		{"svc", "p-svc"},
+		{"http://svc:8080/x", "http://p-svc:8080/x"},
+		{"svc,svc", "p-svc,p-svc"},
+		{"my-svc", "my-svc"},
+		{"svc2", "svc2"},
+		{"svc.example.com", "svc.example.com"},
+		{"", ""},
+	} {
+		actual := replaceMentions(tc.text, "svc", "p-svc")
+		if actual != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.text, tc.expected, actual)
+		}
+	}
+}
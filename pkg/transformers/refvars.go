@@ -18,6 +18,9 @@ package transformers
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/irairdon/kustomize/v3/pkg/expansion"
 	"github.com/irairdon/kustomize/v3/pkg/resmap"
 	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
@@ -26,8 +29,9 @@ import (
 type RefVarTransformer struct {
 	varMap            map[string]interface{}
 	replacementCounts map[string]int
+	unresolved        map[string]int
 	fieldSpecs        []config.FieldSpec
-	mappingFunc       func(string) interface{}
+	mappingFunc       func(string) (interface{}, error)
 }
 
 // NewRefVarTransformer returns a new RefVarTransformer
@@ -50,7 +54,11 @@ func (rv *RefVarTransformer) replaceVars(in interface{}) (interface{}, error) {
 	case []interface{}:
 		var xs []interface{}
 		for _, a := range in.([]interface{}) {
-			xs = append(xs, expansion.Expand(a.(string), rv.mappingFunc))
+			x, err := expansion.ExpandWithErrors(a.(string), rv.mappingFunc)
+			if err != nil {
+				return nil, err
+			}
+			xs = append(xs, x)
 		}
 		return xs, nil
 	case map[string]interface{}:
@@ -67,7 +75,11 @@ func (rv *RefVarTransformer) replaceVars(in interface{}) (interface{}, error) {
 				// This field can potentially contains a $(VAR) since it is
 				// of string type. For instance .spec.replicas: $(REPLICAS)
 				// in a Deployment object
-				xs[k] = expansion.Expand(s, rv.mappingFunc)
+				x, err := expansion.ExpandWithErrors(s, rv.mappingFunc)
+				if err != nil {
+					return nil, err
+				}
+				xs[k] = x
 			}
 		}
 		return xs, nil
@@ -79,7 +91,7 @@ func (rv *RefVarTransformer) replaceVars(in interface{}) (interface{}, error) {
 		}
 		// This field can potentially contain a $(VAR) since it is
 		// of string type.
-		return expansion.Expand(s, rv.mappingFunc), nil
+		return expansion.ExpandWithErrors(s, rv.mappingFunc)
 	case nil:
 		return nil, nil
 	default:
@@ -100,21 +112,109 @@ func (rv *RefVarTransformer) UnusedVars() []string {
 	return unused
 }
 
+// UnresolvedVars returns the sorted names of vars that
+// were referenced but neither defined nor given a default
+// in a Transform run.
+// Names a container defines in its env, or may get from
+// its envFrom, are left for kubernetes to resolve in that
+// container, and aren't included.
+func (rv *RefVarTransformer) UnresolvedVars() []string {
+	var result []string
+	for k := range rv.unresolved {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
 // Transform replaces $(VAR) style variables with values.
 func (rv *RefVarTransformer) Transform(m resmap.ResMap) error {
 	rv.replacementCounts = make(map[string]int)
-	rv.mappingFunc = expansion.MappingFuncFor(
-		rv.replacementCounts, rv.varMap)
+	rv.unresolved = make(map[string]int)
 	for _, res := range m.Resources() {
 		for _, fieldSpec := range rv.fieldSpecs {
-			if res.OrgId().IsSelected(&fieldSpec.Gvk) {
+			if !res.OrgId().IsSelected(&fieldSpec.Gvk) {
+				continue
+			}
+			path := fieldSpec.PathSlice()
+			i := containersIndex(path)
+			if i < 0 {
+				rv.mappingFunc = expansion.MappingFuncWithUnresolved(
+					rv.replacementCounts, rv.unresolved, rv.varMap)
 				if err := MutateField(
-					res.Map(), fieldSpec.PathSlice(),
-					false, rv.replaceVars); err != nil {
+					res.Map(), path, false, rv.replaceVars); err != nil {
 					return err
 				}
+				continue
+			}
+			if err := MutateField(
+				res.Map(), path[:i+1], false,
+				func(in interface{}) (interface{}, error) {
+					return in, rv.replaceContainerVars(in, path[i+1:])
+				}); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
+
+// containersIndex returns the index of the segment of
+// path naming a list of containers, or -1.
+func containersIndex(path []string) int {
+	for i, p := range path {
+		p = strings.TrimSuffix(p, "[]")
+		if p == "containers" || p == "initContainers" {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceContainerVars replaces vars at the given path in
+// each container in the list in, leaving the names each
+// container may define in its env for kubernetes to expand.
+func (rv *RefVarTransformer) replaceContainerVars(
+	in interface{}, path []string) error {
+	containers, ok := in.([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		unresolved := make(map[string]int)
+		rv.mappingFunc = expansion.MappingFuncWithUnresolved(
+			rv.replacementCounts, unresolved, rv.varMap)
+		if err := MutateField(
+			container, path, false, rv.replaceVars); err != nil {
+			return err
+		}
+		// Any name may come from the ConfigMaps
+		// and Secrets of an envFrom.
+		_, envFrom := container["envFrom"]
+		env := containerEnvNames(container)
+		for k, n := range unresolved {
+			if !envFrom && !env[k] {
+				rv.unresolved[k] += n
+			}
+		}
+	}
+	return nil
+}
+
+// containerEnvNames returns the names of the env vars of a container.
+func containerEnvNames(container map[string]interface{}) map[string]bool {
+	result := make(map[string]bool)
+	env, _ := container["env"].([]interface{})
+	for _, e := range env {
+		if m, ok := e.(map[string]interface{}); ok {
+			if s, ok := m["name"].(string); ok {
+				result[s] = true
+			}
+		}
+	}
+	return result
+}
@@ -27,6 +27,15 @@ type Transformer interface {
 	Transform(m resmap.ResMap) error
 }
 
+// An UnmatchedReporter is a Transformer that can say, after
+// a Transform, which parts of its configuration matched
+// nothing, e.g. a patch whose target selects no resources.
+// Strict builds fail on these.
+type UnmatchedReporter interface {
+	// Unmatched describes each part that matched nothing.
+	Unmatched() []string
+}
+
 // A Generator creates an instance of resmap.ResMap.
 type Generator interface {
 	Generate() (resmap.ResMap, error)
@@ -0,0 +1,91 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/gvk"
+)
+
+// FieldSpec completely specifies a kustomizable field in
+// an unstructured representation of a k8s API object.
+// It helps define the operands of transformations.
+//
+// For example, a directive to add a common label to objects
+// will need to know that a 'Deployment' object (in API group
+// 'apps', any version) can have labels at field path
+// 'spec/template/metadata/labels', and further that it is OK
+// (or not OK) to add that field path to the object if the
+// field path doesn't exist already.
+//
+// This would look like
+// {
+//   group: apps
+//   kind: Deployment
+//   path: spec/template/metadata/labels
+//   create: true
+// }
+type FieldSpec struct {
+	gvk.Gvk            `json:",inline,omitempty" yaml:",inline,omitempty"`
+	Path               string `json:"path,omitempty" yaml:"path,omitempty"`
+	CreateIfNotPresent bool   `json:"create,omitempty" yaml:"create,omitempty"`
+
+	// Pattern, in a nameReference, says the field holds text
+	// that mentions the referral as the pattern does, with
+	// {name} and {namespace} standing for its name and
+	// namespace, e.g. {name}.{namespace}.svc.cluster.local.
+	// In images, it's a regular expression matching the
+	// image references in the field's text, the first group,
+	// if it has one, being the reference, e.g.
+	// --sidecar-image=(\S+).  Without one, the field's
+	// whole value is the reference.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+const (
+	// PatternName stands for a name in a Pattern.
+	PatternName = "{name}"
+	// PatternNamespace stands for a namespace in a Pattern.
+	PatternNamespace = "{namespace}"
+)
+
+const (
+	escapedForwardSlash  = "\\/"
+	tempSlashReplacement = "???"
+)
+
+func (fs FieldSpec) String() string {
+	return fmt.Sprintf(
+		"%s:%v:%s", fs.Gvk.String(), fs.CreateIfNotPresent, fs.Path)
+}
+
+// PathSlice converts the path string to a slice of strings,
+// separated by a '/'. Forward slash can be contained in a
+// fieldname. such as ingress.kubernetes.io/auth-secret in
+// Ingress annotations. To deal with this special case, the
+// path to this field should be formatted as
+//
+//   metadata/annotations/ingress.kubernetes.io\/auth-secret
+//
+// Then PathSlice will return
+//
+//   []string{
+//      "metadata",
+//      "annotations",
+//      "ingress.auth-secretkubernetes.io/auth-secret"
+//   }
+func (fs FieldSpec) PathSlice() []string {
+	if !strings.Contains(fs.Path, escapedForwardSlash) {
+		return strings.Split(fs.Path, "/")
+	}
+	s := strings.Replace(fs.Path, escapedForwardSlash, tempSlashReplacement, -1)
+	paths := strings.Split(s, "/")
+	var result []string
+	for _, path := range paths {
+		result = append(result, strings.Replace(path, tempSlashReplacement, "/", -1))
+	}
+	return result
+}
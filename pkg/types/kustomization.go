@@ -5,6 +5,8 @@
 package types
 
 import (
+	"strings"
+
 	"github.com/irairdon/kustomize/v3/pkg/gvk"
 	"github.com/irairdon/kustomize/v3/pkg/image"
 )
@@ -50,6 +52,10 @@ type Kustomization struct {
 	// CommonLabels to add to all objects and selectors.
 	CommonLabels map[string]string `json:"commonLabels,omitempty" yaml:"commonLabels,omitempty"`
 
+	// Labels to add to all objects, and optionally
+	// to selectors and templates.
+	Labels []Label `json:"labels,omitempty" yaml:"labels,omitempty"`
+
 	// CommonAnnotations to add to all objects.
 	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty" yaml:"commonAnnotations,omitempty"`
 
@@ -87,6 +93,13 @@ type Kustomization struct {
 	// value of the specified field has been determined.
 	Vars []Var `json:"vars,omitempty" yaml:"vars,omitempty"`
 
+	// Replacements copy the value of a field in one resource
+	// into fields of other resources, optionally into just
+	// one delimited part of a string field, e.g. the host
+	// of a URL.  Unlike Vars, they need no placeholders and
+	// no varReference configuration to reach a field.
+	Replacements []Replacement `json:"replacements,omitempty" yaml:"replacements,omitempty"`
+
 	//
 	// Operands - what kustomize operates on.
 	//
@@ -349,15 +362,26 @@ type PatchTarget struct {
 type PatchStrategicMerge string
 
 // Replica specifies a modification to a replica config.
-// The number of replicas of a resource whose name matches will be set to count.
+// The number of replicas of a resource whose name matches,
+// and that the selector, if any, selects, will be set to count.
 // This struct is used by the ReplicaCountTransform, and is meant to supplement
 // the existing patch functionality with a simpler syntax for replica configuration.
 type Replica struct {
 	// The name of the resource to change the replica count
 	Name string `json:"name,omitempty" yaml:"name,omitempty"`
 
-	// The number of replicas required.
+	// Selector picks the resources to change, alone or
+	// together with Name.
+	Selector *Selector `json:"selector,omitempty" yaml:"selector,omitempty"`
+
+	// The number of replicas required.  Left alone if zero
+	// and MinReplicas or MaxReplicas is set.
 	Count int64 `json:"count,omitempty" yaml:"count,omitempty"`
+
+	// MinReplicas and MaxReplicas bound the number of
+	// replicas a HorizontalPodAutoscaler may set.
+	MinReplicas *int64 `json:"minReplicas,omitempty" yaml:"minReplicas,omitempty"`
+	MaxReplicas *int64 `json:"maxReplicas,omitempty" yaml:"maxReplicas,omitempty"`
 }
 
 // Patch represent either a Strategic Merge Patch or a JSON patch
@@ -393,3 +417,20 @@ type Selector struct {
 	// It matches with the resource labels.
 	LabelSelector string `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
 }
+
+// String lists the conditions the selector sets,
+// e.g. {kind=Deployment, name=web}.
+func (s Selector) String() string {
+	var parts []string
+	for _, p := range []struct{ k, v string }{
+		{"group", s.Group}, {"version", s.Version}, {"kind", s.Kind},
+		{"namespace", s.Namespace}, {"name", s.Name},
+		{"labelSelector", s.LabelSelector},
+		{"annotationSelector", s.AnnotationSelector},
+	} {
+		if p.v != "" {
+			parts = append(parts, p.k+"="+p.v)
+		}
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,23 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// Label adds labels to resources.  Unlike CommonLabels,
+// by default the labels go in metadata only, leaving
+// selectors, which may be immutable, alone.
+type Label struct {
+	// Pairs of label keys and values.
+	Pairs map[string]string `json:"pairs,omitempty" yaml:"pairs,omitempty"`
+
+	// IncludeSelectors adds the labels to selectors and
+	// templates too, as CommonLabels does.
+	IncludeSelectors bool `json:"includeSelectors,omitempty" yaml:"includeSelectors,omitempty"`
+
+	// IncludeTemplates adds the labels to templates,
+	// e.g. a Deployment's pod template, but not selectors.
+	IncludeTemplates bool `json:"includeTemplates,omitempty" yaml:"includeTemplates,omitempty"`
+
+	// FieldSpecs are more fields to add the labels to.
+	FieldSpecs []FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+}
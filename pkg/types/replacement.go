@@ -0,0 +1,61 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// Replacement copies a value from a field of one
+// resource into fields of other resources.
+type Replacement struct {
+	// Source says where the value comes from.
+	Source *ReplSource `json:"source" yaml:"source"`
+
+	// Targets say where the value goes.
+	Targets []*ReplTarget `json:"targets" yaml:"targets"`
+}
+
+// ReplSource selects exactly one resource, and
+// the field in it holding the value to copy.
+type ReplSource struct {
+	Selector `json:",inline,omitempty" yaml:",inline,omitempty"`
+
+	// FieldPath is the path to the field holding the
+	// value, e.g. spec.template.spec.containers[name=app].image.
+	// If unspecified, it defaults to metadata.name.
+	FieldPath string `json:"fieldPath,omitempty" yaml:"fieldPath,omitempty"`
+
+	// Options, if given, take just one delimited
+	// part of the field's value.
+	Options *FieldOptions `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// ReplTarget selects resources, and the fields
+// in them to replace.
+type ReplTarget struct {
+	// Select picks the resources to change.
+	Select *Selector `json:"select" yaml:"select"`
+
+	// Reject drops resources that Select picked.
+	Reject []*Selector `json:"reject,omitempty" yaml:"reject,omitempty"`
+
+	// FieldPaths are the paths to the fields to replace.
+	FieldPaths []string `json:"fieldPaths" yaml:"fieldPaths"`
+
+	// Options, if given, replace just one delimited
+	// part of each field's value, or create missing fields.
+	Options *FieldOptions `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+// FieldOptions refine how a replacement reads or
+// writes a field.
+type FieldOptions struct {
+	// Delimiter splits a string field into parts.
+	Delimiter string `json:"delimiter,omitempty" yaml:"delimiter,omitempty"`
+
+	// Index picks one of the parts made by Delimiter.
+	// In a target, an index before the first part or
+	// after the last one adds a part there.
+	Index int `json:"index,omitempty" yaml:"index,omitempty"`
+
+	// Create makes a target field if it's missing.
+	Create bool `json:"create,omitempty" yaml:"create,omitempty"`
+}
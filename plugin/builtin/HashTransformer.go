@@ -6,10 +6,19 @@ import (
 
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
 	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
+	"sigs.k8s.io/yaml"
 )
 
+// Append a hash of their content to the names of
+// generated resources, and those annotated to ask
+// for one.  Field specs narrow the content hashed
+// for the kinds they select.
 type HashTransformerPlugin struct {
-	hasher ifc.KunstructuredHasher
+	FieldSpecs []config.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	hasher     ifc.KunstructuredHasher
+	rf         *resource.Factory
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -18,16 +27,18 @@ func NewHashTransformerPlugin() *HashTransformerPlugin {
 }
 
 func (p *HashTransformerPlugin) Config(
-	ldr ifc.Loader, rf *resmap.Factory, config []byte) (err error) {
+	ldr ifc.Loader, rf *resmap.Factory, c []byte) (err error) {
 	p.hasher = rf.RF().Hasher()
-	return nil
+	p.rf = rf.RF()
+	p.FieldSpecs = nil
+	return yaml.Unmarshal(c, p)
 }
 
 // Transform appends hash to generated resources.
 func (p *HashTransformerPlugin) Transform(m resmap.ResMap) error {
 	for _, res := range m.Resources() {
-		if res.NeedHashSuffix() {
-			h, err := p.hasher.Hash(res)
+		if res.NeedHashSuffix() && !res.Skips(resource.SkipHash) {
+			h, err := p.hasher.Hash(p.hashed(res))
 			if err != nil {
 				return err
 			}
@@ -36,3 +47,49 @@ func (p *HashTransformerPlugin) Transform(m resmap.ResMap) error {
 	}
 	return nil
 }
+
+// hashed returns the resource, or, if field specs select
+// it, a copy holding only its kind, name and the fields
+// they name.
+func (p *HashTransformerPlugin) hashed(res *resource.Resource) ifc.Kunstructured {
+	var paths [][]string
+	for _, fs := range p.FieldSpecs {
+		if res.OrgId().IsSelected(&fs.Gvk) {
+			paths = append(paths, fs.PathSlice())
+		}
+	}
+	if len(paths) == 0 {
+		return res
+	}
+	m := map[string]interface{}{
+		"apiVersion": res.Map()["apiVersion"],
+		"kind":       res.GetKind(),
+		"metadata": map[string]interface{}{
+			"name": res.GetName(),
+		},
+	}
+	for _, path := range paths {
+		copyField(res.Map(), m, path)
+	}
+	return p.rf.FromMap(m)
+}
+
+// copyField copies the field at the path, if there is
+// one.  Past a list, all of the list is copied.
+func copyField(from, to map[string]interface{}, path []string) {
+	v, ok := from[path[0]]
+	if !ok {
+		return
+	}
+	fromMap, ok := v.(map[string]interface{})
+	if len(path) == 1 || !ok {
+		to[path[0]] = v
+		return
+	}
+	toMap, ok := to[path[0]].(map[string]interface{})
+	if !ok {
+		toMap = make(map[string]interface{})
+		to[path[0]] = toMap
+	}
+	copyField(fromMap, toMap, path[1:])
+}
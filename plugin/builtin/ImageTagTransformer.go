@@ -4,6 +4,7 @@ package builtin
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
@@ -19,6 +20,11 @@ import (
 type ImageTagTransformerPlugin struct {
 	ImageTag   image.Image        `json:"imageTag,omitempty" yaml:"imageTag,omitempty"`
 	FieldSpecs []config.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	images     []image.Image
+	// patterns match the image references in the
+	// values of the field specs, by index.
+	patterns []*regexp.Regexp
+	matched  bool
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -30,49 +36,170 @@ func (p *ImageTagTransformerPlugin) Config(
 	ldr ifc.Loader, rf *resmap.Factory, c []byte) (err error) {
 	p.ImageTag = image.Image{}
 	p.FieldSpecs = nil
-	return yaml.Unmarshal(c, p)
+	err = yaml.Unmarshal(c, p)
+	if err != nil {
+		return err
+	}
+	p.patterns = nil
+	for _, fs := range p.FieldSpecs {
+		re := wholeValue
+		if fs.Pattern != "" {
+			re, err = regexp.Compile(fs.Pattern)
+			if err != nil {
+				return fmt.Errorf(
+					"images field spec %s has a bad pattern: %v", fs.Path, err)
+			}
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	p.images, err = imagesToReplace(ldr, p.ImageTag)
+	return err
+}
+
+// imagesToReplace returns the configured image, with its
+// digest read from the digest file if there is one, or,
+// if it has no name, every image in the digest file.
+func imagesToReplace(
+	ldr ifc.Loader, img image.Image) ([]image.Image, error) {
+	if img.DigestFile == "" {
+		return []image.Image{img}, nil
+	}
+	content, err := ldr.Load(img.DigestFile)
+	if err != nil {
+		return nil, err
+	}
+	digests := make(map[string]string)
+	err = yaml.Unmarshal(content, &digests)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"digest file %s must map image names to digests: %v",
+			img.DigestFile, err)
+	}
+	if img.Name != "" {
+		d, ok := digests[img.Name]
+		if !ok {
+			return nil, fmt.Errorf(
+				"digest file %s has no image %s", img.DigestFile, img.Name)
+		}
+		img.Digest = d
+		return []image.Image{img}, nil
+	}
+	if img.NewName != "" || img.NewTag != "" || img.Digest != "" {
+		return nil, fmt.Errorf(
+			"image with digest file %s and no name can't change names, tags or digests",
+			img.DigestFile)
+	}
+	var names []string
+	for name := range digests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var result []image.Image
+	for _, name := range names {
+		result = append(result, image.Image{Name: name, Digest: digests[name]})
+	}
+	return result, nil
 }
 
 func (p *ImageTagTransformerPlugin) Transform(m resmap.ResMap) error {
-	for _, r := range m.Resources() {
-		for _, path := range p.FieldSpecs {
-			if !r.OrgId().IsSelected(&path.Gvk) {
-				continue
+	p.matched = false
+	for _, img := range p.images {
+		for _, r := range m.Resources() {
+			for i, path := range p.FieldSpecs {
+				if !r.OrgId().IsSelected(&path.Gvk) {
+					continue
+				}
+				err := transformers.MutateField(
+					r.Map(), path.PathSlice(), false,
+					p.mutateImagesIn(img, p.patterns[i]))
+				if err != nil {
+					return err
+				}
 			}
-			err := transformers.MutateField(
-				r.Map(), path.PathSlice(), false, p.mutateImage)
-			if err != nil {
+			// Kept for backward compatibility
+			if err := p.findAndReplaceImage(r.Map(), img); err != nil && r.OrgId().Kind != `CustomResourceDefinition` {
 				return err
 			}
 		}
-		// Kept for backward compatibility
-		if err := p.findAndReplaceImage(r.Map()); err != nil && r.OrgId().Kind != `CustomResourceDefinition` {
-			return err
-		}
 	}
 	return nil
 }
 
-func (p *ImageTagTransformerPlugin) mutateImage(in interface{}) (interface{}, error) {
-	original, ok := in.(string)
-	if !ok {
-		return nil, fmt.Errorf("image path is not of type string but %T", in)
-	}
+// wholeValue is the pattern of a field spec that doesn't
+// give one: the field's whole value is the reference.
+var wholeValue = regexp.MustCompile(`(?s)^.*$`)
 
-	if !isImageMatched(original, p.ImageTag.Name) {
-		return original, nil
+// mutateImagesIn replaces the image wherever the pattern
+// finds it in a string field, or in a list of strings,
+// e.g. --sidecar=nginx:1.7 in an args entry given the
+// pattern --sidecar=(\S+).  If the pattern has a group,
+// the first group is the reference.
+func (p *ImageTagTransformerPlugin) mutateImagesIn(
+	img image.Image,
+	re *regexp.Regexp) func(in interface{}) (interface{}, error) {
+	group := 0
+	if re.NumSubexp() > 0 {
+		group = 1
+	}
+	replace := func(s string) string {
+		var b strings.Builder
+		last := 0
+		for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+			start, end := m[2*group], m[2*group+1]
+			if start < 0 || !isImageMatched(s[start:end], img.Name) {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(p.mutateImage(s[start:end], img))
+			last = end
+		}
+		b.WriteString(s[last:])
+		return b.String()
+	}
+	return func(in interface{}) (interface{}, error) {
+		switch v := in.(type) {
+		case string:
+			return replace(v), nil
+		case []interface{}:
+			for i, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf(
+						"image path is not of type string but %T", item)
+				}
+				v[i] = replace(s)
+			}
+			return v, nil
+		default:
+			return nil, fmt.Errorf("image path is not of type string but %T", in)
+		}
 	}
+}
+
+func (p *ImageTagTransformerPlugin) mutateImage(original string, img image.Image) string {
+	p.matched = true
 	name, tag := split(original)
-	if p.ImageTag.NewName != "" {
-		name = p.ImageTag.NewName
+	if img.NewName != "" {
+		name = img.NewName
 	}
-	if p.ImageTag.NewTag != "" {
-		tag = ":" + p.ImageTag.NewTag
+	if img.NewTag != "" {
+		tag = ":" + img.NewTag
 	}
-	if p.ImageTag.Digest != "" {
-		tag = "@" + p.ImageTag.Digest
+	if img.Digest != "" {
+		tag = "@" + img.Digest
 	}
-	return name + tag, nil
+	return name + tag
+}
+
+// Unmatched says if the image matched no container.
+// Images that are only in a digest file aren't reported,
+// as such a file may cover more than one build.
+func (p *ImageTagTransformerPlugin) Unmatched() []string {
+	if p.matched || p.ImageTag.Name == "" {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("image %s matches no container", p.ImageTag.Name)}
 }
 
 // findAndReplaceImage replaces the image name and
@@ -81,25 +208,27 @@ func (p *ImageTagTransformerPlugin) mutateImage(in interface{}) (interface{}, er
 // then loops though all images inside containers
 // session, finds matched ones and update the
 // image name and tag name
-func (p *ImageTagTransformerPlugin) findAndReplaceImage(obj map[string]interface{}) error {
+func (p *ImageTagTransformerPlugin) findAndReplaceImage(
+	obj map[string]interface{}, img image.Image) error {
 	paths := []string{"containers", "initContainers"}
 	updated := false
 	for _, path := range paths {
 		containers, found := obj[path]
 		if found {
-			if _, err := p.updateContainers(containers); err != nil {
+			if _, err := p.updateContainers(containers, img); err != nil {
 				return err
 			}
 			updated = true
 		}
 	}
 	if !updated {
-		return p.findContainers(obj)
+		return p.findContainers(obj, img)
 	}
 	return nil
 }
 
-func (p *ImageTagTransformerPlugin) updateContainers(in interface{}) (interface{}, error) {
+func (p *ImageTagTransformerPlugin) updateContainers(
+	in interface{}, img image.Image) (interface{}, error) {
 	containers, ok := in.([]interface{})
 	if !ok {
 		return nil, fmt.Errorf(
@@ -112,22 +241,19 @@ func (p *ImageTagTransformerPlugin) updateContainers(in interface{}) (interface{
 			continue
 		}
 		imageName := containerImage.(string)
-		if isImageMatched(imageName, p.ImageTag.Name) {
-			newImage, err := p.mutateImage(imageName)
-			if err != nil {
-				return nil, err
-			}
-			container["image"] = newImage
+		if isImageMatched(imageName, img.Name) {
+			container["image"] = p.mutateImage(imageName, img)
 		}
 	}
 	return containers, nil
 }
 
-func (p *ImageTagTransformerPlugin) findContainers(obj map[string]interface{}) error {
+func (p *ImageTagTransformerPlugin) findContainers(
+	obj map[string]interface{}, img image.Image) error {
 	for key := range obj {
 		switch typedV := obj[key].(type) {
 		case map[string]interface{}:
-			err := p.findAndReplaceImage(typedV)
+			err := p.findAndReplaceImage(typedV, img)
 			if err != nil {
 				return err
 			}
@@ -136,7 +262,7 @@ func (p *ImageTagTransformerPlugin) findContainers(obj map[string]interface{}) e
 				item := typedV[i]
 				typedItem, ok := item.(map[string]interface{})
 				if ok {
-					err := p.findAndReplaceImage(typedItem)
+					err := p.findAndReplaceImage(typedItem, img)
 					if err != nil {
 						return err
 					}
@@ -4,6 +4,7 @@ package builtin
 import (
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
 	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
 	"github.com/irairdon/kustomize/v3/pkg/transformers"
 	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
 	"sigs.k8s.io/yaml"
@@ -35,5 +36,17 @@ func (p *LabelTransformerPlugin) Transform(m resmap.ResMap) error {
 	if err != nil {
 		return err
 	}
-	return t.Transform(m)
+	// Resources opting out are left out of the map
+	// handed to the transformer.
+	applicable := resmap.New()
+	for _, r := range m.Resources() {
+		if r.Skips(resource.SkipLabels) {
+			continue
+		}
+		err = applicable.Append(r)
+		if err != nil {
+			return err
+		}
+	}
+	return t.Transform(applicable)
 }
@@ -20,6 +20,7 @@ type PatchTransformerPlugin struct {
 	Path         string          `json:"path,omitempty" yaml:"path,omitempty"`
 	Patch        string          `json:"patch,omitempty" yaml:"patch,omitempty"`
 	Target       *types.Selector `json:"target,omitempty", yaml:"target,omitempty"`
+	unmatched    bool
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -78,6 +79,7 @@ func (p *PatchTransformerPlugin) Config(
 }
 
 func (p *PatchTransformerPlugin) Transform(m resmap.ResMap) error {
+	p.unmatched = false
 	if p.loadedPatch != nil && p.Target == nil {
 		target, err := m.GetById(p.loadedPatch.OrgId())
 		if err != nil {
@@ -98,6 +100,7 @@ func (p *PatchTransformerPlugin) Transform(m resmap.ResMap) error {
 	if err != nil {
 		return err
 	}
+	p.unmatched = len(resources) == 0
 	for _, resource := range resources {
 		if p.decodedPatch != nil {
 			rawObj, err := resource.MarshalJSON()
@@ -128,6 +131,15 @@ func (p *PatchTransformerPlugin) Transform(m resmap.ResMap) error {
 	return nil
 }
 
+// Unmatched says if the target selected no resources.
+func (p *PatchTransformerPlugin) Unmatched() []string {
+	if !p.unmatched {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("patch target %s selects no resources", p.Target)}
+}
+
 // jsonPatchFromBytes loads a Json 6902 patch from
 // a bytes input
 func jsonPatchFromBytes(
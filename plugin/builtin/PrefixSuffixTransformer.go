@@ -9,6 +9,7 @@ import (
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
 	"github.com/irairdon/kustomize/v3/pkg/resid"
 	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
 	"github.com/irairdon/kustomize/v3/pkg/transformers"
 	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
 	"sigs.k8s.io/yaml"
@@ -61,6 +62,13 @@ func (p *PrefixSuffixTransformerPlugin) Transform(m resmap.ResMap) error {
 			continue
 		}
 		id := r.OrgId()
+		prefix, suffix := p.Prefix, p.Suffix
+		if r.Skips(resource.SkipPrefix) {
+			prefix = ""
+		}
+		if r.Skips(resource.SkipSuffix) {
+			suffix = ""
+		}
 		// current default configuration contains
 		// only one entry: "metadata/name" with no GVK
 		for _, path := range p.FieldSpecs {
@@ -76,18 +84,18 @@ func (p *PrefixSuffixTransformerPlugin) Transform(m resmap.ResMap) error {
 				// this will add a prefix and a suffix
 				// to the resource even if those are
 				// empty
-				r.AddNamePrefix(p.Prefix)
-				r.AddNameSuffix(p.Suffix)
+				r.AddNamePrefix(prefix)
+				r.AddNameSuffix(suffix)
 			}
 
-			// the addPrefixSuffix method will not
+			// the addPrefixSuffix function will not
 			// change the name if both the prefix and suffix
 			// are empty.
 			err := transformers.MutateField(
 				r.Map(),
 				path.PathSlice(),
 				path.CreateIfNotPresent,
-				p.addPrefixSuffix)
+				addPrefixSuffix(prefix, suffix))
 			if err != nil {
 				return err
 			}
@@ -110,11 +118,13 @@ func (p *PrefixSuffixTransformerPlugin) shouldSkip(
 	return false
 }
 
-func (p *PrefixSuffixTransformerPlugin) addPrefixSuffix(
-	in interface{}) (interface{}, error) {
-	s, ok := in.(string)
-	if !ok {
-		return nil, fmt.Errorf("%#v is expected to be %T", in, s)
+func addPrefixSuffix(
+	prefix, suffix string) func(interface{}) (interface{}, error) {
+	return func(in interface{}) (interface{}, error) {
+		s, ok := in.(string)
+		if !ok {
+			return nil, fmt.Errorf("%#v is expected to be %T", in, s)
+		}
+		return fmt.Sprintf("%s%s%s", prefix, s, suffix), nil
 	}
-	return fmt.Sprintf("%s%s%s", p.Prefix, s, p.Suffix), nil
 }
@@ -3,10 +3,11 @@ package builtin
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
-	"github.com/irairdon/kustomize/v3/pkg/resid"
 	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
 	"github.com/irairdon/kustomize/v3/pkg/transformers"
 	"github.com/irairdon/kustomize/v3/pkg/transformers/config"
 	"github.com/irairdon/kustomize/v3/pkg/types"
@@ -18,11 +19,17 @@ import (
 type ReplicaCountTransformerPlugin struct {
 	Replica    types.Replica      `json:"replica,omitempty" yaml:"replica,omitempty"`
 	FieldSpecs []config.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+
+	// MinReplicasFieldSpecs and MaxReplicasFieldSpecs are
+	// the fields, e.g. of a HorizontalPodAutoscaler, that
+	// the replica's minReplicas and maxReplicas set.
+	MinReplicasFieldSpecs []config.FieldSpec `json:"minReplicasFieldSpecs,omitempty" yaml:"minReplicasFieldSpecs,omitempty"`
+	MaxReplicasFieldSpecs []config.FieldSpec `json:"maxReplicasFieldSpecs,omitempty" yaml:"maxReplicasFieldSpecs,omitempty"`
 }
 
 //noinspection GoUnusedGlobalVariable
 func NewReplicaCountTransformerPlugin() *ReplicaCountTransformerPlugin {
-	return &ReplicaCountTransformerPlugin{}
+  return &ReplicaCountTransformerPlugin{}
 }
 
 func (p *ReplicaCountTransformerPlugin) Config(
@@ -30,22 +37,34 @@ func (p *ReplicaCountTransformerPlugin) Config(
 
 	p.Replica = types.Replica{}
 	p.FieldSpecs = nil
-	return yaml.Unmarshal(c, p)
+	p.MinReplicasFieldSpecs = nil
+	p.MaxReplicasFieldSpecs = nil
+	err = yaml.Unmarshal(c, p)
+	if err != nil {
+		return err
+	}
+	if p.Replica.Name == "" && p.Replica.Selector == nil {
+		return fmt.Errorf("replica must have a name or a selector in\n%s", string(c))
+	}
+	return nil
 }
 
 func (p *ReplicaCountTransformerPlugin) Transform(m resmap.ResMap) error {
-
+	resources, err := p.selectResources(m)
+	if err != nil {
+		return err
+	}
+	fields := p.fields()
 	found := false
-	for i, replicaSpec := range p.FieldSpecs {
-		matcher := p.createMatcher(i)
-		matchOriginal := m.GetMatchingResourcesByOriginalId(matcher)
-		matchCurrent := m.GetMatchingResourcesByCurrentId(matcher)
-
-		for _, res := range append(matchOriginal, matchCurrent...) {
+	for _, f := range fields {
+		for _, res := range resources {
+			if !res.GetGvk().IsSelected(&f.spec.Gvk) {
+				continue
+			}
 			found = true
 			err := transformers.MutateField(
-				res.Map(), replicaSpec.PathSlice(),
-				replicaSpec.CreateIfNotPresent, p.addReplicas)
+				res.Map(), f.spec.PathSlice(),
+				f.spec.CreateIfNotPresent, setReplicas(f.count))
 			if err != nil {
 				return err
 			}
@@ -53,38 +72,94 @@ func (p *ReplicaCountTransformerPlugin) Transform(m resmap.ResMap) error {
 	}
 
 	if !found {
-		gvks := make([]string, len(p.FieldSpecs))
-		for i, replicaSpec := range p.FieldSpecs {
-			gvks[i] = replicaSpec.Gvk.String()
+		gvks := make([]string, len(fields))
+		for i, f := range fields {
+			gvks[i] = f.spec.Gvk.String()
 		}
-		return fmt.Errorf("Resource with name %s does not match a config with the following GVK %v",
-			p.Replica.Name, gvks)
+		return fmt.Errorf("Resource %s does not match a config with the following GVK %v",
+			p.describe(), gvks)
 	}
 
 	return nil
 }
 
-// Match Replica.Name and FieldSpec
-func (p *ReplicaCountTransformerPlugin) createMatcher(i int) resmap.IdMatcher {
-	return func(r resid.ResId) bool {
-		return r.Name == p.Replica.Name &&
-			r.Gvk.IsSelected(&p.FieldSpecs[i].Gvk)
+// field is a field to set, and the count to set it to.
+type field struct {
+	spec  config.FieldSpec
+	count int64
+}
+
+func (p *ReplicaCountTransformerPlugin) fields() []field {
+	var result []field
+	r := p.Replica
+	if r.Count != 0 || (r.MinReplicas == nil && r.MaxReplicas == nil) {
+		for _, fs := range p.FieldSpecs {
+			result = append(result, field{spec: fs, count: r.Count})
+		}
 	}
+	if r.MinReplicas != nil {
+		for _, fs := range p.MinReplicasFieldSpecs {
+			result = append(result, field{spec: fs, count: *r.MinReplicas})
+		}
+	}
+	if r.MaxReplicas != nil {
+		for _, fs := range p.MaxReplicasFieldSpecs {
+			result = append(result, field{spec: fs, count: *r.MaxReplicas})
+		}
+	}
+	return result
 }
 
-func (p *ReplicaCountTransformerPlugin) addReplicas(in interface{}) (interface{}, error) {
-	switch m := in.(type) {
-	case int64:
-		// Was already in the field.
-	case map[string]interface{}:
-		if len(m) != 0 {
-			// A map was already in the replicas field, don't want to
-			// discard this data silently.
+// selectResources returns the resources the selector, if
+// any, selects, whose original or current name matches.
+func (p *ReplicaCountTransformerPlugin) selectResources(
+	m resmap.ResMap) ([]*resource.Resource, error) {
+	candidates := m.Resources()
+	if p.Replica.Selector != nil {
+		var err error
+		candidates, err = m.Select(*p.Replica.Selector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.Replica.Name == "" {
+		return candidates, nil
+	}
+	var result []*resource.Resource
+	for _, r := range candidates {
+		if r.OrgId().Name == p.Replica.Name || r.CurId().Name == p.Replica.Name {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (p *ReplicaCountTransformerPlugin) describe() string {
+	var parts []string
+	if p.Replica.Name != "" {
+		parts = append(parts, "with name "+p.Replica.Name)
+	}
+	if p.Replica.Selector != nil {
+		parts = append(parts, "selected by "+p.Replica.Selector.String())
+	}
+	return strings.Join(parts, " and ")
+}
+
+func setReplicas(count int64) func(in interface{}) (interface{}, error) {
+	return func(in interface{}) (interface{}, error) {
+		switch m := in.(type) {
+		case int64:
+			// Was already in the field.
+		case map[string]interface{}:
+			if len(m) != 0 {
+				// A map was already in the replicas field, don't want to
+				// discard this data silently.
+				return nil, fmt.Errorf("%#v is expected to be %T", in, m)
+			}
+			// Just got added, default type is map, but we can return anything.
+		default:
 			return nil, fmt.Errorf("%#v is expected to be %T", in, m)
 		}
-		// Just got added, default type is map, but we can return anything.
-	default:
-		return nil, fmt.Errorf("%#v is expected to be %T", in, m)
+		return count, nil
 	}
-	return p.Replica.Count, nil
 }
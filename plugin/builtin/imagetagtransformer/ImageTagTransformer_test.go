@@ -4,6 +4,7 @@
 package main_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/irairdon/kustomize/v3/pkg/kusttest"
@@ -370,3 +371,268 @@ spec:
         name: init-alpine
 `)
 }
+
+func TestImageTagTransformerDigestFile(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ImageTagTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+	th.WriteF("/app/digests.json", `{
+  "nginx": "sha256:24a0c4b4a4c0eb97a1aabb8e29f18e917d05abfe1b7a7c07857230879ce7d3d3",
+  "busybox": "sha256:7964ad52e396a6e045c39b5a44438424ac52e12e4d5a25d94895f2058cb863a0"
+}`)
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ImageTagTransformer
+metadata:
+  name: notImportantHere
+imageTag:
+  digestFile: digests.json
+`, `
+group: apps
+apiVersion: v1
+kind: Deployment
+metadata:
+  name: deploy1
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: busybox
+      containers:
+      - name: web
+        image: nginx:1.7.9
+      - name: other
+        image: redis
+`)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+group: apps
+kind: Deployment
+metadata:
+  name: deploy1
+spec:
+  template:
+    spec:
+      containers:
+      - image: nginx@sha256:24a0c4b4a4c0eb97a1aabb8e29f18e917d05abfe1b7a7c07857230879ce7d3d3
+        name: web
+      - image: redis
+        name: other
+      initContainers:
+      - image: busybox@sha256:7964ad52e396a6e045c39b5a44438424ac52e12e4d5a25d94895f2058cb863a0
+        name: init
+`)
+}
+
+func TestImageTagTransformerDigestFileMissingImage(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ImageTagTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+	th.WriteF("/app/digests.yaml", `
+busybox: sha256:7964ad52e396a6e045c39b5a44438424ac52e12e4d5a25d94895f2058cb863a0
+`)
+
+	err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: ImageTagTransformer
+metadata:
+  name: notImportantHere
+imageTag:
+  name: nginx
+  newName: my.registry/nginx
+  digestFile: digests.yaml
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+`)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if !strings.HasSuffix(err.Error(), "digest file digests.yaml has no image nginx") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Images named in text, e.g. args, are replaced
+// where a field spec says to look.
+func TestImageTagTransformerImagesInText(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ImageTagTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ImageTagTransformer
+metadata:
+  name: notImportantHere
+imageTag:
+  name: envoy
+  newName: my.registry/envoy
+  newTag: v2
+fieldSpecs:
+- path: spec/containers/args
+  kind: Pod
+  pattern: --sidecar-image=(\S+)
+- path: spec/sidecar
+  kind: Mesh
+  pattern: \S+
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+spec:
+  containers:
+  - name: injector
+    image: injector:v1
+    args:
+    - --sidecar-image=envoy:v1
+    - --proxy=envoyproxy:v1
+---
+apiVersion: example.com/v1
+kind: Mesh
+metadata:
+  name: mesh
+spec:
+  sidecar: run envoy:v1 with envoy@sha256:abc
+`)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+spec:
+  containers:
+  - args:
+    - --sidecar-image=my.registry/envoy:v2
+    - --proxy=envoyproxy:v1
+    image: injector:v1
+    name: injector
+---
+apiVersion: example.com/v1
+kind: Mesh
+metadata:
+  name: mesh
+spec:
+  sidecar: run my.registry/envoy:v2 with my.registry/envoy:v2
+`)
+}
+
+func TestImageTagTransformerWholeValueWithoutPattern(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ImageTagTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ImageTagTransformer
+metadata:
+  name: notImportantHere
+imageTag:
+  name: envoy
+  newTag: v2
+fieldSpecs:
+- path: spec/containers/args
+  kind: Pod
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+spec:
+  containers:
+  - name: injector
+    image: injector:v1
+    args:
+    - envoy:v1
+    - envoy:v1 --flag
+    - --mirror=https://mirror.example.com/envoy:v1
+`)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+spec:
+  containers:
+  - args:
+    - envoy:v2
+    - envoy:v1 --flag
+    - --mirror=https://mirror.example.com/envoy:v1
+    image: injector:v1
+    name: injector
+`)
+}
+
+func TestImageTagTransformerImagesInTextWithPattern(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ImageTagTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ImageTagTransformer
+metadata:
+  name: notImportantHere
+imageTag:
+  name: envoy
+  newTag: v2
+fieldSpecs:
+- path: spec/containers/args
+  kind: Pod
+  pattern: --sidecar-image=(\S+)
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+spec:
+  containers:
+  - name: injector
+    image: injector:v1
+    args:
+    - --sidecar-image=envoy:v1 --fallback=envoy:v1
+    - --proxy=envoy:v1
+`)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod1
+spec:
+  containers:
+  - args:
+    - --sidecar-image=envoy:v2 --fallback=envoy:v1
+    - --proxy=envoy:v1
+    image: injector:v1
+    name: injector
+`)
+}
@@ -53,3 +53,53 @@ spec:
   - port: 7002
 `)
 }
+
+func TestLabelTransformerSkip(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "LabelTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: LabelTransformer
+metadata:
+  name: notImportantHere
+labels:
+  app: myApp
+fieldSpecs:
+  - path: metadata/labels
+    create: true
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: myService
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: sharedService
+  annotations:
+    kustomize.config.k8s.io/skip: namespace,labels
+`)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: Service
+metadata:
+  labels:
+    app: myApp
+  name: myService
+---
+apiVersion: v1
+kind: Service
+metadata:
+  annotations:
+    kustomize.config.k8s.io/skip: namespace,labels
+  name: sharedService
+`)
+}
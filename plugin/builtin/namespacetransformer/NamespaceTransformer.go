@@ -7,6 +7,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/irairdon/kustomize/v3/pkg/gvk"
 	"github.com/irairdon/kustomize/v3/pkg/ifc"
 	"github.com/irairdon/kustomize/v3/pkg/resid"
 	"github.com/irairdon/kustomize/v3/pkg/resmap"
@@ -18,9 +19,13 @@ import (
 )
 
 // Change or set the namespace of non-cluster level resources.
+// References from resources in the build to those whose
+// namespace changes, e.g. RoleBinding subjects, are kept
+// pointing at them.
 type plugin struct {
 	types.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
-	FieldSpecs       []config.FieldSpec `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	FieldSpecs       []config.FieldSpec          `json:"fieldSpecs,omitempty" yaml:"fieldSpecs,omitempty"`
+	NameReference    []config.NameBackReferences `json:"nameReference,omitempty" yaml:"nameReference,omitempty"`
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -30,6 +35,7 @@ func (p *plugin) Config(
 	ldr ifc.Loader, rf *resmap.Factory, c []byte) (err error) {
 	p.Namespace = ""
 	p.FieldSpecs = nil
+	p.NameReference = nil
 	return yaml.Unmarshal(c, p)
 }
 
@@ -37,14 +43,19 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 	if len(p.Namespace) == 0 {
 		return nil
 	}
+	moved := make(map[*resource.Resource]string)
 	for _, r := range m.Resources() {
 		if len(r.Map()) == 0 {
 			// Don't mutate empty objects?
 			continue
 		}
+		if r.Skips(resource.SkipNamespace) {
+			continue
+		}
 
 		id := r.OrgId()
 		applicableFs := p.applicableFieldSpecs(id)
+		oldNamespace := r.GetNamespace()
 
 		for _, fs := range applicableFs {
 			err := transformers.MutateField(
@@ -59,10 +70,98 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 		if len(matches) != 1 {
 			return fmt.Errorf("namespace tranformation produces ID conflict: %#v", matches)
 		}
+		if id.IsNamespaceableKind() && r.GetNamespace() != oldNamespace {
+			moved[r] = oldNamespace
+		}
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+	return p.updateReferences(m, moved)
+}
+
+// updateReferences follows the name references to the
+// resources that moved, given with their old namespaces.
+// A reference is a map with a name field, e.g. a subject
+// of a RoleBinding, or the service of a webhook's
+// clientConfig.  If it names a resource that moved, and
+// has a namespace field holding the old namespace, that
+// field gets the new one.
+func (p *plugin) updateReferences(
+	m resmap.ResMap, moved map[*resource.Resource]string) error {
+	for _, referrer := range m.Resources() {
+		for _, target := range p.NameReference {
+			for _, fs := range target.FieldSpecs {
+				if fs.Pattern != "" || !referrer.OrgId().IsSelected(&fs.Gvk) {
+					// References in text are rewritten
+					// once names are final.
+					continue
+				}
+				path := fs.PathSlice()
+				if path[len(path)-1] == "name" {
+					// The name is a string in the map
+					// holding the reference, e.g. the
+					// service of an APIService.
+					path = path[:len(path)-1]
+				}
+				if len(path) == 0 {
+					continue
+				}
+				err := transformers.MutateField(
+					referrer.Map(), path, false,
+					p.updateReference(target.Gvk, moved))
+				if err != nil {
+					return err
+				}
+			}
+		}
 	}
 	return nil
 }
 
+func (p *plugin) updateReference(
+	target gvk.Gvk,
+	moved map[*resource.Resource]string) func(in interface{}) (interface{}, error) {
+	update := func(ref map[string]interface{}) {
+		name, ok := ref["name"].(string)
+		if !ok {
+			return
+		}
+		ns, ok := ref["namespace"].(string)
+		if !ok {
+			return
+		}
+		for r, oldNamespace := range moved {
+			if r.OrgId().IsSelected(&target) &&
+				(r.GetOriginalName() == name || r.GetName() == name) &&
+				effectiveNamespace(oldNamespace) == effectiveNamespace(ns) {
+				ref["namespace"] = r.GetNamespace()
+				return
+			}
+		}
+	}
+	return func(in interface{}) (interface{}, error) {
+		switch v := in.(type) {
+		case map[string]interface{}:
+			update(v)
+		case []interface{}:
+			for _, item := range v {
+				if ref, ok := item.(map[string]interface{}); ok {
+					update(ref)
+				}
+			}
+		}
+		return in, nil
+	}
+}
+
+func effectiveNamespace(ns string) string {
+	if ns == "" {
+		return resid.DefaultNamespace
+	}
+	return ns
+}
+
 const metaNamespace = "metadata/namespace"
 
 // Special casing metadata.namespace since
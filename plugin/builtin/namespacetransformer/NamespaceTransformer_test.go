@@ -279,3 +279,96 @@ metadata:
 		t.Fatalf("unexpected error: %s", err.Error())
 	}
 }
+
+func TestNamespaceTransformerFollowsNameReferences(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "NamespaceTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: NamespaceTransformer
+metadata:
+  name: notImportantHere
+  namespace: shop
+fieldSpecs:
+- path: metadata/namespace
+  create: true
+nameReference:
+- kind: Service
+  version: v1
+  FieldSpecs:
+  - path: spec/service/name
+    kind: APIService
+  - path: webhooks/clientConfig/service
+    kind: ValidatingWebhookConfiguration
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: web
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: v1.example.com
+spec:
+  service:
+    name: svc
+    namespace: web
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: vwc
+webhooks:
+- name: mine
+  clientConfig:
+    service:
+      name: svc
+      namespace: web
+- name: elsewhere
+  clientConfig:
+    service:
+      name: svc
+      namespace: kube-system
+`)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: svc
+  namespace: shop
+---
+apiVersion: apiregistration.k8s.io/v1
+kind: APIService
+metadata:
+  name: v1.example.com
+spec:
+  service:
+    name: svc
+    namespace: shop
+---
+apiVersion: admissionregistration.k8s.io/v1beta1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: vwc
+webhooks:
+- clientConfig:
+    service:
+      name: svc
+      namespace: shop
+  name: mine
+- clientConfig:
+    service:
+      name: svc
+      namespace: kube-system
+  name: elsewhere
+`)
+}
@@ -23,6 +23,7 @@ type plugin struct {
 	Path         string          `json:"path,omitempty" yaml:"path,omitempty"`
 	Patch        string          `json:"patch,omitempty" yaml:"patch,omitempty"`
 	Target       *types.Selector `json:"target,omitempty", yaml:"target,omitempty"`
+	unmatched    bool
 }
 
 //noinspection GoUnusedGlobalVariable
@@ -79,6 +80,7 @@ func (p *plugin) Config(
 }
 
 func (p *plugin) Transform(m resmap.ResMap) error {
+	p.unmatched = false
 	if p.loadedPatch != nil && p.Target == nil {
 		target, err := m.GetById(p.loadedPatch.OrgId())
 		if err != nil {
@@ -99,6 +101,7 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 	if err != nil {
 		return err
 	}
+	p.unmatched = len(resources) == 0
 	for _, resource := range resources {
 		if p.decodedPatch != nil {
 			rawObj, err := resource.MarshalJSON()
@@ -129,6 +132,15 @@ func (p *plugin) Transform(m resmap.ResMap) error {
 	return nil
 }
 
+// Unmatched says if the target selected no resources.
+func (p *plugin) Unmatched() []string {
+	if !p.unmatched {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("patch target %s selects no resources", p.Target)}
+}
+
 // jsonPatchFromBytes loads a Json 6902 patch from
 // a bytes input
 func jsonPatchFromBytes(
@@ -0,0 +1,222 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate go run github.com/irairdon/kustomize/v3/cmd/pluginator
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/irairdon/kustomize/v3/pkg/ifc"
+	"github.com/irairdon/kustomize/v3/pkg/resmap"
+	"github.com/irairdon/kustomize/v3/pkg/resource"
+	"github.com/irairdon/kustomize/v3/pkg/transformers"
+	"github.com/irairdon/kustomize/v3/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Copy the value of a field in one resource into
+// fields of other resources, whole or into one
+// delimited part of a string.
+type plugin struct {
+	Replacements []types.Replacement `json:"replacements,omitempty" yaml:"replacements,omitempty"`
+	unmatched    []string
+}
+
+//noinspection GoUnusedGlobalVariable
+var KustomizePlugin plugin
+
+func (p *plugin) Config(
+	ldr ifc.Loader, rf *resmap.Factory, c []byte) (err error) {
+	p.Replacements = nil
+	err = yaml.Unmarshal(c, p)
+	if err != nil {
+		return err
+	}
+	for _, r := range p.Replacements {
+		if r.Source == nil {
+			return fmt.Errorf("replacement missing source in\n%s", string(c))
+		}
+		for _, t := range r.Targets {
+			if t.Select == nil || len(t.FieldPaths) == 0 {
+				return fmt.Errorf(
+					"replacement target must specify select and fieldPaths in\n%s",
+					string(c))
+			}
+		}
+	}
+	return nil
+}
+
+func (p *plugin) Transform(m resmap.ResMap) error {
+	p.unmatched = nil
+	for _, r := range p.Replacements {
+		value, err := p.sourceValue(m, r.Source)
+		if err != nil {
+			return err
+		}
+		for _, t := range r.Targets {
+			err = p.replace(m, value, t)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *plugin) sourceValue(
+	m resmap.ResMap, s *types.ReplSource) (interface{}, error) {
+	matches, err := m.Select(s.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) != 1 {
+		return nil, fmt.Errorf(
+			"replacement source %s must select one resource, but selects %d",
+			s.Selector, len(matches))
+	}
+	path := s.FieldPath
+	if path == "" {
+		path = "metadata.name"
+	}
+	values, err := transformers.LookupField(matches[0].Map(), path)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf(
+			"replacement source field %s must name one field in %s, but names %d",
+			path, matches[0].OrgId(), len(values))
+	}
+	value := values[0]
+	if s.Options == nil || s.Options.Delimiter == "" {
+		return value, nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf(
+			"replacement source field %s in %s must be a string to use a delimiter",
+			path, matches[0].OrgId())
+	}
+	parts := strings.Split(str, s.Options.Delimiter)
+	if s.Options.Index < 0 || s.Options.Index >= len(parts) {
+		return nil, fmt.Errorf(
+			"replacement source field %s in %s has no part %d split by %q",
+			path, matches[0].OrgId(), s.Options.Index, s.Options.Delimiter)
+	}
+	return parts[s.Options.Index], nil
+}
+
+func (p *plugin) replace(
+	m resmap.ResMap, value interface{}, t *types.ReplTarget) error {
+	selected, err := m.Select(*t.Select)
+	if err != nil {
+		return err
+	}
+	rejected := make(map[*resource.Resource]bool)
+	for _, r := range t.Reject {
+		matches, err := m.Select(*r)
+		if err != nil {
+			return err
+		}
+		for _, res := range matches {
+			rejected[res] = true
+		}
+	}
+	options := t.Options
+	if options == nil {
+		options = &types.FieldOptions{}
+	}
+	fn := func(in interface{}) (interface{}, error) {
+		return substitute(in, value, options)
+	}
+	found := false
+	for _, res := range selected {
+		if rejected[res] {
+			continue
+		}
+		found = true
+		for _, path := range t.FieldPaths {
+			n, err := transformers.MutateFieldPath(
+				res.Map(), path, options.Create, fn)
+			if err != nil {
+				return fmt.Errorf(
+					"replacement target %s: %v", res.OrgId(), err)
+			}
+			if n == 0 {
+				return fmt.Errorf(
+					"replacement target %s has no field %s; "+
+						"set options.create to add it", res.OrgId(), path)
+			}
+		}
+	}
+	if !found {
+		p.unmatched = append(p.unmatched, fmt.Sprintf(
+			"replacement target %s selects no resources", t.Select))
+	}
+	return nil
+}
+
+// Unmatched lists the targets that selected no resources.
+func (p *plugin) Unmatched() []string {
+	return p.unmatched
+}
+
+// substitute returns the value to put in a field,
+// given what's there now.
+func substitute(
+	in interface{}, value interface{},
+	options *types.FieldOptions) (interface{}, error) {
+	if options.Delimiter == "" {
+		return deepCopy(value), nil
+	}
+	var str string
+	switch v := value.(type) {
+	case string, bool, int64, float64:
+		str = fmt.Sprint(v)
+	default:
+		return nil, fmt.Errorf(
+			"%#v can't be put in part of a string", value)
+	}
+	if in == nil {
+		return str, nil
+	}
+	current, ok := in.(string)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%#v must be a string to use a delimiter", in)
+	}
+	parts := strings.Split(current, options.Delimiter)
+	switch {
+	case options.Index < 0:
+		parts = append([]string{str}, parts...)
+	case options.Index >= len(parts):
+		parts = append(parts, str)
+	default:
+		parts[options.Index] = str
+	}
+	return strings.Join(parts, options.Delimiter), nil
+}
+
+// deepCopy copies maps and lists, so that targets
+// don't share them with the source.
+func deepCopy(in interface{}) interface{} {
+	switch v := in.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = deepCopy(e)
+		}
+		return out
+	default:
+		return in
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2019 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package main_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/irairdon/kustomize/v3/pkg/kusttest"
+	plugins_test "github.com/irairdon/kustomize/v3/pkg/plugins/test"
+)
+
+const replacementTestResources = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: prod
+spec:
+  ports:
+  - name: sql
+    port: 5432
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1
+        env:
+        - name: DB_URL
+          value: postgres://localhost:5432/shop
+      - name: proxy
+        image: proxy:1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  labels:
+    legacy: "true"
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1
+`
+
+func TestReplacementTransformer(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ReplacementTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ReplacementTransformer
+metadata:
+  name: notImportantHere
+replacements:
+- source:
+    kind: Service
+    name: db
+  targets:
+  - select:
+      kind: Deployment
+      name: web
+    fieldPaths:
+    - spec.template.spec.containers[name=app].env[name=DB_URL].value
+    options:
+      delimiter: /
+      index: 2
+  - select:
+      kind: Deployment
+    reject:
+    - labelSelector: legacy=true
+    fieldPaths:
+    - spec.template.metadata.annotations.[example.com/db]
+    options:
+      create: true
+- source:
+    kind: Service
+    name: db
+    fieldPath: spec.ports[name=sql].port
+  targets:
+  - select:
+      name: web
+    fieldPaths:
+    - spec.template.spec.containers[name=app].env[name=DB_PORT].value
+    options:
+      create: true
+- source:
+    kind: Deployment
+    name: web
+    fieldPath: spec.template.spec.containers[name=app].image
+    options:
+      delimiter: ":"
+      index: 1
+  targets:
+  - select:
+      name: w.*
+    fieldPaths:
+    - spec.template.spec.containers.image
+    options:
+      delimiter: ":"
+      index: 1
+`, replacementTestResources)
+
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: db
+  namespace: prod
+spec:
+  ports:
+  - name: sql
+    port: 5432
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      annotations:
+        example.com/db: db
+    spec:
+      containers:
+      - env:
+        - name: DB_URL
+          value: postgres://db/shop
+        - name: DB_PORT
+          value: 5432
+        image: app:1
+        name: app
+      - image: proxy:1
+        name: proxy
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    legacy: "true"
+  name: worker
+spec:
+  template:
+    spec:
+      containers:
+      - image: app:1
+        name: app
+`)
+}
+
+func TestReplacementTransformerErrors(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin(
+		"builtin", "", "ReplacementTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	var cases = []struct {
+		config   string
+		expected string
+	}{
+		{`
+replacements:
+- source:
+    kind: Deployment
+  targets:
+  - select:
+      kind: Service
+    fieldPaths:
+    - metadata.name
+`, "must select one resource, but selects 2"},
+		{`
+replacements:
+- source:
+    name: db
+    fieldPath: spec.clusterIP
+  targets: []
+`, "must name one field in ~G_v1_Service|prod|db, but names 0"},
+		{`
+replacements:
+- source:
+    name: db
+  targets:
+  - select:
+      name: web
+    fieldPaths:
+    - spec.template.spec.containers[name=app].args
+`, "has no field spec.template.spec.containers[name=app].args"},
+		{`
+replacements:
+- source:
+    name: db
+  targets:
+  - fieldPaths:
+    - metadata.name
+`, "must specify select and fieldPaths"},
+	}
+	for _, c := range cases {
+		err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: ReplacementTransformer
+metadata:
+  name: notImportantHere
+`+c.config, replacementTestResources)
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("expected error containing %q, got %v", c.expected, err)
+		}
+	}
+}
@@ -233,3 +233,191 @@ spec:
 		t.Fatalf("Unexpected error: %v", err)
 	}
 }
+
+func TestSelectorAndHPA(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin("builtin", "", "ReplicaCountTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ReplicaCountTransformer
+metadata:
+  name: notImportantHere
+replica:
+  selector:
+    labelSelector: tier=frontend
+  count: 4
+  minReplicas: 2
+  maxReplicas: 10
+fieldSpecs:
+- path: spec/replicas
+  create: true
+  kind: Deployment
+minReplicasFieldSpecs:
+- path: spec/minReplicas
+  create: true
+  kind: HorizontalPodAutoscaler
+maxReplicasFieldSpecs:
+- path: spec/maxReplicas
+  create: true
+  kind: HorizontalPodAutoscaler
+`, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    tier: frontend
+spec:
+  replicas: 1
+---
+apiVersion: autoscaling/v1
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+  labels:
+    tier: frontend
+spec:
+  minReplicas: 1
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+  labels:
+    tier: backend
+spec:
+  replicas: 1
+`)
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    tier: frontend
+  name: web
+spec:
+  replicas: 4
+---
+apiVersion: autoscaling/v1
+kind: HorizontalPodAutoscaler
+metadata:
+  labels:
+    tier: frontend
+  name: web
+spec:
+  maxReplicas: 10
+  minReplicas: 2
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    tier: backend
+  name: db
+spec:
+  replicas: 1
+`)
+}
+
+// Setting only the bounds of an autoscaler leaves
+// the count of what it scales alone.
+func TestHPAOnly(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin("builtin", "", "ReplicaCountTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	rm := th.LoadAndRunTransformer(`
+apiVersion: builtin
+kind: ReplicaCountTransformer
+metadata:
+  name: notImportantHere
+replica:
+  name: web
+  maxReplicas: 5
+fieldSpecs:
+- path: spec/replicas
+  create: true
+  kind: Deployment
+minReplicasFieldSpecs:
+- path: spec/minReplicas
+  create: true
+  kind: HorizontalPodAutoscaler
+maxReplicasFieldSpecs:
+- path: spec/maxReplicas
+  create: true
+  kind: HorizontalPodAutoscaler
+`, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+---
+apiVersion: autoscaling/v1
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+`)
+	th.AssertActualEqualsExpected(rm, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+---
+apiVersion: autoscaling/v1
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+spec:
+  maxReplicas: 5
+`)
+}
+
+func TestSelectorNoMatch(t *testing.T) {
+	tc := plugins_test.NewEnvForTest(t).Set()
+	defer tc.Reset()
+
+	tc.BuildGoPlugin("builtin", "", "ReplicaCountTransformer")
+
+	th := kusttest_test.NewKustTestPluginHarness(t, "/app")
+
+	err := th.ErrorFromLoadAndRunTransformer(`
+apiVersion: builtin
+kind: ReplicaCountTransformer
+metadata:
+  name: notImportantHere
+replica:
+  selector:
+    kind: Deployment
+    labelSelector: tier=frontend
+  count: 3
+fieldSpecs:
+- path: spec/replicas
+  create: true
+  kind: Deployment
+`, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: db
+`)
+	if err == nil {
+		t.Fatalf("No match should return an error")
+	}
+	if err.Error() !=
+		"Resource selected by {kind=Deployment, labelSelector=tier=frontend} "+
+			"does not match a config with the following GVK [~G_~V_Deployment]" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}